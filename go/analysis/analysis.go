@@ -47,6 +47,22 @@ type Analyzer struct {
 	// serializable.
 	Run func(*Pass) (interface{}, error)
 
+	// RunProgram, if non-nil, is called once, after Run has been
+	// applied to every package of the program, to perform checks
+	// that require a global view, such as reporting exported
+	// functions that are never called. The driver passes it the
+	// results and facts of all the packages analyzed by Run.
+	//
+	// Only drivers that analyze a whole program in a single
+	// process call RunProgram: the checker used by singlechecker
+	// and multichecker, analysistest, and gopls. The unitchecker
+	// driver used by "go vet" analyzes each package in a separate
+	// process and never calls it. The checker and analysistest
+	// treat the packages named by their patterns as the program,
+	// so a run on a subset of a module, such as ./sub/..., sees
+	// only part of it; see ProgramPass.
+	RunProgram func(*ProgramPass) error
+
 	// RunDespiteErrors allows the driver to invoke
 	// the Run method of this analyzer even on a
 	// package that contains parse or type errors.
@@ -150,6 +166,81 @@ type Pass struct {
 	/* Further fields may be added in future. */
 }
 
+// A ProgramPass provides information to the RunProgram function that
+// applies a specific analyzer to a whole program, after its Run
+// function has been applied to each package of the program.
+//
+// The program is the set of packages that the driver was asked to
+// analyze, with their dependencies. The checker used by singlechecker
+// and multichecker, and analysistest, take it to be the packages
+// matched by their patterns, whatever those are: a RunProgram function
+// that reports, say, functions never called sees no callers outside
+// them. gopls calls RunProgram only when it analyzes every package of
+// the workspace, never for a partial batch.
+//
+// The RunProgram function should not call any of the ProgramPass
+// functions concurrently.
+type ProgramPass struct {
+	Analyzer *Analyzer      // the identity of the current analyzer
+	Fset     *token.FileSet // file position information
+
+	// Packages holds, in unspecified order, each package of the
+	// program to which the analyzer's Run function was applied
+	// successfully: the packages requested by the user and, if
+	// the analyzer uses facts, their dependencies.
+	Packages []*ProgramPackage
+
+	// Report reports a Diagnostic, a finding about a specific
+	// location in the analyzed source code, which may belong to
+	// any package of the program.
+	Report func(Diagnostic)
+
+	// AllPackageFacts returns a new slice containing all package
+	// facts of the analysis's FactTypes, across all packages, in
+	// unspecified order.
+	AllPackageFacts func() []PackageFact
+
+	// AllObjectFacts returns a new slice containing all object
+	// facts of the analysis's FactTypes, across all packages, in
+	// unspecified order.
+	AllObjectFacts func() []ObjectFact
+
+	/* Further fields may be added in future. */
+}
+
+// A ProgramPackage describes one package of a program and the outcome
+// of applying an analyzer's Run function to it.
+//
+// Drivers that cache the results of analysis, such as gopls, may not
+// retain the syntax, type information or Run result of a package, in
+// which case the corresponding fields are nil. A RunProgram function
+// should rely only on Pkg and on facts if it must work with all drivers.
+type ProgramPackage struct {
+	Pkg       *types.Package // type information about the package
+	Root      bool           // the package was requested, not just a dependency
+	Files     []*ast.File    // the abstract syntax tree of each file, if retained
+	TypesInfo *types.Info    // type information about the syntax trees, if retained
+	Result    interface{}    // the result of Run, if retained
+}
+
+// Reportf is a helper function that reports a Diagnostic using the
+// specified position and formatted error message.
+func (pass *ProgramPass) Reportf(pos token.Pos, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	pass.Report(Diagnostic{Pos: pos, Message: msg})
+}
+
+// ReportRangef is a helper function that reports a Diagnostic using the
+// range provided.
+func (pass *ProgramPass) ReportRangef(rng Range, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	pass.Report(Diagnostic{Pos: rng.Pos(), End: rng.End(), Message: msg})
+}
+
+func (pass *ProgramPass) String() string {
+	return fmt.Sprintf("%s@program", pass.Analyzer.Name)
+}
+
 // PackageFact is a package together with an associated fact.
 type PackageFact struct {
 	Package *types.Package
@@ -13,6 +13,7 @@ import (
 	"go/types"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -268,6 +269,157 @@ func applyDiffsAndCompare(src, golden []byte, edits []diff.Edit, fileName string
 	return nil
 }
 
+// RunTxtar behaves like RunWithSuggestedFixes, but reads the test case
+// from the named txtar archive, which it extracts into a temporary
+// directory, instead of from a testdata tree.
+//
+// If the archive contains a go.mod or go.work file at its root, the
+// packages are loaded in module mode; otherwise, the archive is a
+// GOPATH-style tree, whose packages are under src/. In module mode,
+// other modules of the archive, for instance those required by the
+// main module, are resolved by replace directives of the main module
+// or by the go.work file; the module proxy is disabled. If no patterns
+// are given, RunTxtar analyzes the packages matched by "./..." in
+// module mode, and every package under src/ in GOPATH mode.
+//
+// The expectations of diagnostics and facts are specified by '// want'
+// comments in the source files, as for Run. The expected contents of a
+// file after applying the suggested fixes are given by archive files
+// named after the source file, with a ".golden" suffix. A golden file
+// named "a/a.go.golden" holds the result of applying all the fixes of
+// a/a.go, and one named "a/a.go.golden/Message" holds the result of
+// applying only the fixes whose SuggestedFix.Message is Message. The
+// two forms may not be used for the same source file. For example:
+//
+//	-- go.mod --
+//	module example.com
+//
+//	go 1.18
+//	-- a/a.go --
+//	package a
+//
+//	func f() { bar() } // want "call of bar"
+//	-- a/a.go.golden/Rename bar to baz --
+//	package a
+//
+//	func f() { baz() } // want "call of bar"
+//
+// RunTxtar reports an error for each golden file for which no fix was
+// suggested. If the archive contains no golden files, suggested fixes
+// are not checked.
+func RunTxtar(t Testing, a *analysis.Analyzer, filename string, patterns ...string) []*Result {
+	ar, err := txtar.ParseFile(filename)
+	if err != nil {
+		t.Errorf("%v", err)
+		return nil
+	}
+	dir, err := os.MkdirTemp("", "analysistest")
+	if err != nil {
+		t.Errorf("%v", err)
+		return nil
+	}
+	defer os.RemoveAll(dir)
+
+	// Extract the archive, grouping the golden files by source file.
+	golden := make(map[string]*txtar.Archive) // source file -> golden archive
+	combined := make(map[string]bool)         // source files with a single golden file
+	var goldenNames []string
+	for _, f := range ar.Files {
+		src, message, ok := strings.Cut(f.Name, ".golden/")
+		if !ok {
+			src = strings.TrimSuffix(f.Name, ".golden")
+			ok = src != f.Name
+		}
+		if !ok {
+			if err := writeFile(filepath.Join(dir, filepath.FromSlash(f.Name)), f.Data); err != nil {
+				t.Errorf("%v", err)
+				return nil
+			}
+			continue
+		}
+		g, seen := golden[src]
+		if !seen {
+			g = new(txtar.Archive)
+			golden[src] = g
+			goldenNames = append(goldenNames, src)
+		}
+		if seen && (combined[src] || message == "") {
+			t.Errorf("%s: %s has several golden files, or both %s.golden and %s.golden/...", filename, src, src, src)
+			continue
+		}
+		if message == "" {
+			combined[src] = true
+			g.Comment = f.Data
+		} else {
+			g.Files = append(g.Files, txtar.File{Name: message, Data: f.Data})
+		}
+	}
+	for src, g := range golden {
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(src)+".golden"), txtar.Format(g)); err != nil {
+			t.Errorf("%v", err)
+			return nil
+		}
+	}
+
+	if len(patterns) == 0 {
+		patterns = txtarPatterns(ar)
+	}
+	if len(golden) == 0 {
+		return Run(t, dir, a, patterns...)
+	}
+	results := RunWithSuggestedFixes(t, dir, a, patterns...)
+
+	// Check that each golden file (or section) was used.
+	fixed := make(map[string]map[string]bool) // source file -> messages of its fixes
+	for _, r := range results {
+		if r.Pass == nil {
+			continue
+		}
+		for _, diag := range r.Diagnostics {
+			for _, sf := range diag.SuggestedFixes {
+				for _, edit := range sf.TextEdits {
+					if file := r.Pass.Fset.File(edit.Pos); file != nil {
+						rel, err := filepath.Rel(dir, file.Name())
+						if err != nil {
+							continue
+						}
+						rel = filepath.ToSlash(rel)
+						if fixed[rel] == nil {
+							fixed[rel] = make(map[string]bool)
+						}
+						fixed[rel][sf.Message] = true
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(goldenNames)
+	for _, src := range goldenNames {
+		g := golden[src]
+		if combined[src] {
+			if fixed[src] == nil {
+				t.Errorf("%s: no suggested fixes for %s.golden", filename, src)
+			}
+			continue
+		}
+		for _, f := range g.Files {
+			if !fixed[src][f.Name] {
+				t.Errorf("%s: no suggested fix %q for %s.golden/%s", filename, f.Name, src, f.Name)
+			}
+		}
+	}
+	return results
+}
+
+// writeFile writes data to the named file, creating its directory if
+// necessary.
+func writeFile(filename string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0666)
+}
+
 // Run applies an analysis to the packages denoted by the "go list" patterns.
 //
 // It loads the packages from the specified
@@ -337,6 +489,28 @@ func Run(t Testing, dir string, a *analysis.Analyzer, patterns ...string) []*Res
 	return results
 }
 
+// txtarPatterns returns the default patterns of RunTxtar for the
+// extracted archive ar: "./..." in module mode, or in GOPATH mode, as
+// for Run, the import path of each package of the archive under src/.
+func txtarPatterns(ar *txtar.Archive) []string {
+	var pkgs []string
+	seen := make(map[string]bool)
+	for _, f := range ar.Files {
+		if f.Name == "go.mod" || f.Name == "go.work" {
+			return []string{"./..."}
+		}
+		if !strings.HasSuffix(f.Name, ".go") || !strings.HasPrefix(f.Name, "src/") {
+			continue
+		}
+		if pkg := path.Dir(strings.TrimPrefix(f.Name, "src/")); pkg != "." && !seen[pkg] {
+			seen[pkg] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
 // A Result holds the result of applying an analyzer to a package.
 type Result = checker.TestAnalyzerResult
 
@@ -350,6 +524,8 @@ func loadPackages(a *analysis.Analyzer, dir string, patterns ...string) ([]*pack
 	// Undocumented module mode. Will be replaced by something better.
 	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
 		env = []string{"GO111MODULE=on", "GOPROXY=off"} // module mode
+	} else if _, err := os.Stat(filepath.Join(dir, "go.work")); err == nil {
+		env = []string{"GO111MODULE=on", "GOPROXY=off", "GOWORK="} // workspace mode
 	}
 
 	// packages.Load loads the real standard library, not a minimal
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -164,3 +165,115 @@ type errorfunc func(string)
 func (f errorfunc) Errorf(format string, args ...interface{}) {
 	f(fmt.Sprintf(format, args...))
 }
+
+// TestRunTxtar tests RunTxtar on a multi-module archive.
+func TestRunTxtar(t *testing.T) {
+	testenv.NeedsTool(t, "go")
+
+	findcall.Analyzer.Flags.Set("name", "println")
+
+	const archive = `
+-- go.mod --
+module example.com/a
+
+go 1.18
+
+require example.com/b v0.0.0
+
+replace example.com/b => ./b
+-- b/go.mod --
+module example.com/b
+
+go 1.18
+-- b/b.go --
+package b
+
+func F() int { return 1 }
+-- a/a.go --
+package a // want package:"found"
+
+import "example.com/b"
+
+func println(...interface{}) {} // want println:"found"
+
+func f() {
+	println(b.F()) // want "call of println"
+}
+-- a/a.go.golden/Add '_TEST_' --
+package a // want package:"found"
+
+import "example.com/b"
+
+func println(...interface{}) {} // want println:"found"
+
+func f() {
+	println_TEST_(b.F()) // want "call of println"
+}
+-- c/c.go --
+package c
+
+func g() {}
+-- c/c.go.golden --
+package c
+
+func g() {}
+`
+	filename := filepath.Join(t.TempDir(), "test.txtar")
+	if err := os.WriteFile(filename, []byte(archive), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	t2 := errorfunc(func(s string) { got = append(got, s) }) // a fake *testing.T
+	analysistest.RunTxtar(t2, findcall.Analyzer, filename)
+
+	want := []string{
+		filename + ": no suggested fixes for c/c.go.golden",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%s\nwant:\n%s",
+			strings.Join(got, "\n"),
+			strings.Join(want, "\n"))
+	}
+}
+
+// TestRunTxtarGOPATH tests RunTxtar on a GOPATH-style archive, whose
+// packages are analyzed by default.
+func TestRunTxtarGOPATH(t *testing.T) {
+	testenv.NeedsTool(t, "go")
+
+	findcall.Analyzer.Flags.Set("name", "println")
+	t.Cleanup(func() { findcall.Analyzer.Flags.Set("name", "") })
+
+	const archive = `
+-- src/a/a.go --
+package a // want package:"found"
+
+import "b"
+
+func println(...interface{}) {} // want println:"found"
+
+func f() {
+	println(b.F()) // want "call of println"
+}
+-- src/a/a.go.golden --
+package a // want package:"found"
+
+import "b"
+
+func println(...interface{}) {} // want println:"found"
+
+func f() {
+	println_TEST_(b.F()) // want "call of println"
+}
+-- src/b/b.go --
+package b
+
+func F() int { return 1 }
+`
+	filename := filepath.Join(t.TempDir(), "test.txtar")
+	if err := os.WriteFile(filename, []byte(archive), 0666); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.RunTxtar(t, findcall.Analyzer, filename)
+}
@@ -4,7 +4,10 @@
 
 package analysis
 
-import "go/token"
+import (
+	"fmt"
+	"go/token"
+)
 
 // A Diagnostic is a message associated with a source location or range.
 //
@@ -14,11 +17,16 @@ import "go/token"
 //
 // If End is provided, the diagnostic is specified to apply to the range between
 // Pos and End.
+//
+// The optional Severity and Tags describe how a driver should present
+// the diagnostic; see Severity and Tag.
 type Diagnostic struct {
 	Pos      token.Pos
 	End      token.Pos // optional
 	Category string    // optional
 	Message  string
+	Severity Severity // optional
+	Tags     []Tag    // optional
 
 	// URL is the optional location of a web page that provides
 	// additional documentation for this diagnostic.
@@ -41,6 +49,55 @@ type Diagnostic struct {
 	Related []RelatedInformation // optional
 }
 
+// A Severity is the seriousness of a diagnostic.
+//
+// The zero value, SeverityUnspecified, leaves the choice to the driver,
+// which typically treats the diagnostic as a warning.
+type Severity int
+
+const (
+	SeverityUnspecified Severity = iota
+	SeverityError                // a definite mistake
+	SeverityWarning              // a likely mistake
+	SeverityInfo                 // information that may be of interest
+	SeverityHint                 // a suggestion, typically displayed unobtrusively
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityUnspecified:
+		return ""
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// A Tag is additional information about the code reported by a
+// diagnostic, which a driver may use to change the way it is displayed.
+type Tag int
+
+const (
+	TagUnnecessary Tag = iota + 1 // unused or unnecessary code, which may be faded out
+	TagDeprecated                 // use of a deprecated symbol, which may be struck through
+)
+
+func (t Tag) String() string {
+	switch t {
+	case TagUnnecessary:
+		return "unnecessary"
+	case TagDeprecated:
+		return "deprecated"
+	}
+	return fmt.Sprintf("Tag(%d)", int(t))
+}
+
 // RelatedInformation contains information related to a diagnostic.
 // For example, a diagnostic that flags duplicated declarations of a
 // variable may include one RelatedInformation per existing
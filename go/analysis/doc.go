@@ -79,6 +79,7 @@ The Analyzer type has more fields besides those shown above:
 		Doc              string
 		Flags            flag.FlagSet
 		Run              func(*Pass) (interface{}, error)
+		RunProgram       func(*ProgramPass) error
 		RunDespiteErrors bool
 		ResultType       reflect.Type
 		Requires         []*Analyzer
@@ -110,7 +111,8 @@ acyclic, its fact and result types are unique, and so on.
 
 Finally, the Run field contains a function to be called by the driver to
 execute the analysis on a single package. The driver passes it an
-instance of the Pass type.
+instance of the Pass type. The optional RunProgram field is discussed in
+the section on Whole-program analysis.
 
 # Pass
 
@@ -281,6 +283,42 @@ this fact is built in to the analyzer so that it correctly checks
 calls to log.Printf even when run in a driver that does not apply
 it to standard packages. We would like to remove this limitation in future.
 
+# Whole-program analysis
+
+Some checks cannot be made one package at a time, because they require
+a view of the whole program: for example, reporting an exported
+function that is never called, or an interface that has a single
+implementation. An Analyzer may provide a RunProgram function for such
+checks. The driver calls it once, after applying Run to every package,
+passing it a ProgramPass:
+
+	type ProgramPass struct {
+		Analyzer        *Analyzer
+		Fset            *token.FileSet
+		Packages        []*ProgramPackage
+		Report          func(Diagnostic)
+		AllPackageFacts func() []PackageFact
+		AllObjectFacts  func() []ObjectFact
+	}
+
+Each ProgramPackage holds the type information about a package and, if
+the driver retained them, its syntax, its detailed type information,
+and the result of Run on it. Facts, which every driver retains, are the
+portable way for Run to pass information to RunProgram: Run records
+what it learns about each package, such as the calls it makes, and
+RunProgram combines the facts of all packages.
+
+Drivers that analyze each package in a separate process, such as the
+unitchecker used by "go vet", cannot call RunProgram, and do not.
+gopls calls it only when it is analyzing the whole workspace, as a
+check such as "this function is never called" is wrong if the callers
+were omitted. The checker of singlechecker and multichecker, however,
+treats the packages matched by its command-line patterns as the
+program: run on ./sub/..., it reports as never called a function whose
+only callers are elsewhere in the module.
+An Analyzer with a RunProgram function should therefore report from Run
+all the diagnostics that it can compute package by package.
+
 # Testing an Analyzer
 
 The analysistest subpackage provides utilities for testing an Analyzer.
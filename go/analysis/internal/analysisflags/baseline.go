@@ -0,0 +1,209 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// This file implements baselines: files that record the findings that
+// existed when an analyzer was enabled, so that only new ones are
+// reported.
+//
+// A baseline file holds one JSON-encoded BaselineEntry per line.
+// An entry identifies a diagnostic without reference to its line and
+// column, so that it survives unrelated edits to its file: instead, its
+// fingerprint is derived from the name of the file and the text of the
+// line on which the diagnostic starts, ignoring spacing.
+//
+// With -write-baseline, the checker driver replaces the file named by
+// -baseline with the diagnostics of all its packages. The unitchecker
+// driver instead appends the diagnostics of its package to the file:
+// go vet analyzes each package in a separate process, so this is the only
+// way to accumulate a baseline for many packages. Remove the file before
+// rewriting it with go vet. Since go vet runs the analysis of each package
+// in the directory of the package, the unitchecker driver requires the name
+// of the file to be absolute.
+
+// A BaselineEntry records a diagnostic in a baseline file.
+type BaselineEntry struct {
+	Analyzer    string `json:"analyzer"`
+	Package     string `json:"package"` // package ID
+	Fingerprint string `json:"fingerprint"`
+	Message     string `json:"message"`
+}
+
+// A Baseline is a set of recorded diagnostics.
+type Baseline struct {
+	// Overlay, if non-nil, maps the names of files to contents that
+	// replace those on disk when computing fingerprints.
+	Overlay map[string][]byte
+
+	fset      *token.FileSet
+	remaining map[BaselineEntry]int // number of unmatched occurrences of each entry
+	checked   map[[2]string]bool    // (package, analyzer) pairs compared with the baseline
+	lines     map[string][][]byte   // lines of each file, read on demand
+}
+
+// NewBaseline returns an empty baseline for the diagnostics of the files
+// of fset.
+func NewBaseline(fset *token.FileSet) *Baseline {
+	return &Baseline{
+		fset:      fset,
+		remaining: make(map[BaselineEntry]int),
+		checked:   make(map[[2]string]bool),
+		lines:     make(map[string][][]byte),
+	}
+}
+
+// ReadBaseline returns the baseline recorded in the named file.
+func ReadBaseline(fset *token.FileSet, filename string) (*Baseline, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	b := NewBaseline(fset)
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e BaselineEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid baseline entry: %v", filename, i+1, err)
+		}
+		b.remaining[e]++
+	}
+	return b, nil
+}
+
+// Entry returns the baseline entry for a diagnostic reported by the
+// named analyzer in the package with the given ID.
+func (b *Baseline) Entry(pkg, analyzer string, diag analysis.Diagnostic) BaselineEntry {
+	return BaselineEntry{
+		Analyzer:    analyzer,
+		Package:     pkg,
+		Fingerprint: b.fingerprint(diag.Pos),
+		Message:     diag.Message,
+	}
+}
+
+// fingerprint returns a digest of the base name of the file containing
+// pos and of the text of its line, with spacing normalized.
+func (b *Baseline) fingerprint(pos token.Pos) string {
+	posn := b.fset.PositionFor(pos, false)
+	lines, ok := b.lines[posn.Filename]
+	if !ok {
+		data, ok := b.Overlay[posn.Filename]
+		if !ok {
+			data, _ = os.ReadFile(posn.Filename)
+		}
+		if data != nil {
+			lines = bytes.Split(data, []byte("\n"))
+		}
+		b.lines[posn.Filename] = lines
+	}
+	var text string
+	if 0 < posn.Line && posn.Line <= len(lines) {
+		text = strings.Join(strings.Fields(string(lines[posn.Line-1])), " ")
+	}
+	h := sha256.Sum256([]byte(filepath.Base(posn.Filename) + "\n" + text))
+	return fmt.Sprintf("%x", h[:8])
+}
+
+// Filter returns the diagnostics reported by the named analyzer in the
+// package with the given ID that are not recorded in the baseline. Each
+// entry matches as many diagnostics as it occurs in the baseline.
+func (b *Baseline) Filter(pkg, analyzer string, diags []analysis.Diagnostic) []analysis.Diagnostic {
+	b.checked[[2]string{pkg, analyzer}] = true
+	var kept []analysis.Diagnostic
+	for _, diag := range diags {
+		e := b.Entry(pkg, analyzer, diag)
+		if b.remaining[e] > 0 {
+			b.remaining[e]--
+			continue
+		}
+		kept = append(kept, diag)
+	}
+	return kept
+}
+
+// Stale returns, in a deterministic order, the entries of the baseline
+// for packages and analyzers that were compared with it, by Filter, but
+// that matched no diagnostic. Such entries are typically the findings
+// that have since been fixed, and may be removed from the baseline.
+func (b *Baseline) Stale() []BaselineEntry {
+	var stale []BaselineEntry
+	for e, n := range b.remaining {
+		if b.checked[[2]string{e.Package, e.Analyzer}] {
+			for ; n > 0; n-- {
+				stale = append(stale, e)
+			}
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		x, y := stale[i], stale[j]
+		if x.Package != y.Package {
+			return x.Package < y.Package
+		}
+		if x.Analyzer != y.Analyzer {
+			return x.Analyzer < y.Analyzer
+		}
+		if x.Fingerprint != y.Fingerprint {
+			return x.Fingerprint < y.Fingerprint
+		}
+		return x.Message < y.Message
+	})
+	return stale
+}
+
+// SaveBaseline writes the entries to the named baseline file, replacing
+// its contents.
+func SaveBaseline(filename string, entries []BaselineEntry) error {
+	return os.WriteFile(filename, encodeBaseline(entries), 0666)
+}
+
+// AppendBaseline appends the entries to the named baseline file,
+// creating it if necessary. The entries are written with a single call
+// to Write, so that concurrent drivers do not interleave them.
+func AppendBaseline(filename string, entries []BaselineEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(encodeBaseline(entries)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func encodeBaseline(entries []BaselineEntry) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		enc.Encode(e) // can't fail
+	}
+	return buf.Bytes()
+}
+
+// PrintStale reports the stale entries of the baseline on standard error.
+func PrintStale(filename string, stale []BaselineEntry) {
+	for _, e := range stale {
+		fmt.Fprintf(os.Stderr, "%s: stale baseline entry for %s in %s: %s\n", filename, e.Analyzer, e.Package, e.Message)
+	}
+}
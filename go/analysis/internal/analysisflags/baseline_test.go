@@ -0,0 +1,86 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags_test
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
+)
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "p.go")
+	baseline := filepath.Join(dir, "baseline")
+
+	// diagnose returns a diagnostic for each line of the file that
+	// contains "bad", using a fresh file set as a driver would.
+	diagnose := func(content string) (*token.FileSet, []analysis.Diagnostic) {
+		if err := os.WriteFile(src, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		fset := token.NewFileSet()
+		tf := fset.AddFile(src, -1, len(content))
+		tf.SetLinesForContent([]byte(content))
+		var diags []analysis.Diagnostic
+		for line, text := range strings.Split(content, "\n") {
+			if strings.Contains(text, "bad") {
+				diags = append(diags, analysis.Diagnostic{Pos: tf.LineStart(line + 1), Message: "bad"})
+			}
+		}
+		return fset, diags
+	}
+
+	// Record the baseline.
+	fset, diags := diagnose("package p\nbad(1)\nbad(2)\nbad(2)\n")
+	b := analysisflags.NewBaseline(fset)
+	var entries []analysisflags.BaselineEntry
+	for _, diag := range diags {
+		entries = append(entries, b.Entry("p", "a", diag))
+	}
+	if err := analysisflags.SaveBaseline(baseline, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the recorded findings, fix one, and add new ones.
+	fset, diags = diagnose("package p\n\n// comment\n\tbad(2)\nbad(3)\nbad(2)\nbad(2)\n")
+	b, err := analysisflags.ReadBaseline(fset, baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	for _, diag := range b.Filter("p", "a", diags) {
+		lines = append(lines, fset.Position(diag.Pos).Line)
+	}
+	if want := []int{5, 7}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("new findings on lines %v, want %v", lines, want)
+	}
+	if stale := b.Stale(); len(stale) != 1 || stale[0].Message != "bad" || stale[0].Analyzer != "a" {
+		t.Errorf("Stale() = %v, want the entry for bad(1)", stale)
+	}
+
+	// Entries of other analyzers are not stale unless they were checked.
+	if err := analysisflags.AppendBaseline(baseline, []analysisflags.BaselineEntry{{Analyzer: "b", Package: "p", Message: "m"}}); err != nil {
+		t.Fatal(err)
+	}
+	b, err = analysisflags.ReadBaseline(fset, baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Filter("p", "a", diags)
+	if stale := b.Stale(); len(stale) != 1 {
+		t.Errorf("Stale() = %v, want 1 entry", stale)
+	}
+	b.Filter("p", "b", nil)
+	if stale := b.Stale(); len(stale) != 2 {
+		t.Errorf("Stale() = %v, want 2 entries", stale)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// This file implements -config=file, which reads the configuration of
+// the analyzers from a JSON file, so that it may be shared by checking
+// it in. For example:
+//
+//	{
+//		"analyzers": {
+//			"printf": {
+//				"flags": {"funcs": "Logf,Warnf"},
+//				"severity": "error"
+//			},
+//			"shadow": {"enabled": false},
+//			"nilness": {
+//				"packages": ["example.com/app/..."],
+//				"exclude": ["example.com/app/internal/gen/..."]
+//			}
+//		}
+//	}
+//
+// The "enabled": false setting of an analyzer acts like the -NAME=false
+// flag of a multichecker, and the "flags" setting like its -NAME.flag
+// flags; the flags given on the command line take precedence. Unlike
+// the -NAME flag, "enabled": true does not restrict the multichecker
+// to the analyzers so enabled: it only adds the analyzer to those
+// selected by -NAME flags on the command line, if any. The "packages" and
+// "exclude" settings restrict the packages, by package path patterns as
+// understood by go list, in which the diagnostics of the analyzer are
+// reported; the analyzer still runs on the others, as it may need their
+// facts. The "severity" setting, one of "error", "warning", "info" or
+// "hint", is recorded in JSON and SARIF output in place of the severity
+// of each diagnostic of the analyzer.
+
+// A Config is the contents of a -config file.
+type Config struct {
+	Analyzers map[string]*AnalyzerConfig `json:"analyzers"`
+}
+
+// An AnalyzerConfig is the configuration of an analyzer in a Config.
+type AnalyzerConfig struct {
+	Enabled  *bool                  `json:"enabled,omitempty"`
+	Flags    map[string]interface{} `json:"flags,omitempty"` // values are strings, numbers or booleans
+	Packages []string               `json:"packages,omitempty"`
+	Exclude  []string               `json:"exclude,omitempty"`
+	Severity string                 `json:"severity,omitempty"`
+
+	include, exclude []*regexp.Regexp
+}
+
+// Severities are the valid values of AnalyzerConfig.Severity.
+var Severities = []string{"error", "warning", "info", "hint"}
+
+// ReadConfig reads the named configuration file and checks it against
+// the analyzers: each name must be that of an analyzer, and each flag
+// one of its flags.
+func ReadConfig(filename string, analyzers []*analysis.Analyzer) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	var config Config
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	byName := make(map[string]*analysis.Analyzer)
+	for _, a := range analyzers {
+		byName[a.Name] = a
+	}
+	for name, ac := range config.Analyzers {
+		a := byName[name]
+		if a == nil {
+			return nil, fmt.Errorf("%s: no analyzer named %q", filename, name)
+		}
+		if ac == nil {
+			ac = new(AnalyzerConfig)
+			config.Analyzers[name] = ac
+		}
+		for fname, value := range ac.Flags {
+			if a.Flags.Lookup(fname) == nil {
+				return nil, fmt.Errorf("%s: analyzer %s has no flag %q", filename, name, fname)
+			}
+			switch value.(type) {
+			case string, json.Number, bool:
+			default:
+				// e.g. an array or object, or null.
+				return nil, fmt.Errorf("%s: invalid value for flag %q of analyzer %s; want a string, number or boolean",
+					filename, fname, name)
+			}
+		}
+		if ac.Severity != "" && !contains(Severities, ac.Severity) {
+			return nil, fmt.Errorf("%s: invalid severity %q for analyzer %s; want one of %s",
+				filename, ac.Severity, name, strings.Join(Severities, ", "))
+		}
+		for _, pattern := range ac.Packages {
+			ac.include = append(ac.include, matchPattern(pattern))
+		}
+		for _, pattern := range ac.Exclude {
+			ac.exclude = append(ac.exclude, matchPattern(pattern))
+		}
+	}
+	return &config, nil
+}
+
+// apply sets the flags of the analyzers and the states of their -NAME
+// flags from the configuration, except for those set on the command
+// line, whose names are in set.
+func (config *Config) apply(analyzers []*analysis.Analyzer, multi bool, enabled map[*analysis.Analyzer]*triState, set map[string]bool) error {
+	for _, a := range analyzers {
+		ac := config.Analyzers[a.Name]
+		if ac == nil {
+			continue
+		}
+		// "enabled": true is additive (see addedBy), so only
+		// "enabled": false is recorded as if by a -NAME flag.
+		if multi && ac.Enabled != nil && !*ac.Enabled && !set[a.Name] {
+			*enabled[a] = setFalse
+		}
+
+		// Set the flags in a deterministic order.
+		names := make([]string, 0, len(ac.Flags))
+		for name := range ac.Flags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			cmdline := name
+			if multi {
+				cmdline = a.Name + "." + name
+			}
+			if set[cmdline] {
+				continue
+			}
+			value := fmt.Sprint(ac.Flags[name])
+			if err := a.Flags.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value %q for flag %s of analyzer %s: %v", value, name, a.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// inScope reports whether the diagnostics of the named analyzer should
+// be reported for the package with the given path.
+func (config *Config) inScope(analyzer, pkgPath string) bool {
+	if config == nil {
+		return true
+	}
+	ac := config.Analyzers[analyzer]
+	if ac == nil {
+		return true
+	}
+	for _, rx := range ac.exclude {
+		if rx.MatchString(pkgPath) {
+			return false
+		}
+	}
+	if len(ac.include) == 0 {
+		return true
+	}
+	for _, rx := range ac.include {
+		if rx.MatchString(pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// Severity returns the configured severity of the named analyzer, or ""
+// if none. It is safe to call on a nil Config.
+func (config *Config) Severity(analyzer string) string {
+	if config == nil {
+		return ""
+	}
+	if ac := config.Analyzers[analyzer]; ac != nil {
+		return ac.Severity
+	}
+	return ""
+}
+
+// severity returns the severity of a diagnostic of the named analyzer:
+// the configured one if any, or else that of the diagnostic itself.
+// It is safe to call on a nil Config.
+func (config *Config) severity(analyzer string, diag analysis.Diagnostic) string {
+	if s := config.Severity(analyzer); s != "" {
+		return s
+	}
+	return diag.Severity.String()
+}
+
+// addedBy reports whether the configuration enables the named
+// analyzer. It is safe to call on a nil Config.
+func (config *Config) addedBy(analyzer string) bool {
+	if config == nil {
+		return false
+	}
+	ac := config.Analyzers[analyzer]
+	return ac != nil && ac.Enabled != nil && *ac.Enabled
+}
+
+// FilterScope returns the diagnostics of the named analyzer in the
+// package with the given path, or nil if the package is out of its
+// scope. It is safe to call on a nil Config.
+func (config *Config) FilterScope(analyzer, pkgPath string, diags []analysis.Diagnostic) []analysis.Diagnostic {
+	if !config.inScope(analyzer, pkgPath) {
+		return nil
+	}
+	return diags
+}
+
+// matchPattern returns a regular expression matching the package paths
+// matched by a go list pattern, in which "..." matches any string, and
+// a trailing "/..." also matches the empty string.
+func matchPattern(pattern string) *regexp.Regexp {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	return regexp.MustCompile(`^` + re + `$`)
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
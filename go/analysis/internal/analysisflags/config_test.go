@@ -0,0 +1,93 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
+)
+
+func TestConfig(t *testing.T) {
+	a := &analysis.Analyzer{Name: "a", Doc: "a"}
+	a.Flags.Init("a", flag.ContinueOnError)
+	a.Flags.Bool("strict", false, "be strict")
+	b := &analysis.Analyzer{Name: "b", Doc: "b"}
+	analyzers := []*analysis.Analyzer{a, b}
+
+	read := func(content string) (*analysisflags.Config, error) {
+		filename := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(filename, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return analysisflags.ReadConfig(filename, analyzers)
+	}
+
+	config, err := read(`{
+	"analyzers": {
+		"a": {
+			"flags": {"strict": true},
+			"packages": ["example.com/app/..."],
+			"exclude": ["example.com/app/gen/...", "example.com/app/*_test"],
+			"severity": "error"
+		},
+		"b": {"enabled": false}
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Severity("a"); got != "error" {
+		t.Errorf("Severity(a) = %q, want error", got)
+	}
+	if got := config.Severity("b"); got != "" {
+		t.Errorf("Severity(b) = %q, want none", got)
+	}
+	diags := []analysis.Diagnostic{{Message: "m"}}
+	for _, test := range []struct {
+		analyzer, pkg string
+		want          bool
+	}{
+		{"a", "example.com/app", true},
+		{"a", "example.com/app/x", true},
+		{"a", "example.com/application", false},
+		{"a", "example.com/app/gen", false},
+		{"a", "example.com/app/gen/x", false},
+		{"a", "example.com/other", false},
+		{"b", "example.com/other", true},
+		{"c", "example.com/other", true},
+	} {
+		got := config.FilterScope(test.analyzer, test.pkg, diags) != nil
+		if got != test.want {
+			t.Errorf("FilterScope(%s, %s) kept diagnostics: %t, want %t", test.analyzer, test.pkg, got, test.want)
+		}
+	}
+	var nilConfig *analysisflags.Config
+	if nilConfig.FilterScope("a", "p", diags) == nil || nilConfig.Severity("a") != "" {
+		t.Errorf("nil Config is not empty")
+	}
+
+	// Invalid configurations.
+	for _, test := range []struct {
+		content, want string
+	}{
+		{`{"analyzers": {"c": {}}}`, `no analyzer named "c"`},
+		{`{"analyzers": {"a": {"flags": {"lax": true}}}}`, `analyzer a has no flag "lax"`},
+		{`{"analyzers": {"a": {"severity": "fatal"}}}`, `invalid severity "fatal"`},
+		{`{"analyzers": {"a": {"flags": {"strict": [true]}}}}`, `invalid value for flag "strict"`},
+		{`{"analyzers": {"a": {"flags": {"strict": {"on": true}}}}}`, `invalid value for flag "strict"`},
+		{`{"analyzers": {"a": {"flags": {"strict": null}}}}`, `invalid value for flag "strict"`},
+		{`{"analyzers": {"a": {"enable": true}}}`, `unknown field "enable"`},
+	} {
+		if _, err := read(test.content); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ReadConfig(%s) = %v, want error containing %q", test.content, err, test.want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -24,8 +25,16 @@ import (
 
 // flags common to all {single,multi,unit}checkers.
 var (
-	JSON    = false // -json
-	Context = -1    // -c=N: if N>0, display offending line plus N lines of context
+	JSON                = false // -json
+	Format              = ""    // -format=text|json|sarif; -format=json is -json
+	Context             = -1    // -c=N: if N>0, display offending line plus N lines of context
+	RequireIgnoreReason = false // -require-ignore-reason: report //lint:ignore directives without a reason
+	BaselineFile        = ""    // -baseline=file: report only diagnostics not recorded in file
+	WriteBaseline       = false // -write-baseline: record diagnostics in the -baseline file
+	ConfigFile          = ""    // -config=file: read the configuration of the analyzers from file
+
+	// Configuration is the contents of the -config file, or nil.
+	Configuration *Config
 )
 
 // Parse creates a flag for each of the analyzer's flags,
@@ -73,7 +82,12 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 
 	// flags common to all checkers
 	flag.BoolVar(&JSON, "json", JSON, "emit JSON output")
+	flag.StringVar(&Format, "format", Format, "output `format`: text, json or sarif (not supported under go vet)")
 	flag.IntVar(&Context, "c", Context, `display offending line with this many lines of context`)
+	flag.BoolVar(&RequireIgnoreReason, "require-ignore-reason", RequireIgnoreReason, "report //lint:ignore directives that give no reason")
+	flag.StringVar(&BaselineFile, "baseline", BaselineFile, "report only diagnostics not recorded in this baseline `file` (an absolute path under go vet)")
+	flag.BoolVar(&WriteBaseline, "write-baseline", WriteBaseline, "record all diagnostics in the -baseline file instead of reporting them")
+	flag.StringVar(&ConfigFile, "config", ConfigFile, "read the configuration of the analyzers from this JSON `file` (an absolute path under go vet)")
 
 	// Add shims for legacy vet flags to enable existing
 	// scripts that run vet to continue to work.
@@ -96,6 +110,48 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 		os.Exit(0)
 	}
 
+	// go vet runs the tool in the directory of each package, naming
+	// the .cfg file of the unit to analyze.
+	unit := flag.NArg() == 1 && strings.HasSuffix(flag.Arg(0), ".cfg")
+
+	switch Format {
+	case "", "text":
+	case "json":
+		JSON = true
+	case "sarif":
+		// go vet analyzes each package in a separate process, and
+		// would concatenate their logs, which is not a SARIF file.
+		if unit {
+			log.Fatal("-format=sarif is not supported under go vet, which analyzes each package separately; use a multichecker or singlechecker command")
+		}
+		if JSON {
+			log.Fatal("-json and -format=sarif are incompatible")
+		}
+	default:
+		log.Fatalf("invalid -format=%s; want text, json or sarif", Format)
+	}
+
+	if WriteBaseline && BaselineFile == "" {
+		log.Fatal("-write-baseline requires -baseline=file")
+	}
+
+	// -config: apply the settings not overridden on the command line.
+	if ConfigFile != "" {
+		if unit && !filepath.IsAbs(ConfigFile) {
+			log.Fatalf("-config=%s: the file name must be absolute, as go vet analyzes each package in its own directory", ConfigFile)
+		}
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		config, err := ReadConfig(ConfigFile, analyzers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := config.apply(analyzers, multi, enabled, set); err != nil {
+			log.Fatalf("%s: %v", ConfigFile, err)
+		}
+		Configuration = config
+	}
+
 	everything := expand(analyzers)
 
 	// If any -NAME flag is true,  run only those analyzers. Otherwise,
@@ -114,7 +170,7 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 		var keep []*analysis.Analyzer
 		if hasTrue {
 			for _, a := range analyzers {
-				if *enabled[a] == setTrue {
+				if *enabled[a] == setTrue || *enabled[a] == unset && Configuration.addedBy(a.Name) {
 					keep = append(keep, a)
 				}
 			}
@@ -167,10 +223,10 @@ func printFlags() {
 	var flags []jsonFlag = nil
 	flag.VisitAll(func(f *flag.Flag) {
 		// Don't report {single,multi}checker debugging
-		// flags or fix as these have no effect on unitchecker
+		// flags, fix or cache as these have no effect on unitchecker
 		// (as invoked by 'go vet').
 		switch f.Name {
-		case "debug", "cpuprofile", "memprofile", "trace", "fix":
+		case "debug", "cpuprofile", "memprofile", "trace", "fix", "diff", "cache":
 			return
 		}
 
@@ -368,11 +424,22 @@ type JSONSuggestedFix struct {
 // If so, how should they be formatted?
 type JSONDiagnostic struct {
 	Category       string             `json:"category,omitempty"`
+	Severity       string             `json:"severity,omitempty"` // see Config
+	Tags           []string           `json:"tags,omitempty"`
 	Posn           string             `json:"posn"`
 	Message        string             `json:"message"`
 	SuggestedFixes []JSONSuggestedFix `json:"suggested_fixes,omitempty"`
 }
 
+// tagNames returns the names of the tags, or nil if there are none.
+func tagNames(tags []analysis.Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.String())
+	}
+	return names
+}
+
 // Add adds the result of analysis 'name' on package 'id'.
 // The result is either a list of diagnostics or an error.
 func (tree JSONTree) Add(fset *token.FileSet, id, name string, diags []analysis.Diagnostic, err error) {
@@ -403,6 +470,8 @@ func (tree JSONTree) Add(fset *token.FileSet, id, name string, diags []analysis.
 			}
 			jdiag := JSONDiagnostic{
 				Category:       f.Category,
+				Severity:       Configuration.severity(name, f),
+				Tags:           tagNames(f.Tags),
 				Posn:           fset.Position(f.Pos).String(),
 				Message:        f.Message,
 				SuggestedFixes: fixes,
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -17,11 +18,14 @@ import (
 )
 
 func main() {
+	a1 := &analysis.Analyzer{Name: "a1", Doc: "a1"}
+	f := a1.Flags.String("f", "", "a flag")
 	fmt.Println(analysisflags.Parse([]*analysis.Analyzer{
-		{Name: "a1", Doc: "a1"},
+		a1,
 		{Name: "a2", Doc: "a2"},
 		{Name: "a3", Doc: "a3"},
 	}, true))
+	fmt.Printf("a1.f=%q\n", *f)
 	os.Exit(0)
 }
 
@@ -40,6 +44,18 @@ func TestExec(t *testing.T) {
 		panic("unreachable")
 	}
 
+	config := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(config, []byte(`{"analyzers": {"a1": {"flags": {"f": "x"}}, "a2": {"enabled": false}}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// "enabled": true adds an analyzer to those selected on the
+	// command line, but does not restrict the analyzers to it.
+	additive := filepath.Join(t.TempDir(), "additive.json")
+	if err := os.WriteFile(additive, []byte(`{"analyzers": {"a1": {"enabled": true}}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
 	for _, test := range []struct {
 		flags string
 		want  string // output should contain want
@@ -51,6 +67,14 @@ func TestExec(t *testing.T) {
 		{"-a1=1 -a3=1", "[a1 a3]"},
 		{"-a1=1 -a3=0", "[a1]"},
 		{"-V=full", "analysisflags.test version devel"},
+		{"-config=" + config, "[a1 a3]"},
+		{"-config=" + config + " -a2", "[a2]"},
+		{"-config=" + config, `a1.f="x"`},
+		{"-config=" + config + " -a1.f=y", `a1.f="y"`},
+		{"-config=" + additive, "[a1 a2 a3]"},
+		{"-config=" + additive + " -a3", "[a1 a3]"},
+		{"-config=" + additive + " -a1=0", "[a2 a3]"},
+		{"-config=" + additive + " -a2 -a1=0", "[a2]"},
 	} {
 		cmd := exec.Command(progname, "-test.run=TestExec")
 		cmd.Env = append(os.Environ(), "ANALYSISFLAGS_CHILD=1", "FLAGS="+test.flags)
@@ -0,0 +1,212 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// This file implements the directives that suppress diagnostics:
+//
+//	//lint:ignore analyzer[,analyzer...] reason
+//	//lint:file-ignore analyzer[,analyzer...] reason
+//
+// The first suppresses the diagnostics of the named analyzers on the
+// line that follows it, or, if it follows code on the same line, on its
+// own line. The second suppresses them throughout its file.
+//
+// A directive that is malformed, that lacks a reason when one is required
+// (-require-ignore-reason), or that names an analyzer that ran without
+// reporting any diagnostic it suppresses, is itself reported.
+
+// IgnoreName is the name under which problems with suppression
+// directives are reported, in place of the name of an analyzer.
+const IgnoreName = "lint:ignore"
+
+const ignoreDoc = "report malformed or unused //lint:ignore directives"
+
+// Suppressions holds the suppression directives of a set of files.
+type Suppressions struct {
+	fset       *token.FileSet
+	directives map[string][]*ignoreDirective // keyed by file name
+	ids        map[string]string             // package ID of each file
+}
+
+// An ignoreDirective is a //lint:ignore or //lint:file-ignore comment.
+type ignoreDirective struct {
+	pos       token.Pos
+	kind      string // "ignore" or "file-ignore"
+	line      int    // line whose diagnostics are suppressed, or 0 for the whole file
+	analyzers []string
+	malformed string // the problem with a malformed directive, if any
+	used      map[string]bool
+}
+
+// NewSuppressions returns an empty set of suppression directives for the
+// files of fset.
+func NewSuppressions(fset *token.FileSet) *Suppressions {
+	return &Suppressions{
+		fset:       fset,
+		directives: make(map[string][]*ignoreDirective),
+		ids:        make(map[string]string),
+	}
+}
+
+// AddFiles records the directives of the files of the package with the
+// given ID. Files already added, for example as part of a test variant of
+// the package, are skipped.
+func (s *Suppressions) AddFiles(id string, files []*ast.File) {
+	for _, f := range files {
+		name := s.fset.PositionFor(f.Pos(), false).Filename
+		if _, ok := s.ids[name]; ok {
+			continue
+		}
+		s.ids[name] = id
+
+		var ends map[int]token.Pos // computed on demand
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				kind, args, ok := cutDirective(c.Text)
+				if !ok {
+					continue
+				}
+				d := &ignoreDirective{pos: c.Pos(), kind: kind, used: make(map[string]bool)}
+				if kind == "ignore" {
+					if ends == nil {
+						ends = codeEnds(s.fset, f)
+					}
+					d.line = s.fset.PositionFor(c.Pos(), false).Line
+					if end, ok := ends[d.line]; !ok || end > c.Pos() {
+						d.line++ // the directive is on a line of its own
+					}
+				}
+				names, reason := cutSpace(strings.TrimSpace(args))
+				for _, a := range strings.Split(names, ",") {
+					if a == "" {
+						d.analyzers = nil
+						break
+					}
+					d.analyzers = append(d.analyzers, a)
+				}
+				if d.analyzers == nil {
+					d.malformed = fmt.Sprintf("malformed //lint:%s directive; want //lint:%[1]s analyzer[,analyzer...] reason", kind)
+				} else if RequireIgnoreReason && strings.TrimSpace(reason) == "" {
+					d.malformed = fmt.Sprintf("//lint:%s directive for %s has no reason", kind, names)
+				}
+				s.directives[name] = append(s.directives[name], d)
+			}
+		}
+	}
+}
+
+// cutDirective reports whether the comment text is a suppression
+// directive, and if so returns its kind, "ignore" or "file-ignore", and
+// its arguments.
+func cutDirective(text string) (kind, args string, ok bool) {
+	if !strings.HasPrefix(text, "//lint:") {
+		return "", "", false
+	}
+	kind, args = cutSpace(strings.TrimPrefix(text, "//lint:"))
+	if kind != "ignore" && kind != "file-ignore" {
+		return "", "", false
+	}
+	return kind, args, true
+}
+
+// cutSpace slices s around the first space or tab.
+func cutSpace(s string) (before, after string) {
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// codeEnds maps each line of f on which some syntax other than a comment
+// ends to the position of the earliest such end.
+func codeEnds(fset *token.FileSet, f *ast.File) map[int]token.Pos {
+	ends := make(map[int]token.Pos)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.Comment, *ast.CommentGroup:
+			return false
+		}
+		line := fset.PositionFor(n.End(), false).Line
+		if end, ok := ends[line]; !ok || n.End() < end {
+			ends[line] = n.End()
+		}
+		return true
+	})
+	return ends
+}
+
+// Filter returns the diagnostics reported by the named analyzer that are
+// not suppressed by a directive.
+func (s *Suppressions) Filter(analyzer string, diags []analysis.Diagnostic) []analysis.Diagnostic {
+	var kept []analysis.Diagnostic
+	for _, diag := range diags {
+		if !s.suppressed(analyzer, diag) {
+			kept = append(kept, diag)
+		}
+	}
+	return kept
+}
+
+func (s *Suppressions) suppressed(analyzer string, diag analysis.Diagnostic) bool {
+	posn := s.fset.PositionFor(diag.Pos, false)
+	for _, d := range s.directives[posn.Filename] {
+		if d.malformed != "" || d.line != 0 && d.line != posn.Line {
+			continue
+		}
+		for _, name := range d.analyzers {
+			if name == analyzer {
+				d.used[name] = true
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Problems returns the diagnostics about malformed directives, and about
+// directives naming an analyzer that ran, according to ran, but whose
+// diagnostics they did not suppress, grouped by the ID of the package of
+// their file. It must be called after the diagnostics of all the analyzers
+// have been filtered.
+func (s *Suppressions) Problems(ran map[string]bool) map[string][]analysis.Diagnostic {
+	names := make([]string, 0, len(s.directives))
+	for name := range s.directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := make(map[string][]analysis.Diagnostic)
+	report := func(name string, pos token.Pos, format string, args ...interface{}) {
+		id := s.ids[name]
+		problems[id] = append(problems[id], analysis.Diagnostic{
+			Pos:     pos,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+	for _, name := range names {
+		for _, d := range s.directives[name] {
+			if d.malformed != "" {
+				report(name, d.pos, "%s", d.malformed)
+				continue
+			}
+			for _, a := range d.analyzers {
+				if ran[a] && !d.used[a] {
+					report(name, d.pos, "unused //lint:%s directive for %s", d.kind, a)
+				}
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
+)
+
+const ignoreSrc = `package p
+
+//lint:file-ignore b the whole file
+
+func f() {
+	//lint:ignore a on the next line
+	_ = 1 // line 7
+	_ = 2 //lint:ignore a,b on this line
+	_ = 3 // line 9
+
+	//lint:ignore c
+	_ = 4 // line 12
+
+	//lint:ignore
+	_ = 5 // line 15
+}
+`
+
+func TestSuppressions(t *testing.T) {
+	defer func(require bool) { analysisflags.RequireIgnoreReason = require }(analysisflags.RequireIgnoreReason)
+
+	for _, test := range []struct {
+		require  bool
+		kept     map[string][]int // analyzer -> lines of unsuppressed diagnostics
+		problems []string
+	}{
+		{
+			require: false,
+			kept: map[string][]int{
+				"a": {9, 12, 15},
+				"b": nil,
+				"c": {7, 8, 9, 15},
+			},
+			problems: []string{
+				"p.go:8: unused //lint:ignore directive for b",
+				"p.go:14: malformed //lint:ignore directive; want //lint:ignore analyzer[,analyzer...] reason",
+			},
+		},
+		{
+			require: true,
+			kept: map[string][]int{
+				"a": {9, 12, 15},
+				"b": nil,
+				"c": {7, 8, 9, 12, 15},
+			},
+			problems: []string{
+				"p.go:8: unused //lint:ignore directive for b",
+				"p.go:11: //lint:ignore directive for c has no reason",
+				"p.go:14: malformed //lint:ignore directive; want //lint:ignore analyzer[,analyzer...] reason",
+			},
+		},
+	} {
+		analysisflags.RequireIgnoreReason = test.require
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", ignoreSrc, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sup := analysisflags.NewSuppressions(fset)
+		sup.AddFiles("p", []*ast.File{f})
+
+		// Each analyzer reports a diagnostic on each line of the form "_ = N".
+		tf := fset.File(f.Pos())
+		ran := make(map[string]bool)
+		for _, name := range []string{"a", "b", "c"} {
+			var diags []analysis.Diagnostic
+			for line := 1; line <= tf.LineCount(); line++ {
+				start := tf.LineStart(line)
+				if strings.HasPrefix(strings.TrimSpace(ignoreSrc[tf.Offset(start):]), "_ =") {
+					diags = append(diags, analysis.Diagnostic{Pos: start, Message: name})
+				}
+			}
+			var kept []int
+			for _, diag := range sup.Filter(name, diags) {
+				kept = append(kept, fset.Position(diag.Pos).Line)
+			}
+			if !reflect.DeepEqual(kept, test.kept[name]) {
+				t.Errorf("require=%t: analyzer %s kept diagnostics on lines %v, want %v", test.require, name, kept, test.kept[name])
+			}
+			ran[name] = true
+		}
+
+		var problems []string
+		for _, diag := range sup.Problems(ran)["p"] {
+			posn := fset.Position(diag.Pos)
+			problems = append(problems, fmt.Sprintf("%s:%d: %s", posn.Filename, posn.Line, diag.Message))
+		}
+		if !reflect.DeepEqual(problems, test.problems) {
+			t.Errorf("require=%t: got problems\n%s\nwant\n%s", test.require, strings.Join(problems, "\n"), strings.Join(test.problems, "\n"))
+		}
+	}
+}
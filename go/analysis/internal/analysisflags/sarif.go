@@ -0,0 +1,323 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// This file implements -format=sarif, which prints the results of the
+// analysis as a SARIF 2.1.0 log, for consumption by code scanning tools.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+//
+// The log has a single run, whose rules are the analyzers. (The
+// unitchecker driver, which analyzes each package separately, does not
+// support it.) Each diagnostic is a result; its related information and suggested fixes
+// become related locations and fixes. Analysis errors are reported as
+// notifications of the invocation.
+
+// A SARIFLog accumulates the results of analysis in SARIF form.
+type SARIFLog struct {
+	rules     []*sarifRule
+	ruleIndex map[string]int // index of each rule, by analyzer name
+	results   []sarifResult
+	errors    []sarifNotification
+	files     map[string][]byte // file contents, for column computation
+}
+
+// NewSARIFLog returns an empty log whose rules describe the analyzers.
+func NewSARIFLog(analyzers []*analysis.Analyzer) *SARIFLog {
+	l := &SARIFLog{
+		ruleIndex: make(map[string]int),
+		files:     make(map[string][]byte),
+	}
+	for _, a := range analyzers {
+		l.addRule(a.Name, a.Doc, a.URL)
+	}
+	return l
+}
+
+func (l *SARIFLog) addRule(name, doc, url string) int {
+	if i, ok := l.ruleIndex[name]; ok {
+		return i
+	}
+	rule := &sarifRule{ID: name, HelpURI: url}
+	if doc != "" {
+		short, _, _ := strings.Cut(doc, "\n\n")
+		rule.ShortDescription = &sarifMessage{Text: strings.Join(strings.Fields(short), " ")}
+		rule.FullDescription = &sarifMessage{Text: doc}
+	}
+	l.ruleIndex[name] = len(l.rules)
+	l.rules = append(l.rules, rule)
+	return len(l.rules) - 1
+}
+
+// Add adds the result of analysis 'name' on package 'id', like
+// JSONTree.Add. The result is either a list of diagnostics or an error.
+// The diagnostics of an analysis not passed to NewSARIFLog are recorded
+// under a rule without description, except for the problems with
+// //lint:ignore directives, reported under IgnoreName.
+func (l *SARIFLog) Add(fset *token.FileSet, id, name string, diags []analysis.Diagnostic, err error) {
+	if err != nil {
+		l.errors = append(l.errors, sarifNotification{
+			Level:   "error",
+			Message: sarifMessage{Text: fmt.Sprintf("%s: %s: %v", id, name, err)},
+		})
+		return
+	}
+	for _, diag := range diags {
+		var doc string
+		if name == IgnoreName {
+			doc = ignoreDoc
+		}
+		ruleIndex := l.addRule(name, doc, "")
+		res := sarifResult{
+			RuleID:     name,
+			RuleIndex:  ruleIndex,
+			Level:      sarifLevel(Configuration.severity(name, diag)),
+			Message:    sarifMessage{Text: diag.Message},
+			Locations:  []sarifLocation{l.location(fset, diag.Pos, diag.End, "")},
+			Properties: map[string]interface{}{"package": id},
+		}
+		if diag.Category != "" {
+			res.Properties["category"] = diag.Category
+		}
+		if tags := tagNames(diag.Tags); tags != nil {
+			res.Properties["tags"] = tags
+		}
+		for i, rel := range diag.Related {
+			loc := l.location(fset, rel.Pos, rel.End, rel.Message)
+			loc.ID = i + 1
+			res.RelatedLocations = append(res.RelatedLocations, loc)
+		}
+		for _, fix := range diag.SuggestedFixes {
+			if len(fix.TextEdits) == 0 {
+				continue // SARIF requires at least one change
+			}
+			sfix := sarifFix{Description: sarifMessage{Text: fix.Message}}
+			changes := make(map[string]int) // index of the change to each file
+			for _, edit := range fix.TextEdits {
+				loc := l.location(fset, edit.Pos, edit.End, "")
+				uri := loc.PhysicalLocation.ArtifactLocation.URI
+				i, ok := changes[uri]
+				if !ok {
+					i = len(sfix.ArtifactChanges)
+					changes[uri] = i
+					sfix.ArtifactChanges = append(sfix.ArtifactChanges, sarifArtifactChange{
+						ArtifactLocation: loc.PhysicalLocation.ArtifactLocation,
+					})
+				}
+				sfix.ArtifactChanges[i].Replacements = append(sfix.ArtifactChanges[i].Replacements, sarifReplacement{
+					DeletedRegion:   loc.PhysicalLocation.Region,
+					InsertedContent: &sarifArtifactContent{Text: string(edit.NewText)},
+				})
+			}
+			res.Fixes = append(res.Fixes, sfix)
+		}
+		l.results = append(l.results, res)
+	}
+}
+
+// sarifLevel returns the SARIF level of a result of the given severity.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case "info", "hint":
+		return "note"
+	}
+	return "warning"
+}
+
+// location returns the SARIF location of the range [pos, end).
+// Its columns count UTF-16 code units, the SARIF default.
+func (l *SARIFLog) location(fset *token.FileSet, pos, end token.Pos, message string) sarifLocation {
+	if !end.IsValid() {
+		end = pos
+	}
+	start, stop := fset.Position(pos), fset.Position(end)
+	loc := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: fileURI(start.Filename)},
+			Region: sarifRegion{
+				StartLine:   start.Line,
+				StartColumn: l.column(start),
+				EndLine:     stop.Line,
+				EndColumn:   l.column(stop),
+			},
+		},
+	}
+	if message != "" {
+		loc.Message = &sarifMessage{Text: message}
+	}
+	return loc
+}
+
+// column returns the 1-based column of posn in UTF-16 code units. It
+// falls back to the byte column if the file cannot be read.
+func (l *SARIFLog) column(posn token.Position) int {
+	content, ok := l.files[posn.Filename]
+	if !ok {
+		content, _ = os.ReadFile(posn.Filename)
+		l.files[posn.Filename] = content
+	}
+	lineStart := posn.Offset - (posn.Column - 1)
+	if lineStart < 0 || posn.Offset > len(content) {
+		return posn.Column
+	}
+	col := 1
+	for _, r := range string(content[lineStart:posn.Offset]) {
+		if r >= 0x10000 {
+			col += 2 // surrogate pair
+		} else {
+			col++
+		}
+	}
+	return col
+}
+
+// fileURI returns the file URI of the named file.
+func fileURI(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	path := filepath.ToSlash(filename)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letter
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// Print prints the log to standard output.
+func (l *SARIFLog) Print() {
+	out := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:  filepath.Base(os.Args[0]),
+				Rules: l.rules,
+			}},
+			Results: l.results,
+			Invocations: []sarifInvocation{{
+				ExecutionSuccessful:        len(l.errors) == 0,
+				ToolExecutionNotifications: l.errors,
+			}},
+		}},
+	}
+	if out.Runs[0].Results == nil {
+		out.Runs[0].Results = []sarifResult{} // the property is required
+	}
+	data, err := json.MarshalIndent(out, "", "\t")
+	if err != nil {
+		log.Panicf("internal error: SARIF marshaling failed: %v", err)
+	}
+	fmt.Printf("%s\n", data)
+}
+
+// The types below encode the subset of the SARIF schema that we use.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Results     []sarifResult     `json:"results"`
+	Invocations []sarifInvocation `json:"invocations,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string       `json:"name"`
+	Rules []*sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+	FullDescription  *sarifMessage `json:"fullDescription,omitempty"`
+	HelpURI          string        `json:"helpUri,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string                 `json:"ruleId"`
+	RuleIndex        int                    `json:"ruleIndex"`
+	Level            string                 `json:"level"`
+	Message          sarifMessage           `json:"message"`
+	Locations        []sarifLocation        `json:"locations"`
+	RelatedLocations []sarifLocation        `json:"relatedLocations,omitempty"`
+	Fixes            []sarifFix             `json:"fixes,omitempty"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion           `json:"deletedRegion"`
+	InsertedContent *sarifArtifactContent `json:"insertedContent,omitempty"`
+}
+
+type sarifArtifactContent struct {
+	Text string `json:"text"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
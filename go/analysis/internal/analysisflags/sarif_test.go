@@ -0,0 +1,161 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags_test
+
+import (
+	"encoding/json"
+	"errors"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
+)
+
+func TestSARIF(t *testing.T) {
+	src := "package p\n\nvar 𝓍, y = 1, 2\n"
+	filename := filepath.Join(t.TempDir(), "p.go")
+	if err := os.WriteFile(filename, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	tf := fset.AddFile(filename, -1, len(src))
+	tf.SetLinesForContent([]byte(src))
+	y := tf.Pos(strings.Index(src, "y"))
+	x := tf.Pos(strings.Index(src, "𝓍"))
+
+	a := &analysis.Analyzer{
+		Name: "a",
+		Doc:  "check things\n\nThe a analyzer checks things.",
+		URL:  "https://example.com/a",
+	}
+	sarif := analysisflags.NewSARIFLog([]*analysis.Analyzer{a})
+	sarif.Add(fset, "p", "a", []analysis.Diagnostic{{
+		Pos:      y,
+		End:      y + 1,
+		Category: "cat",
+		Message:  "y is bad",
+		Severity: analysis.SeverityHint,
+		Tags:     []analysis.Tag{analysis.TagUnnecessary},
+		Related:  []analysis.RelatedInformation{{Pos: x, End: x + 4, Message: "x is related"}},
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "rename y",
+			TextEdits: []analysis.TextEdit{{Pos: y, End: y + 1, NewText: []byte("z")}},
+		}},
+	}}, nil)
+	sarif.Add(fset, "q", "a", nil, errors.New("failed"))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	sarif.Print()
+	os.Stdout = stdout
+	w.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID               string
+						ShortDescription struct{ Text string }
+						HelpURI          string
+					}
+				}
+			}
+			Results []struct {
+				RuleID     string
+				Level      string
+				Message    struct{ Text string }
+				Locations  []sarifLocation
+				Related    []sarifLocation `json:"relatedLocations"`
+				Properties struct {
+					Package  string
+					Category string
+					Tags     []string
+				}
+				Fixes []struct {
+					ArtifactChanges []struct {
+						Replacements []struct {
+							DeletedRegion   sarifRegion
+							InsertedContent struct{ Text string }
+						}
+					}
+				}
+			}
+			Invocations []struct {
+				ExecutionSuccessful        bool
+				ToolExecutionNotifications []struct{ Message struct{ Text string } }
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("got version %q and %d runs, want 2.1.0 and 1 run", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 1 || rules[0].ID != "a" || rules[0].ShortDescription.Text != "check things" || rules[0].HelpURI != a.URL {
+		t.Errorf("got rules %+v, want the rule for analyzer a", rules)
+	}
+
+	if len(run.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(run.Results))
+	}
+	res := run.Results[0]
+	if res.RuleID != "a" || res.Message.Text != "y is bad" || res.Level != "note" ||
+		res.Properties.Category != "cat" || res.Properties.Package != "p" ||
+		len(res.Properties.Tags) != 1 || res.Properties.Tags[0] != "unnecessary" {
+		t.Errorf("got result %+v", res)
+	}
+	// 𝓍 is one code point, two UTF-16 code units, and four bytes.
+	wantRegion := sarifRegion{StartLine: 3, StartColumn: 9, EndLine: 3, EndColumn: 10}
+	if len(res.Locations) != 1 || res.Locations[0].PhysicalLocation.Region != wantRegion {
+		t.Errorf("got locations %+v, want region %+v", res.Locations, wantRegion)
+	} else if uri := res.Locations[0].PhysicalLocation.ArtifactLocation.URI; !strings.HasPrefix(uri, "file:///") || !strings.HasSuffix(uri, "/p.go") {
+		t.Errorf("got URI %q, want file URI of p.go", uri)
+	}
+	wantRelated := sarifRegion{StartLine: 3, StartColumn: 5, EndLine: 3, EndColumn: 7}
+	if len(res.Related) != 1 || res.Related[0].PhysicalLocation.Region != wantRelated || res.Related[0].Message.Text != "x is related" {
+		t.Errorf("got related locations %+v, want region %+v", res.Related, wantRelated)
+	}
+	if len(res.Fixes) != 1 || len(res.Fixes[0].ArtifactChanges) != 1 || len(res.Fixes[0].ArtifactChanges[0].Replacements) != 1 {
+		t.Fatalf("got fixes %+v, want a single replacement", res.Fixes)
+	}
+	if repl := res.Fixes[0].ArtifactChanges[0].Replacements[0]; repl.DeletedRegion != wantRegion || repl.InsertedContent.Text != "z" {
+		t.Errorf("got replacement %+v, want z at %+v", repl, wantRegion)
+	}
+
+	if len(run.Invocations) != 1 || run.Invocations[0].ExecutionSuccessful || len(run.Invocations[0].ToolExecutionNotifications) != 1 {
+		t.Errorf("got invocations %+v, want one failed invocation with a notification", run.Invocations)
+	}
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct{ URI string }
+		Region           sarifRegion
+	}
+	Message struct{ Text string }
+}
+
+type sarifRegion struct {
+	StartLine, StartColumn, EndLine, EndColumn int
+}
@@ -0,0 +1,455 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"flag"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/objectpath"
+)
+
+// This file implements the persistent cache of analysis results
+// enabled by the -cache=dir flag.
+//
+// The cache records the facts and diagnostics produced by each
+// successful action, keyed by a hash of everything that may affect
+// them: the executable (which stands for the version of the analyzers),
+// the Go release, the analyzer and its flags, the target's type sizes,
+// and the content of the package's files and those of all its
+// dependencies. On a hit, the action is not executed: its facts and
+// diagnostics are decoded from the cache. The packages must still be
+// loaded and type-checked, but unchanged packages are not analyzed.
+//
+// The Run result of an action is not serializable, so the actions of
+// analyzers with a ResultType are never cached. Object facts about
+// objects that have no object path, such as local variables, are lost
+// on a hit, as they are when facts are passed between processes. So are
+// all results of actions whose diagnostics refer to files outside the
+// package, such as those added to the file set by the analyzer.
+
+// cacheVersion must be incremented whenever the encoding changes.
+const cacheVersion = 2
+
+// diskCache is the persistent cache, or nil if -cache is not set.
+var diskCache *cache
+
+// A cache is a directory of encoded action results, keyed by hash.
+type cache struct {
+	hits, misses int64 // statistics, updated atomically; must be first for alignment
+
+	dir     string
+	exeHash string // hash of the executable
+
+	mu     sync.Mutex
+	hashes map[*packages.Package]*packageHash
+}
+
+type packageHash struct {
+	once sync.Once
+	sum  [sha256.Size]byte
+	err  error
+}
+
+// A cacheEntry is the encoded result of a successful action.
+type cacheEntry struct {
+	ObjectFacts  []cachedObjectFact
+	PackageFacts []analysis.Fact
+	Diagnostics  []cachedDiagnostic
+}
+
+type cachedObjectFact struct {
+	Path objectpath.Path
+	Fact analysis.Fact
+}
+
+type cachedDiagnostic struct {
+	Pos, End       cachedPos
+	Category       string
+	Message        string
+	Severity       analysis.Severity
+	Tags           []analysis.Tag
+	URL            string
+	SuggestedFixes []cachedSuggestedFix
+	Related        []cachedRelatedInformation
+}
+
+type cachedSuggestedFix struct {
+	Message   string
+	TextEdits []cachedTextEdit
+}
+
+type cachedTextEdit struct {
+	Pos, End cachedPos
+	NewText  []byte
+}
+
+type cachedRelatedInformation struct {
+	Pos, End cachedPos
+	Message  string
+}
+
+// A cachedPos is a position in a file of the package, by name and
+// offset. The zero value stands for token.NoPos.
+type cachedPos struct {
+	File   string
+	Offset int
+}
+
+// openCache returns the cache in the named directory, creating it if
+// necessary, for use with the specified analyzers.
+func openCache(dir string, analyzers []*analysis.Analyzer) (*cache, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+
+	// Facts are encoded as interface values.
+	for a := range expand(analyzers) {
+		for _, f := range a.FactTypes {
+			gob.Register(f)
+		}
+	}
+
+	return &cache{
+		dir:     dir,
+		exeHash: fmt.Sprintf("%x", h.Sum(nil)),
+		hashes:  make(map[*packages.Package]*packageHash),
+	}, nil
+}
+
+// expand returns the set of analyzers reachable from analyzers
+// through Requires edges.
+func expand(analyzers []*analysis.Analyzer) map[*analysis.Analyzer]bool {
+	seen := make(map[*analysis.Analyzer]bool)
+	var visit func([]*analysis.Analyzer)
+	visit = func(analyzers []*analysis.Analyzer) {
+		for _, a := range analyzers {
+			if !seen[a] {
+				seen[a] = true
+				visit(a.Requires)
+			}
+		}
+	}
+	visit(analyzers)
+	return seen
+}
+
+// cacheable reports whether the result of act may be cached.
+func (c *cache) cacheable(act *action) bool {
+	return act.a.ResultType == nil
+}
+
+// key returns the cache key for act. It fails if some file of the
+// package or its dependencies cannot be read.
+func (c *cache) key(act *action) ([sha256.Size]byte, error) {
+	var key [sha256.Size]byte
+	pkgHash, err := c.packageHash(act.pkg)
+	if err != nil {
+		return key, err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "analysis cache %d\n", cacheVersion)
+	fmt.Fprintf(h, "executable %s\n", c.exeHash)
+	fmt.Fprintf(h, "go %s\n", runtime.Version())
+	fmt.Fprintf(h, "analyzer %s\n", act.a.Name)
+	// The flags of a required analyzer may change its result,
+	// and so the diagnostics and facts of act.
+	var required []*analysis.Analyzer
+	for a := range expand([]*analysis.Analyzer{act.a}) {
+		required = append(required, a)
+	}
+	sort.Slice(required, func(i, j int) bool { return required[i].Name < required[j].Name })
+	for _, a := range required {
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(h, "flag %s.%s=%q\n", a.Name, f.Name, f.Value)
+		})
+	}
+	if sizes := act.pkg.TypesSizes; sizes != nil {
+		fmt.Fprintf(h, "sizes %d %d\n",
+			sizes.Sizeof(types.Typ[types.Int]),
+			sizes.Alignof(types.NewPointer(types.Typ[types.Int64])))
+	}
+	fmt.Fprintf(h, "package %x\n", pkgHash)
+	h.Sum(key[:0])
+	return key, nil
+}
+
+// packageHash returns a hash of the metadata and files of pkg and of
+// all its dependencies.
+func (c *cache) packageHash(pkg *packages.Package) ([sha256.Size]byte, error) {
+	c.mu.Lock()
+	ph, ok := c.hashes[pkg]
+	if !ok {
+		ph = new(packageHash)
+		c.hashes[pkg] = ph
+	}
+	c.mu.Unlock()
+
+	ph.once.Do(func() {
+		h := sha256.New()
+		fmt.Fprintf(h, "package %s %s %s\n", pkg.ID, pkg.PkgPath, pkg.Name)
+		fmt.Fprintf(h, "illtyped %t errors %d\n", pkg.IllTyped, len(pkg.Errors))
+		for _, files := range [][]string{pkg.CompiledGoFiles, pkg.OtherFiles} {
+			fmt.Fprintf(h, "files %d\n", len(files))
+			for _, name := range files {
+				data, ok := overlay[name]
+				if !ok {
+					var err error
+					data, err = os.ReadFile(name)
+					if err != nil {
+						ph.err = err
+						return
+					}
+				}
+				fmt.Fprintf(h, "file %s %x\n", name, sha256.Sum256(data))
+			}
+		}
+		fmt.Fprintf(h, "ignored %q\n", pkg.IgnoredFiles)
+
+		paths := make([]string, 0, len(pkg.Imports))
+		for path := range pkg.Imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			depHash, err := c.packageHash(pkg.Imports[path])
+			if err != nil {
+				ph.err = err
+				return
+			}
+			fmt.Fprintf(h, "import %s %x\n", path, depHash)
+		}
+		h.Sum(ph.sum[:0])
+	})
+	return ph.sum, ph.err
+}
+
+// filename returns the name of the cache file for key.
+func (c *cache) filename(key [sha256.Size]byte) string {
+	name := fmt.Sprintf("%x", key)
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+// get looks up the result of act in the cache. On a hit, it adds the
+// cached facts and diagnostics to act and reports true.
+func (c *cache) get(act *action, key [sha256.Size]byte) bool {
+	data, err := os.ReadFile(c.filename(key))
+	if err != nil {
+		atomic.AddInt64(&c.misses, 1)
+		return false
+	}
+	var entry cacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		atomic.AddInt64(&c.misses, 1)
+		return false // corrupt entry; it will be overwritten
+	}
+
+	files := packageFiles(act.pkg)
+	decodePos := func(pos cachedPos) (token.Pos, error) {
+		if pos.File == "" {
+			return token.NoPos, nil
+		}
+		tf, ok := files[pos.File]
+		if !ok || pos.Offset > tf.Size() {
+			return token.NoPos, fmt.Errorf("invalid position %s:#%d", pos.File, pos.Offset)
+		}
+		return tf.Pos(pos.Offset), nil
+	}
+	var diagnostics []analysis.Diagnostic
+	for _, cd := range entry.Diagnostics {
+		diag, err := decodeDiagnostic(cd, decodePos)
+		if err != nil {
+			atomic.AddInt64(&c.misses, 1)
+			return false
+		}
+		diagnostics = append(diagnostics, diag)
+	}
+
+	objectFacts := make(map[objectFactKey]analysis.Fact)
+	for _, f := range entry.ObjectFacts {
+		obj, err := objectpath.Object(act.pkg.Types, f.Path)
+		if err != nil {
+			atomic.AddInt64(&c.misses, 1)
+			return false
+		}
+		objectFacts[objectFactKey{obj, factType(f.Fact)}] = f.Fact
+	}
+
+	// Success: commit the results to act.
+	for k, fact := range objectFacts {
+		act.objectFacts[k] = fact
+	}
+	for _, fact := range entry.PackageFacts {
+		act.packageFacts[packageFactKey{act.pkg.Types, factType(fact)}] = fact
+	}
+	act.diagnostics = diagnostics
+	atomic.AddInt64(&c.hits, 1)
+	return true
+}
+
+// put records the result of act, which succeeded, in the cache.
+// Failures are silently ignored: the result is simply not cached.
+func (c *cache) put(act *action, key [sha256.Size]byte) {
+	var entry cacheEntry
+
+	// Record only the facts exported by act, not those it inherited.
+	var enc objectpath.Encoder
+	for k, fact := range act.objectFacts {
+		if k.obj.Pkg() == act.pkg.Types {
+			if path, err := enc.For(k.obj); err == nil {
+				entry.ObjectFacts = append(entry.ObjectFacts, cachedObjectFact{path, fact})
+			}
+		}
+	}
+	for k, fact := range act.packageFacts {
+		if k.pkg == act.pkg.Types {
+			entry.PackageFacts = append(entry.PackageFacts, fact)
+		}
+	}
+
+	files := packageFiles(act.pkg)
+	encodePos := func(pos token.Pos) (cachedPos, error) {
+		if !pos.IsValid() {
+			return cachedPos{}, nil
+		}
+		tf := act.pkg.Fset.File(pos)
+		if tf == nil || files[tf.Name()] != tf {
+			return cachedPos{}, fmt.Errorf("position not within package")
+		}
+		return cachedPos{tf.Name(), tf.Offset(pos)}, nil
+	}
+	for _, diag := range act.diagnostics {
+		cd, err := encodeDiagnostic(diag, encodePos)
+		if err != nil {
+			return
+		}
+		entry.Diagnostics = append(entry.Diagnostics, cd)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return
+	}
+	filename := c.filename(key)
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return
+	}
+	// Write the entry atomically, as concurrent processes may share
+	// the cache.
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// packageFiles returns the token.File of each Go file of pkg, by name.
+func packageFiles(pkg *packages.Package) map[string]*token.File {
+	files := make(map[string]*token.File)
+	for _, f := range pkg.Syntax {
+		if tf := pkg.Fset.File(f.Pos()); tf != nil {
+			files[tf.Name()] = tf
+		}
+	}
+	return files
+}
+
+func encodeDiagnostic(diag analysis.Diagnostic, encodePos func(token.Pos) (cachedPos, error)) (cachedDiagnostic, error) {
+	var err error
+	pos := func(p token.Pos) cachedPos {
+		cp, perr := encodePos(p)
+		if perr != nil {
+			err = perr
+		}
+		return cp
+	}
+	cd := cachedDiagnostic{
+		Pos:      pos(diag.Pos),
+		End:      pos(diag.End),
+		Category: diag.Category,
+		Message:  diag.Message,
+		Severity: diag.Severity,
+		Tags:     diag.Tags,
+		URL:      diag.URL,
+	}
+	for _, fix := range diag.SuggestedFixes {
+		cfix := cachedSuggestedFix{Message: fix.Message}
+		for _, edit := range fix.TextEdits {
+			cfix.TextEdits = append(cfix.TextEdits, cachedTextEdit{pos(edit.Pos), pos(edit.End), edit.NewText})
+		}
+		cd.SuggestedFixes = append(cd.SuggestedFixes, cfix)
+	}
+	for _, rel := range diag.Related {
+		cd.Related = append(cd.Related, cachedRelatedInformation{pos(rel.Pos), pos(rel.End), rel.Message})
+	}
+	return cd, err
+}
+
+func decodeDiagnostic(cd cachedDiagnostic, decodePos func(cachedPos) (token.Pos, error)) (analysis.Diagnostic, error) {
+	var err error
+	pos := func(cp cachedPos) token.Pos {
+		p, perr := decodePos(cp)
+		if perr != nil {
+			err = perr
+		}
+		return p
+	}
+	diag := analysis.Diagnostic{
+		Pos:      pos(cd.Pos),
+		End:      pos(cd.End),
+		Category: cd.Category,
+		Message:  cd.Message,
+		Severity: cd.Severity,
+		Tags:     cd.Tags,
+		URL:      cd.URL,
+	}
+	for _, cfix := range cd.SuggestedFixes {
+		fix := analysis.SuggestedFix{Message: cfix.Message}
+		for _, edit := range cfix.TextEdits {
+			fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{Pos: pos(edit.Pos), End: pos(edit.End), NewText: edit.NewText})
+		}
+		diag.SuggestedFixes = append(diag.SuggestedFixes, fix)
+	}
+	for _, rel := range cd.Related {
+		diag.Related = append(diag.Related, analysis.RelatedInformation{Pos: pos(rel.Pos), End: pos(rel.End), Message: rel.Message})
+	}
+	return diag, err
+}
@@ -10,6 +10,7 @@ package checker
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/gob"
 	"errors"
 	"flag"
@@ -52,8 +53,17 @@ var (
 	// IncludeTests indicates whether test files should be analyzed too.
 	IncludeTests = true
 
-	// Fix determines whether to apply all suggested fixes.
+	// Fix determines whether to apply all suggested fixes
+	// whose edits do not overlap.
 	Fix bool
+
+	// Diff causes the changes of the suggested fixes to be printed as
+	// unified diffs instead of being applied.
+	Diff bool
+
+	// CacheDir is the directory of the persistent cache of analysis
+	// results, or empty to disable it.
+	CacheDir string
 )
 
 // RegisterFlags registers command-line flags used by the analysis driver.
@@ -68,7 +78,9 @@ func RegisterFlags() {
 	flag.StringVar(&Trace, "trace", "", "write trace log to this file")
 	flag.BoolVar(&IncludeTests, "test", IncludeTests, "indicates whether test files should be analyzed, too")
 
-	flag.BoolVar(&Fix, "fix", false, "apply all suggested fixes")
+	flag.BoolVar(&Fix, "fix", false, "apply all suggested fixes whose edits do not overlap")
+	flag.BoolVar(&Diff, "diff", false, "print the changes of all suggested fixes whose edits do not overlap as unified diffs, without applying them")
+	flag.StringVar(&CacheDir, "cache", "", "cache analysis facts and diagnostics in this `dir`ectory")
 }
 
 // Run loads the packages specified by args using go/packages,
@@ -79,6 +91,11 @@ func RegisterFlags() {
 // singlechecker and the multi-analysis commands.
 // It returns the appropriate exit code.
 func Run(args []string, analyzers []*analysis.Analyzer) (exitcode int) {
+	// The diffs would be mixed with the structured output on stdout.
+	if Diff && (analysisflags.JSON || analysisflags.Format == "sarif") {
+		log.Fatal("-diff is incompatible with -json and -format=sarif")
+	}
+
 	if CPUProfile != "" {
 		f, err := os.Create(CPUProfile)
 		if err != nil {
@@ -142,12 +159,54 @@ func Run(args []string, analyzers []*analysis.Analyzer) (exitcode int) {
 		// TODO: filter analyzers based on RunDespiteError?
 	}
 
+	// Open the cache of analysis results.
+	if CacheDir != "" {
+		diskCache, err = openCache(CacheDir, analyzers)
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		defer func() { diskCache = nil }()
+	}
+
 	// Run the analysis.
 	roots := analyze(initial, analyzers)
+	if diskCache != nil && dbg('v') {
+		log.Printf("cache: %d hits, %d misses", diskCache.hits, diskCache.misses)
+	}
+
+	// Discard the diagnostics suppressed by //lint:ignore directives.
+	problems := suppress(initial, roots)
+
+	// Discard the diagnostics recorded in the baseline, or record them.
+	if analysisflags.BaselineFile != "" {
+		if err := applyBaseline(initial, roots); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
 
-	// Apply fixes.
-	if Fix {
-		if err := applyFixes(roots); err != nil {
+	// Apply fixes, or print them.
+	var unapplied int
+	if Fix || Diff {
+		// Reanalyze the fixed packages, using the overlay.
+		reanalyze := func() ([]*action, error) {
+			initial, err := load(args, allSyntax)
+			if err != nil {
+				if _, ok := err.(typeParseError); !ok {
+					return nil, err
+				}
+			}
+			roots := analyze(initial, analyzers)
+			suppress(initial, roots)
+			if analysisflags.BaselineFile != "" {
+				if err := filterBaseline(initial, roots); err != nil {
+					return nil, err
+				}
+			}
+			return roots, nil
+		}
+		if unapplied, err = applyFixes(roots, reanalyze); err != nil {
 			// Fail when applying fixes failed.
 			log.Print(err)
 			return 1
@@ -155,7 +214,92 @@ func Run(args []string, analyzers []*analysis.Analyzer) (exitcode int) {
 	}
 
 	// Print the results.
-	return printDiagnostics(roots)
+	exitcode = printDiagnostics(roots, problems)
+	if unapplied > 0 {
+		exitcode = 1 // some fixes were not applied
+	}
+	return exitcode
+}
+
+// suppress discards the diagnostics of the root actions that are
+// suppressed by //lint:ignore directives in the initial packages, and
+// returns the problems with those directives, keyed by package ID.
+// It also discards the diagnostics of packages outside the scope of
+// their analyzer in the -config file.
+func suppress(initial []*packages.Package, roots []*action) map[string][]analysis.Diagnostic {
+	if len(initial) == 0 {
+		return nil
+	}
+	sup := analysisflags.NewSuppressions(initial[0].Fset)
+	for _, pkg := range initial {
+		sup.AddFiles(pkg.ID, pkg.Syntax)
+	}
+	ran := make(map[string]bool)
+	for _, act := range roots {
+		if _, ok := ran[act.a.Name]; !ok {
+			ran[act.a.Name] = true
+		}
+		if act.err != nil {
+			ran[act.a.Name] = false // an unused directive may be due to the error
+		}
+		act.diagnostics = sup.Filter(act.a.Name, act.diagnostics)
+		act.diagnostics = analysisflags.Configuration.FilterScope(act.a.Name, act.pkg.PkgPath, act.diagnostics)
+	}
+	return sup.Problems(ran)
+}
+
+// applyBaseline discards the diagnostics of the root actions that are
+// recorded in the -baseline file, and reports the stale entries of the
+// file. With -write-baseline, it instead replaces the contents of the
+// file with all the diagnostics.
+func applyBaseline(initial []*packages.Package, roots []*action) error {
+	var fset *token.FileSet
+	if len(initial) > 0 {
+		fset = initial[0].Fset
+	}
+	filename := analysisflags.BaselineFile
+	if analysisflags.WriteBaseline {
+		b := analysisflags.NewBaseline(fset)
+		var entries []analysisflags.BaselineEntry
+		for _, act := range roots {
+			for _, diag := range act.diagnostics {
+				entries = append(entries, b.Entry(act.pkg.ID, act.a.Name, diag))
+			}
+			act.diagnostics = nil
+		}
+		return analysisflags.SaveBaseline(filename, entries)
+	}
+
+	b, err := analysisflags.ReadBaseline(fset, filename)
+	if err != nil {
+		return err
+	}
+	for _, act := range roots {
+		if act.err == nil { // a failed analysis leaves its entries unchecked
+			act.diagnostics = b.Filter(act.pkg.ID, act.a.Name, act.diagnostics)
+		}
+	}
+	analysisflags.PrintStale(filename, b.Stale())
+	return nil
+}
+
+// filterBaseline discards the diagnostics of the root actions that are
+// recorded in the -baseline file, when the packages are reanalyzed by
+// -fix. The fixed files are those of the overlay.
+func filterBaseline(initial []*packages.Package, roots []*action) error {
+	var fset *token.FileSet
+	if len(initial) > 0 {
+		fset = initial[0].Fset
+	}
+	b, err := analysisflags.ReadBaseline(fset, analysisflags.BaselineFile)
+	if err != nil {
+		return err
+	}
+	b.Overlay = overlay
+	for _, act := range roots {
+		act.diagnostics = b.Filter(act.pkg.ID, act.a.Name, act.diagnostics)
+	}
+	return nil
 }
 
 // typeParseError represents a package load error
@@ -173,8 +317,9 @@ func load(patterns []string, allSyntax bool) ([]*packages.Package, error) {
 	}
 	mode |= packages.NeedModule
 	conf := packages.Config{
-		Mode:  mode,
-		Tests: IncludeTests,
+		Mode:    mode,
+		Tests:   IncludeTests,
+		Overlay: overlay,
 	}
 	initial, err := packages.Load(&conf, patterns...)
 	if err == nil {
@@ -310,211 +455,477 @@ func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer) []*action
 	// Execute the graph in parallel.
 	execAll(roots)
 
+	// Apply the whole-program phase of each analyzer.
+	for _, a := range analyzers {
+		if a.RunProgram != nil {
+			runProgram(a, roots)
+		}
+	}
+
 	return roots
 }
 
-func applyFixes(roots []*action) error {
-	// visit all of the actions and accumulate the suggested edits.
-	paths := make(map[robustio.FileID]string)
-	editsByAction := make(map[robustio.FileID]map[*action][]diff.Edit)
-	visited := make(map[*action]bool)
-	var apply func(*action) error
-	var visitAll func(actions []*action) error
-	visitAll = func(actions []*action) error {
-		for _, act := range actions {
-			if !visited[act] {
-				visited[act] = true
-				if err := visitAll(act.deps); err != nil {
-					return err
+// runProgram applies the RunProgram function of analyzer a to the
+// packages to which its Run function was successfully applied.
+//
+// Each diagnostic is recorded by the root action for a whose package
+// contains the file of the diagnostic, or by the first root action for a
+// if there is none, so that it is printed, fixed, and suppressed like the
+// others. Likewise, an error is recorded by the first root action.
+func runProgram(a *analysis.Analyzer, roots []*action) {
+	var first *action
+	byFile := make(map[*token.File]*action) // root action for each file
+	for _, act := range roots {
+		if act.a == a {
+			if first == nil {
+				first = act
+			}
+			for _, f := range act.pkg.Syntax {
+				if tf := act.pkg.Fset.File(f.Pos()); tf != nil && byFile[tf] == nil {
+					byFile[tf] = act
+				}
+			}
+		}
+	}
+	if first == nil {
+		return
+	}
+
+	// Gather the successful actions for a, in the order of the graph,
+	// and the facts they export.
+	var (
+		pkgs         []*analysis.ProgramPackage
+		objectFacts  []analysis.ObjectFact
+		packageFacts []analysis.PackageFact
+		seen         = make(map[*action]bool)
+		visit        func(acts []*action)
+	)
+	visit = func(acts []*action) {
+		for _, act := range acts {
+			if seen[act] {
+				continue
+			}
+			seen[act] = true
+			visit(act.deps)
+			if act.a != a || act.err != nil {
+				continue
+			}
+			pkgs = append(pkgs, &analysis.ProgramPackage{
+				Pkg:       act.pkg.Types,
+				Root:      act.isroot,
+				Files:     act.pkg.Syntax,
+				TypesInfo: act.pkg.TypesInfo,
+				Result:    act.result,
+			})
+			// Each fact is inherited by the actions of the packages
+			// that import its package, so take it from the action
+			// that exported it.
+			for key, fact := range act.objectFacts {
+				if key.obj.Pkg() == act.pkg.Types {
+					objectFacts = append(objectFacts, analysis.ObjectFact{Object: key.obj, Fact: fact})
 				}
-				if err := apply(act); err != nil {
-					return err
+			}
+			for key, fact := range act.packageFacts {
+				if key.pkg == act.pkg.Types {
+					packageFacts = append(packageFacts, analysis.PackageFact{Package: key.pkg, Fact: fact})
 				}
 			}
 		}
-		return nil
 	}
+	visit(roots)
+
+	pass := &analysis.ProgramPass{
+		Analyzer: a,
+		Fset:     first.pkg.Fset,
+		Packages: pkgs,
+		Report: func(d analysis.Diagnostic) {
+			if url, err := analysisflags.ResolveURL(a, d); err == nil {
+				d.URL = url
+			} else {
+				first.err = err
+			}
+			act := byFile[first.pkg.Fset.File(d.Pos)]
+			if act == nil {
+				act = first
+			}
+			act.diagnostics = append(act.diagnostics, d)
+		},
+		AllObjectFacts:  func() []analysis.ObjectFact { return append([]analysis.ObjectFact(nil), objectFacts...) },
+		AllPackageFacts: func() []analysis.PackageFact { return append([]analysis.PackageFact(nil), packageFacts...) },
+	}
+	if err := a.RunProgram(pass); err != nil {
+		first.err = fmt.Errorf("whole-program analysis failed: %v", err)
+	}
+}
 
-	apply = func(act *action) error {
-		editsForTokenFile := make(map[*token.File][]diff.Edit)
-		for _, diag := range act.diagnostics {
-			for _, sf := range diag.SuggestedFixes {
-				for _, edit := range sf.TextEdits {
-					// Validate the edit.
-					// Any error here indicates a bug in the analyzer.
-					start, end := edit.Pos, edit.End
-					file := act.pkg.Fset.File(start)
-					if file == nil {
-						return fmt.Errorf("analysis %q suggests invalid fix: missing file info for pos (%v)",
-							act.a.Name, start)
-					}
-					if !end.IsValid() {
-						end = start
-					}
-					if start > end {
-						return fmt.Errorf("analysis %q suggests invalid fix: pos (%v) > end (%v)",
-							act.a.Name, start, end)
-					}
-					if eof := token.Pos(file.Base() + file.Size()); end > eof {
-						return fmt.Errorf("analysis %q suggests invalid fix: end (%v) past end of file (%v)",
-							act.a.Name, end, eof)
-					}
-					edit := diff.Edit{
-						Start: file.Offset(start),
-						End:   file.Offset(end),
-						New:   string(edit.NewText),
+// overlay holds the contents of the files changed by -fix, until they
+// are written. They replace the files on disk when the packages are
+// reanalyzed.
+var overlay map[string][]byte
+
+// maxFixRounds is the maximum number of rounds of analysis performed by
+// applyFixes.
+const maxFixRounds = 10
+
+// A suggestedFix is a suggested fix of a diagnostic, with its edits
+// resolved to offsets within the current contents of their files.
+type suggestedFix struct {
+	act     *action
+	posn    token.Position // position of the diagnostic
+	key     fixKey
+	message string
+	edits   map[string][]diff.Edit // sorted edits to each file, by name
+
+	invalid  string        // if nonempty, why the fix is invalid
+	conflict *suggestedFix // if non-nil, an applied fix that conflicts with this one
+}
+
+// A fixKey identifies a fix across rounds of analysis, in which its
+// position and edits may change: it records the analyzer, the file of
+// the diagnostic, and the messages of the diagnostic and the fix.
+type fixKey struct {
+	analyzer, file, diag, fix string
+}
+
+func (fix *suggestedFix) String() string {
+	msg := fix.message
+	if msg == "" {
+		msg = "fix"
+	}
+	return fmt.Sprintf("%v: %s: %q", fix.posn, fix.act.a.Name, msg)
+}
+
+// applyFixes applies the non-overlapping suggested fixes of the
+// diagnostics of the root actions and their dependencies, and returns
+// the number of fixes that it could not apply.
+//
+// Identical edits from several fixes, such as those of the same package
+// analyzed with and without its tests, are applied once, and the fixes
+// whose edits do not overlap are applied together. Overlapping edits are
+// never merged: a fix whose edits overlap those of a fix already
+// accepted is not applied. If there were such conflicts, the packages are
+// reloaded from the fixed contents and analyzed again, by reanalyze,
+// and the fixes that conflicted are merged again if they are still
+// suggested, until no conflict remains or no file changes. Other
+// fixes are applied only once, as some fixes do not eliminate their
+// diagnostic.
+//
+// Finally, the fixed files are written, or with -diff, their changes
+// are printed as unified diffs, and the fixes that could not be applied
+// are reported.
+func applyFixes(roots []*action, reanalyze func() ([]*action, error)) (int, error) {
+	overlay = make(map[string][]byte)
+	defer func() { overlay = nil }()
+	original := make(map[string][]byte) // contents of the changed files on disk
+
+	var (
+		pending   map[fixKey]int // fixes that conflicted in the previous round
+		unapplied = make(map[fixKey][]*suggestedFix)
+	)
+	for round := 1; ; round++ {
+		fixes, err := suggestedFixes(roots)
+		if err != nil {
+			return 0, err
+		}
+		if pending != nil {
+			// Retry only the fixes that conflicted.
+			retry := fixes[:0]
+			for _, fix := range fixes {
+				if pending[fix.key] > 0 {
+					pending[fix.key]--
+					retry = append(retry, fix)
+					if prev := unapplied[fix.key]; len(prev) > 0 {
+						unapplied[fix.key] = prev[1:]
 					}
-					editsForTokenFile[file] = append(editsForTokenFile[file], edit)
 				}
 			}
+			fixes = retry
 		}
 
-		for f, edits := range editsForTokenFile {
-			id, _, err := robustio.GetFileID(f.Name())
-			if err != nil {
-				return err
+		changed, rejected, err := mergeFixes(fixes)
+		if err != nil {
+			return 0, err
+		}
+		pending = make(map[fixKey]int)
+		for _, fix := range rejected {
+			unapplied[fix.key] = append(unapplied[fix.key], fix)
+			if fix.conflict != nil {
+				pending[fix.key]++
 			}
-			if _, hasId := paths[id]; !hasId {
-				paths[id] = f.Name()
-				editsByAction[id] = make(map[*action][]diff.Edit)
+		}
+		for path, content := range changed {
+			if _, ok := original[path]; !ok {
+				original[path] = readFile(path)
 			}
-			editsByAction[id][act] = edits
+			overlay[path] = content
+		}
+		if len(changed) == 0 || len(pending) == 0 {
+			break // fixpoint
+		}
+		if round == maxFixRounds {
+			log.Printf("fixes still conflicted after %d rounds of analysis", round)
+			break
+		}
+		if dbg('v') {
+			log.Printf("fix round %d changed %d files; reanalyzing", round, len(changed))
+		}
+		if roots, err = reanalyze(); err != nil {
+			return 0, err
 		}
-		return nil
 	}
 
-	if err := visitAll(roots); err != nil {
-		return err
+	// Write or print the fixed files.
+	paths := make([]string, 0, len(overlay))
+	for path := range overlay {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		old, new := original[path], overlay[path]
+		if bytes.Equal(old, new) {
+			continue
+		}
+		if Diff {
+			fmt.Print(diff.Unified(path+".orig", path, string(old), string(new)))
+		} else if err := os.WriteFile(path, new, 0644); err != nil {
+			return 0, err
+		}
 	}
 
-	// Validate and group the edits to each actual file.
-	editsByPath := make(map[string][]diff.Edit)
-	for id, actToEdits := range editsByAction {
-		path := paths[id]
-		actions := make([]*action, 0, len(actToEdits))
-		for act := range actToEdits {
-			actions = append(actions, act)
+	// Report the fixes that were not applied.
+	var report []*suggestedFix
+	for _, fixes := range unapplied {
+		report = append(report, fixes...)
+	}
+	sortFixes(report)
+	for _, fix := range report {
+		reason := fix.invalid
+		if fix.conflict != nil {
+			reason = fmt.Sprintf("conflicts with %v", fix.conflict)
 		}
+		fmt.Fprintf(os.Stderr, "%v: not applied: %s\n", fix, reason)
+	}
+	return len(report), nil
+}
 
-		// Does any action create conflicting edits?
+// suggestedFixes returns the suggested fixes of the diagnostics of the
+// actions and their dependencies, in a deterministic order: by position
+// of their diagnostic, then analyzer name.
+func suggestedFixes(roots []*action) ([]*suggestedFix, error) {
+	paths := make(map[robustio.FileID]string) // canonical name of each file
+	var fixes []*suggestedFix
+	visited := make(map[*action]bool)
+	var visitAll func(actions []*action) error
+	visitAll = func(actions []*action) error {
 		for _, act := range actions {
-			edits := actToEdits[act]
-			if _, invalid := validateEdits(edits); invalid > 0 {
-				name, x, y := act.a.Name, edits[invalid-1], edits[invalid]
-				return diff3Conflict(path, name, name, []diff.Edit{x}, []diff.Edit{y})
+			if visited[act] {
+				continue
 			}
-		}
-
-		// Does any pair of different actions create edits that conflict?
-		for j := range actions {
-			for k := range actions[:j] {
-				x, y := actions[j], actions[k]
-				if x.a.Name > y.a.Name {
-					x, y = y, x
-				}
-				xedits, yedits := actToEdits[x], actToEdits[y]
-				combined := append(xedits, yedits...)
-				if _, invalid := validateEdits(combined); invalid > 0 {
-					// TODO: consider applying each action's consistent list of edits entirely,
-					// and then using a three-way merge (such as GNU diff3) on the resulting
-					// files to report more precisely the parts that actually conflict.
-					return diff3Conflict(path, x.a.Name, y.a.Name, xedits, yedits)
+			visited[act] = true
+			if err := visitAll(act.deps); err != nil {
+				return err
+			}
+			for _, diag := range act.diagnostics {
+				for _, sf := range diag.SuggestedFixes {
+					posn := act.pkg.Fset.Position(diag.Pos)
+					fix := &suggestedFix{
+						act:     act,
+						posn:    posn,
+						key:     fixKey{act.a.Name, posn.Filename, diag.Message, sf.Message},
+						message: sf.Message,
+						edits:   make(map[string][]diff.Edit),
+					}
+					if err := fix.resolve(sf.TextEdits, paths); err != nil {
+						return err
+					}
+					if len(fix.edits) > 0 || fix.invalid != "" {
+						fixes = append(fixes, fix)
+					}
 				}
 			}
 		}
-
-		var edits []diff.Edit
-		for act := range actToEdits {
-			edits = append(edits, actToEdits[act]...)
-		}
-		editsByPath[path], _ = validateEdits(edits) // remove duplicates. already validated.
+		return nil
+	}
+	if err := visitAll(roots); err != nil {
+		return nil, err
 	}
 
-	// Now we've got a set of valid edits for each file. Apply them.
-	for path, edits := range editsByPath {
-		contents, err := os.ReadFile(path)
-		if err != nil {
-			return err
+	sortFixes(fixes)
+	return fixes, nil
+}
+
+// sortFixes sorts fixes by position of their diagnostic, then analyzer
+// name.
+func sortFixes(fixes []*suggestedFix) {
+	sort.SliceStable(fixes, func(i, j int) bool {
+		x, y := fixes[i], fixes[j]
+		if x.posn.Filename != y.posn.Filename {
+			return x.posn.Filename < y.posn.Filename
+		}
+		if x.posn.Offset != y.posn.Offset {
+			return x.posn.Offset < y.posn.Offset
 		}
+		return x.act.a.Name < y.act.a.Name
+	})
+}
 
-		out, err := diff.ApplyBytes(contents, edits)
+// resolve converts the edits of a fix to file offsets and records them
+// in fix.edits. Edits that are invalid, which indicates a bug in the
+// analyzer, make the fix invalid.
+func (fix *suggestedFix) resolve(edits []analysis.TextEdit, paths map[robustio.FileID]string) error {
+	fset := fix.act.pkg.Fset
+	for _, edit := range edits {
+		start, end := edit.Pos, edit.End
+		file := fset.File(start)
+		if file == nil {
+			fix.invalid = fmt.Sprintf("missing file info for pos (%v)", start)
+			return nil
+		}
+		if !end.IsValid() {
+			end = start
+		}
+		if start > end {
+			fix.invalid = fmt.Sprintf("pos (%v) > end (%v)", start, end)
+			return nil
+		}
+		if eof := token.Pos(file.Base() + file.Size()); end > eof {
+			fix.invalid = fmt.Sprintf("end (%v) past end of file (%v)", end, eof)
+			return nil
+		}
+		id, _, err := robustio.GetFileID(file.Name())
 		if err != nil {
 			return err
 		}
-
-		// Try to format the file.
-		if formatted, err := format.Source(out); err == nil {
-			out = formatted
+		path, ok := paths[id]
+		if !ok {
+			path = file.Name()
+			paths[id] = path
 		}
+		fix.edits[path] = append(fix.edits[path], diff.Edit{
+			Start: file.Offset(start),
+			End:   file.Offset(end),
+			New:   string(edit.NewText),
+		})
+	}
 
-		if err := os.WriteFile(path, out, 0644); err != nil {
-			return err
+	// Sort the edits, remove duplicates, and check for overlaps.
+	for path, edits := range fix.edits {
+		diff.SortEdits(edits)
+		unique := edits[:1]
+		for _, edit := range edits[1:] {
+			prev := unique[len(unique)-1]
+			if edit == prev {
+				continue
+			}
+			if overlaps(prev, edit) {
+				fix.invalid = fmt.Sprintf("edits overlap in %s", path)
+				return nil
+			}
+			unique = append(unique, edit)
 		}
+		fix.edits[path] = unique
 	}
 	return nil
 }
 
-// validateEdits returns a list of edits that is sorted and
-// contains no duplicate edits. Returns the index of some
-// overlapping adjacent edits if there is one and <0 if the
-// edits are valid.
-func validateEdits(edits []diff.Edit) ([]diff.Edit, int) {
-	if len(edits) == 0 {
-		return nil, -1
+// overlaps reports whether two edits to the same file conflict: they
+// replace overlapping ranges, or insert text at the same point.
+func overlaps(x, y diff.Edit) bool {
+	if x.Start == x.End && y.Start == y.End {
+		return x.Start == y.Start
 	}
-	equivalent := func(x, y diff.Edit) bool {
-		return x.Start == y.Start && x.End == y.End && x.New == y.New
+	return x.Start < y.End && y.Start < x.End
+}
+
+// mergeFixes applies the fixes that do not conflict with those before
+// them to the files, and formats them. It returns the new contents of
+// the files that changed, and the fixes that were not applied.
+func mergeFixes(fixes []*suggestedFix) (changed map[string][]byte, unapplied []*suggestedFix, _ error) {
+	type acceptedEdit struct {
+		diff.Edit
+		fix *suggestedFix
 	}
-	diff.SortEdits(edits)
-	unique := []diff.Edit{edits[0]}
-	invalid := -1
-	for i := 1; i < len(edits); i++ {
-		prev, cur := edits[i-1], edits[i]
-		// We skip over equivalent edits without considering them
-		// an error. This handles identical edits coming from the
-		// multiple ways of loading a package into a
-		// *go/packages.Packages for testing, e.g. packages "p" and "p [p.test]".
-		if !equivalent(prev, cur) {
-			unique = append(unique, cur)
-			if prev.End > cur.Start {
-				invalid = i
+	accepted := make(map[string][]acceptedEdit)
+	for _, fix := range fixes {
+		if fix.invalid != "" {
+			unapplied = append(unapplied, fix)
+			continue
+		}
+		var conflict *suggestedFix
+	check:
+		for path, edits := range fix.edits {
+			for _, edit := range edits {
+				for _, prev := range accepted[path] {
+					if edit != prev.Edit && overlaps(edit, prev.Edit) {
+						conflict = prev.fix
+						break check
+					}
+				}
+			}
+		}
+		if conflict != nil {
+			fix.conflict = conflict
+			unapplied = append(unapplied, fix)
+			continue
+		}
+		for path, edits := range fix.edits {
+		next:
+			for _, edit := range edits {
+				for _, prev := range accepted[path] {
+					if edit == prev.Edit {
+						continue next // identical edits are applied once
+					}
+				}
+				accepted[path] = append(accepted[path], acceptedEdit{edit, fix})
 			}
 		}
 	}
-	return unique, invalid
-}
 
-// diff3Conflict returns an error describing two conflicting sets of
-// edits on a file at path.
-func diff3Conflict(path string, xlabel, ylabel string, xedits, yedits []diff.Edit) error {
-	contents, err := os.ReadFile(path)
-	if err != nil {
-		return err
-	}
-	oldlabel, old := "base", string(contents)
+	changed = make(map[string][]byte)
+	for path, acc := range accepted {
+		edits := make([]diff.Edit, len(acc))
+		for i, edit := range acc {
+			edits[i] = edit.Edit
+		}
+		content := readFile(path)
+		out, err := diff.ApplyBytes(content, edits)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	xdiff, err := diff.ToUnified(oldlabel, xlabel, old, xedits, diff.DefaultContextLines)
-	if err != nil {
-		return err
-	}
-	ydiff, err := diff.ToUnified(oldlabel, ylabel, old, yedits, diff.DefaultContextLines)
-	if err != nil {
-		return err
+		// Try to format the file.
+		if formatted, err := format.Source(out); err == nil {
+			out = formatted
+		}
+
+		if !bytes.Equal(out, content) {
+			changed[path] = out
+		}
 	}
+	return changed, unapplied, nil
+}
 
-	return fmt.Errorf("conflicting edits from %s and %s on %s\nfirst edits:\n%s\nsecond edits:\n%s",
-		xlabel, ylabel, path, xdiff, ydiff)
+// readFile returns the current contents of the named file, from the
+// overlay if -fix has changed it. It returns nil if the file cannot be
+// read, in which case applying edits to it fails.
+func readFile(name string) []byte {
+	if content, ok := overlay[name]; ok {
+		return content
+	}
+	content, _ := os.ReadFile(name)
+	return content
 }
 
-// printDiagnostics prints the diagnostics for the root packages in either
-// plain text or JSON format. JSON format also includes errors for any
-// dependencies.
+// printDiagnostics prints the diagnostics for the root packages in
+// plain text, JSON or SARIF format. JSON and SARIF formats also include
+// errors for any dependencies. Problems with suppression directives are printed
+// along with the diagnostics.
 //
 // It returns the exitcode: in plain mode, 0 for success, 1 for analysis
 // errors, and 3 for diagnostics. We avoid 2 since the flag package uses
-// it. JSON mode always succeeds at printing errors and diagnostics in a
-// structured form to stdout.
-func printDiagnostics(roots []*action) (exitcode int) {
+// it. JSON and SARIF modes always succeed at printing errors and
+// diagnostics in a structured form to stdout.
+func printDiagnostics(roots []*action, problems map[string][]analysis.Diagnostic) (exitcode int) {
 	// Print the output.
 	//
 	// Print diagnostics only for root packages,
@@ -532,7 +943,50 @@ func printDiagnostics(roots []*action) (exitcode int) {
 		}
 	}
 
-	if analysisflags.JSON {
+	if analysisflags.Format == "sarif" {
+		// SARIF output
+		var analyzers []*analysis.Analyzer
+		isRootAnalyzer := make(map[*analysis.Analyzer]bool)
+		for _, act := range roots {
+			if !isRootAnalyzer[act.a] {
+				isRootAnalyzer[act.a] = true
+				analyzers = append(analyzers, act.a)
+			}
+		}
+		sarif := analysisflags.NewSARIFLog(analyzers)
+
+		// As in plain text output, de-duplicate diagnostics by position
+		// in files that belong to multiple packages.
+		type key struct {
+			pos, end token.Position
+			name     string
+			message  string
+		}
+		seen := make(map[key]bool)
+		add := func(fset *token.FileSet, id, name string, diags []analysis.Diagnostic, err error) {
+			var unique []analysis.Diagnostic
+			for _, diag := range diags {
+				k := key{fset.Position(diag.Pos), fset.Position(diag.End), name, diag.Message}
+				if !seen[k] {
+					seen[k] = true
+					unique = append(unique, diag)
+				}
+			}
+			sarif.Add(fset, id, name, unique, err)
+		}
+		print = func(act *action) {
+			var diags []analysis.Diagnostic
+			if act.isroot {
+				diags = act.diagnostics
+			}
+			add(act.pkg.Fset, act.pkg.ID, act.a.Name, diags, act.err)
+		}
+		visitAll(roots)
+		for _, act := range roots {
+			add(act.pkg.Fset, act.pkg.ID, analysisflags.IgnoreName, problems[act.pkg.ID], nil)
+		}
+		sarif.Print()
+	} else if analysisflags.JSON {
 		// JSON output
 		tree := make(analysisflags.JSONTree)
 		print = func(act *action) {
@@ -543,6 +997,9 @@ func printDiagnostics(roots []*action) (exitcode int) {
 			tree.Add(act.pkg.Fset, act.pkg.ID, act.a.Name, diags, act.err)
 		}
 		visitAll(roots)
+		for _, act := range roots {
+			tree.Add(act.pkg.Fset, act.pkg.ID, analysisflags.IgnoreName, problems[act.pkg.ID], nil)
+		}
 		tree.Print()
 	} else {
 		// plain text output
@@ -583,6 +1040,19 @@ func printDiagnostics(roots []*action) (exitcode int) {
 		}
 		visitAll(roots)
 
+		for _, act := range roots {
+			for _, diag := range problems[act.pkg.ID] {
+				posn := act.pkg.Fset.Position(diag.Pos)
+				k := key{posn, posn, nil, diag.Message}
+				if seen[k] {
+					continue // duplicate
+				}
+				seen[k] = true
+
+				analysisflags.PrintPlain(act.pkg.Fset, diag)
+			}
+		}
+
 		if exitcode == 0 && len(seen) > 0 {
 			exitcode = 3 // successfully produced diagnostics
 		}
@@ -691,8 +1161,23 @@ func execAll(actions []*action) {
 func (act *action) exec() { act.once.Do(act.execOnce) }
 
 func (act *action) execOnce() {
-	// Analyze dependencies.
-	execAll(act.deps)
+	// Analyze dependencies. If the result of this action may be
+	// cached, analyze only the dependencies that provide facts at
+	// first: they suffice to compute the cache key, and analysis of
+	// the others is unnecessary on a hit.
+	var key [sha256.Size]byte
+	cached := diskCache != nil && diskCache.cacheable(act)
+	if cached {
+		var vertical []*action
+		for _, dep := range act.deps {
+			if dep.pkg != act.pkg {
+				vertical = append(vertical, dep)
+			}
+		}
+		execAll(vertical)
+	} else {
+		execAll(act.deps)
+	}
 
 	// TODO(adonovan): uncomment this during profiling.
 	// It won't build pre-go1.11 but conditional compilation
@@ -713,15 +1198,7 @@ func (act *action) execOnce() {
 	}
 
 	// Report an error if any dependency failed.
-	var failed []string
-	for _, dep := range act.deps {
-		if dep.err != nil {
-			failed = append(failed, dep.String())
-		}
-	}
-	if failed != nil {
-		sort.Strings(failed)
-		act.err = fmt.Errorf("failed prerequisites: %s", strings.Join(failed, ", "))
+	if act.err = failedDeps(act.deps); act.err != nil {
 		return
 	}
 
@@ -730,18 +1207,35 @@ func (act *action) execOnce() {
 	inputs := make(map[*analysis.Analyzer]interface{})
 	act.objectFacts = make(map[objectFactKey]analysis.Fact)
 	act.packageFacts = make(map[packageFactKey]analysis.Fact)
+	for _, dep := range act.deps {
+		if dep.pkg != act.pkg && dep.a == act.a { // (always true)
+			// Same analysis, different package (vertical edge):
+			// serialized facts produced by prerequisite analysis
+			// become available to this analysis pass.
+			inheritFacts(act, dep)
+		}
+	}
+
+	// Consult the cache, now that the facts of the dependencies,
+	// on which the result depends, are known.
+	if cached {
+		var err error
+		if key, err = diskCache.key(act); err != nil {
+			cached = false // some file is unreadable
+		} else if diskCache.get(act, key) {
+			return
+		}
+		execAll(act.deps)
+		if act.err = failedDeps(act.deps); act.err != nil {
+			return
+		}
+	}
 	for _, dep := range act.deps {
 		if dep.pkg == act.pkg {
 			// Same package, different analysis (horizontal edge):
 			// in-memory outputs of prerequisite analyzers
 			// become inputs to this analysis pass.
 			inputs[dep.a] = dep.result
-
-		} else if dep.a == act.a { // (always true)
-			// Same analysis, different package (vertical edge):
-			// serialized facts produced by prerequisite analysis
-			// become available to this analysis pass.
-			inheritFacts(act, dep)
 		}
 	}
 
@@ -795,6 +1289,25 @@ func (act *action) execOnce() {
 	// disallow calls after Run
 	pass.ExportObjectFact = nil
 	pass.ExportPackageFact = nil
+
+	if cached && err == nil {
+		diskCache.put(act, key)
+	}
+}
+
+// failedDeps returns an error if any of the dependencies failed.
+func failedDeps(deps []*action) error {
+	var failed []string
+	for _, dep := range deps {
+		if dep.err != nil {
+			failed = append(failed, dep.String())
+		}
+	}
+	if failed != nil {
+		sort.Strings(failed)
+		return fmt.Errorf("failed prerequisites: %s", strings.Join(failed, ", "))
+	}
+	return nil
 }
 
 // inheritFacts populates act.facts with
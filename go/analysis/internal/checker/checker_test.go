@@ -7,16 +7,21 @@ package checker_test
 import (
 	"fmt"
 	"go/ast"
+	"go/types"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
 	"golang.org/x/tools/go/analysis/internal/checker"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
 	"golang.org/x/tools/internal/testenv"
 )
 
@@ -254,3 +259,355 @@ func TestURL(t *testing.T) {
 		t.Errorf("Expected Diagnostics.URLs %v. got %v", want, urls)
 	}
 }
+
+func TestSuppressions(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"suppressed/test.go": `package suppressed //lint:ignore pkgname reported by the test`,
+		"unused/test.go": `package unused
+
+//lint:ignore pkgname nothing to suppress here
+var x int
+`,
+	}
+	pkgname := &analysis.Analyzer{
+		Name: "pkgname",
+		Doc:  "trivial analyzer that reports package names",
+		Run: func(p *analysis.Pass) (interface{}, error) {
+			for _, f := range p.Files {
+				p.ReportRangef(f.Name, "package name is %s", f.Name.Name)
+			}
+			return nil, nil
+		},
+	}
+
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for _, test := range []struct {
+		pkg  string
+		code int
+	}{
+		// The only diagnostic is suppressed.
+		{"suppressed", 0},
+		// The diagnostic is not suppressed, and the directive is unused.
+		{"unused", 3},
+	} {
+		path := filepath.Join(testdata, "src", test.pkg, "test.go")
+		if got := checker.Run([]string{"file=" + path}, []*analysis.Analyzer{pkgname}); got != test.code {
+			t.Errorf("got exit code %d for package %s; want %d", got, test.pkg, test.code)
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"p/a.go": `package p`,
+	}
+	pkgname := &analysis.Analyzer{
+		Name: "pkgname",
+		Doc:  "trivial analyzer that reports package names",
+		Run: func(p *analysis.Pass) (interface{}, error) {
+			for _, f := range p.Files {
+				p.ReportRangef(f.Name, "package name is %s", f.Name.Name)
+			}
+			return nil, nil
+		},
+	}
+
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	dir := filepath.Join(testdata, "src/p")
+	baseline := filepath.Join(testdata, "baseline")
+
+	defer func() {
+		analysisflags.BaselineFile = ""
+		analysisflags.WriteBaseline = false
+	}()
+	analysisflags.BaselineFile = baseline
+	run := func() int {
+		return checker.Run([]string{"file=" + filepath.Join(dir, "a.go")}, []*analysis.Analyzer{pkgname})
+	}
+
+	// Record the finding in a.go.
+	analysisflags.WriteBaseline = true
+	if got := run(); got != 0 {
+		t.Errorf("got exit code %d when writing baseline; want 0", got)
+	}
+	analysisflags.WriteBaseline = false
+
+	// The recorded finding is not reported, even after it moves.
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("// Package p.\n\npackage p\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); got != 0 {
+		t.Errorf("got exit code %d for recorded finding; want 0", got)
+	}
+
+	// A changed finding is reported.
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package p // changed\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); got != 3 {
+		t.Errorf("got exit code %d for new finding; want 3", got)
+	}
+}
+
+// calls is a package fact recording the functions called by a package.
+type calls struct{ Funcs []string }
+
+func (*calls) AFact()           {}
+func (f *calls) String() string { return "calls " + strings.Join(f.Funcs, ", ") }
+
+// uncalled reports exported functions that are not called by any
+// package of the program.
+var uncalled = &analysis.Analyzer{
+	Name:      "uncalled",
+	Doc:       "report exported functions that are never called",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(calls)},
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+		var fact calls
+		inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+			if fn, ok := typeutil.Callee(pass.TypesInfo, n.(*ast.CallExpr)).(*types.Func); ok {
+				fact.Funcs = append(fact.Funcs, fn.FullName())
+			}
+		})
+		if len(fact.Funcs) > 0 {
+			pass.ExportPackageFact(&fact)
+		}
+		return nil, nil
+	},
+	RunProgram: func(pass *analysis.ProgramPass) error {
+		called := make(map[string]bool)
+		for _, f := range pass.AllPackageFacts() {
+			for _, fn := range f.Fact.(*calls).Funcs {
+				called[fn] = true
+			}
+		}
+		for _, pkg := range pass.Packages {
+			scope := pkg.Pkg.Scope()
+			for _, name := range scope.Names() {
+				if fn, ok := scope.Lookup(name).(*types.Func); ok && fn.Exported() && !called[fn.FullName()] {
+					pass.Reportf(fn.Pos(), "%s is never called", fn.Name())
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func TestRunProgram(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"a/a.go": `package a
+
+func Used() {}
+
+func Unused() {} // want "Unused is never called"
+`,
+		"b/b.go": `package b // want package:"calls a.Used"
+
+import "a"
+
+func F() { a.Used() } // want "F is never called"
+`,
+	}
+
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	analysistest.Run(t, testdata, uncalled, "a", "b")
+}
+
+// TestRunProgramPartial checks that the checker treats the packages
+// matched by its patterns as the program, even if they are only part
+// of it: a caller outside them goes unseen.
+func TestRunProgramPartial(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"a/a.go": `package a
+
+func Used() {} // want "Used is never called"
+`,
+		"b/b.go": `package b
+
+import "a"
+
+func F() { a.Used() }
+`,
+	}
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	analysistest.Run(t, testdata, uncalled, "a")
+}
+
+func TestCache(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"a/a.go": `package a
+
+func A() {}
+`,
+		"b/b.go": `package b
+
+import "a"
+
+func B() { a.A() }
+`,
+	}
+
+	// callsa reports calls to functions of packages whose calls fact
+	// is known, and exports a calls fact for each package.
+	var runs int32
+	callsa := &analysis.Analyzer{
+		Name:      "callsa",
+		Doc:       "report calls to functions of packages that call functions",
+		FactTypes: []analysis.Fact{new(calls)},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			atomic.AddInt32(&runs, 1)
+			var fact calls
+			for _, f := range pass.Files {
+				ast.Inspect(f, func(n ast.Node) bool {
+					if call, ok := n.(*ast.CallExpr); ok {
+						if fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func); ok {
+							fact.Funcs = append(fact.Funcs, fn.FullName())
+							if pass.ImportPackageFact(fn.Pkg(), new(calls)) {
+								pass.ReportRangef(call, "call of %s", fn.Name())
+							}
+						}
+					}
+					return true
+				})
+			}
+			pass.ExportPackageFact(&fact)
+			return nil, nil
+		},
+	}
+
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	t.Setenv("GOPATH", testdata)
+	t.Setenv("GO111MODULE", "off")
+	t.Setenv("GOPROXY", "off")
+
+	defer func() { checker.CacheDir = "" }()
+	checker.CacheDir = filepath.Join(testdata, "cache")
+	run := func() int {
+		atomic.StoreInt32(&runs, 0)
+		return checker.Run([]string{"b"}, []*analysis.Analyzer{callsa})
+	}
+
+	// The first run analyzes both packages.
+	if got := run(); got != 3 {
+		t.Errorf("got exit code %d on first run; want 3", got)
+	}
+	if runs != 2 {
+		t.Errorf("analyzer ran %d times on first run; want 2", runs)
+	}
+
+	// The second run reports the same diagnostic, from the cache.
+	if got := run(); got != 3 {
+		t.Errorf("got exit code %d on cached run; want 3", got)
+	}
+	if runs != 0 {
+		t.Errorf("analyzer ran %d times on cached run; want 0", runs)
+	}
+
+	// A change to a dependency invalidates both packages.
+	if err := os.WriteFile(filepath.Join(testdata, "src/a/a.go"), []byte("package a\n\nfunc A() {}\n\nfunc init() { A() }\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); got != 3 {
+		t.Errorf("got exit code %d after change; want 3", got)
+	}
+	if runs != 2 {
+		t.Errorf("analyzer ran %d times after change; want 2", runs)
+	}
+}
+
+func TestCacheRequiredFlags(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"a/a.go": `package a
+
+func A() {}
+`,
+	}
+
+	// report requires mode, whose flag might change its result, and
+	// so the diagnostics of report.
+	mode := &analysis.Analyzer{
+		Name: "mode",
+		Doc:  "a required analyzer with a flag",
+		Run:  func(pass *analysis.Pass) (interface{}, error) { return nil, nil },
+	}
+	strict := mode.Flags.Bool("strict", false, "be strict")
+	var runs int32
+	report := &analysis.Analyzer{
+		Name:     "report",
+		Doc:      "report each file",
+		Requires: []*analysis.Analyzer{mode},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			atomic.AddInt32(&runs, 1)
+			for _, f := range pass.Files {
+				pass.Reportf(f.Package, "file")
+			}
+			return nil, nil
+		},
+	}
+
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	t.Setenv("GOPATH", testdata)
+	t.Setenv("GO111MODULE", "off")
+	t.Setenv("GOPROXY", "off")
+
+	defer func() { checker.CacheDir = "" }()
+	checker.CacheDir = filepath.Join(testdata, "cache")
+	run := func() int32 {
+		atomic.StoreInt32(&runs, 0)
+		if got := checker.Run([]string{"a"}, []*analysis.Analyzer{report}); got != 3 {
+			t.Errorf("got exit code %d; want 3", got)
+		}
+		return runs
+	}
+
+	if runs := run(); runs != 1 {
+		t.Errorf("analyzer ran %d times on first run; want 1", runs)
+	}
+	if runs := run(); runs != 0 {
+		t.Errorf("analyzer ran %d times on cached run; want 0", runs)
+	}
+
+	// A change to the flag of a required analyzer is a cache miss.
+	*strict = true
+	if runs := run(); runs != 1 {
+		t.Errorf("analyzer ran %d times after flag change; want 1", runs)
+	}
+}
@@ -7,7 +7,9 @@ package checker_test
 import (
 	"flag"
 	"fmt"
+	"go/ast"
 	"go/token"
+	"go/types"
 	"log"
 	"os"
 	"os/exec"
@@ -61,10 +63,16 @@ const (
 // directory, applying the comma-separated list of named analyzers to
 // the packages matching the patterns. It returns the CombinedOutput.
 func fix(t *testing.T, dir, analyzers string, wantExit int, patterns ...string) string {
+	return runFlag(t, dir, "-fix", analyzers, wantExit, patterns...)
+}
+
+// runFlag is like fix, but runs the multichecker with the given flag
+// instead of -fix.
+func runFlag(t *testing.T, dir, flag, analyzers string, wantExit int, patterns ...string) string {
 	testenv.NeedsExec(t)
 	testenv.NeedsTool(t, "go")
 
-	cmd := exec.Command(os.Args[0], "-fix")
+	cmd := exec.Command(os.Args[0], flag)
 	cmd.Args = append(cmd.Args, patterns...)
 	cmd.Env = append(os.Environ(),
 		"ANALYZERS="+analyzers,
@@ -185,7 +193,8 @@ func Foo() {
 	}
 }
 
-// TestConflict ensures that checker.Run detects conflicts correctly.
+// TestConflict ensures that checker.Run reports fixes whose own edits
+// overlap, without applying them, and fails.
 // This test fork/execs the main function above.
 func TestConflict(t *testing.T) {
 	files := map[string]string{
@@ -207,7 +216,7 @@ func Foo() {
 
 	out := fix(t, dir, "rename,other", exitCodeFailed, "conflict")
 
-	pattern := `conflicting edits from rename and rename on .*foo.go`
+	pattern := `foo.go:4:2: rename: .*: not applied: edits overlap in .*foo.go`
 	matched, err := regexp.MatchString(pattern, out)
 	if err != nil {
 		t.Errorf("error matching pattern %s: %v", pattern, err)
@@ -228,8 +237,9 @@ func Foo() {
 	}
 }
 
-// TestOther ensures that checker.Run reports conflicts from
-// distinct actions correctly.
+// TestOther ensures that checker.Run applies only the first of two
+// conflicting fixes from distinct actions, and reports the other and
+// fails.
 // This test fork/execs the main function above.
 func TestOther(t *testing.T) {
 	files := map[string]string{
@@ -251,7 +261,7 @@ func Foo() {
 
 	out := fix(t, dir, "rename,other", exitCodeFailed, "other")
 
-	pattern := `.*conflicting edits from other and rename on .*foo.go`
+	pattern := `foo.go:4:2: rename: .*: not applied: conflicts with .*foo.go:4:2: other: `
 	matched, err := regexp.MatchString(pattern, out)
 	if err != nil {
 		t.Errorf("error matching pattern %s: %v", pattern, err)
@@ -259,6 +269,122 @@ func Foo() {
 		t.Errorf("output did not match pattern: %s", pattern)
 	}
 
+	// The fixes of other, which come first, are applied.
+	// (They replace "ar" by "baz".)
+	want := `package other
+
+func Foo() {
+	bbaz := 12
+	_ = bbaz
+}
+
+// the end
+`
+	path := path.Join(dir, "src/other/foo.go")
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(contents); got != want {
+		t.Errorf("contents of %s file did not match expectations. got=%s, want=%s", path, got, want)
+	}
+}
+
+// TestFixpoint ensures that checker.Run reanalyzes the fixed packages
+// to apply the fixes that conflicted with others.
+// This test fork/execs the main function above.
+func TestFixpoint(t *testing.T) {
+	files := map[string]string{
+		"fixpoint/foo.go": `package fixpoint
+
+func Foo() {
+	bar := 12
+	_ = bar
+}
+`,
+	}
+	dir, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatalf("Creating test files failed with %s", err)
+	}
+	defer cleanup()
+
+	// The fix of vardecl conflicts with the first of rename.
+	out := fix(t, dir, "rename,vardecl", exitCodeDiagnostics, "fixpoint")
+	if strings.Contains(out, "not applied") {
+		t.Errorf("some fixes were not applied")
+	}
+
+	want := `package fixpoint
+
+func Foo() {
+	var baz = 12
+	_ = baz
+}
+`
+	path := path.Join(dir, "src/fixpoint/foo.go")
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(contents); got != want {
+		t.Errorf("contents of %s file did not match expectations. got=%s, want=%s", path, got, want)
+	}
+}
+
+func init() {
+	candidates["vardecl"] = &analysis.Analyzer{
+		Name: "vardecl",
+		Doc:  "replaces short variable declarations by var declarations",
+		Run: func(pass *analysis.Pass) (any, error) {
+			for _, f := range pass.Files {
+				ast.Inspect(f, func(n ast.Node) bool {
+					if assign, ok := n.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE && len(assign.Lhs) == 1 {
+						text := fmt.Sprintf("var %s = %s", types.ExprString(assign.Lhs[0]), types.ExprString(assign.Rhs[0]))
+						pass.Report(analysis.Diagnostic{
+							Pos:     assign.Pos(),
+							End:     assign.End(),
+							Message: "short variable declaration",
+							SuggestedFixes: []analysis.SuggestedFix{{
+								Message:   "use a var declaration",
+								TextEdits: []analysis.TextEdit{{Pos: assign.Pos(), End: assign.End(), NewText: []byte(text)}},
+							}},
+						})
+					}
+					return true
+				})
+			}
+			return nil, nil
+		},
+	}
+}
+
+// TestDiff ensures that checker.Run prints the changes of the fixes
+// with -diff, without applying them.
+// This test fork/execs the main function above.
+func TestDiff(t *testing.T) {
+	files := map[string]string{
+		"rename/foo.go": `package rename
+
+func Foo() {
+	bar := 12
+	_ = bar
+}
+`,
+	}
+	dir, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatalf("Creating test files failed with %s", err)
+	}
+	defer cleanup()
+
+	out := runFlag(t, dir, "-diff", "rename", exitCodeDiagnostics, "rename")
+	for _, want := range []string{"-\tbar := 12\n", "+\tbaz := 12\n", "-\t_ = bar\n", "+\t_ = baz\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
 	// No files updated
 	for name, want := range files {
 		path := path.Join(dir, "src", name)
@@ -272,6 +398,28 @@ func Foo() {
 	}
 }
 
+// TestDiffJSON ensures that checker.Run rejects -diff with -json or
+// -format=sarif, whose output would be a mixture of diffs and JSON.
+// This test fork/execs the main function above.
+func TestDiffJSON(t *testing.T) {
+	files := map[string]string{
+		"rename/foo.go": "package rename\n\nfunc Foo() {\n\tbar := 12\n\t_ = bar\n}\n",
+	}
+	dir, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatalf("Creating test files failed with %s", err)
+	}
+	defer cleanup()
+
+	// The format flag precedes the package pattern.
+	for _, format := range []string{"-json", "-format=sarif"} {
+		out := runFlag(t, dir, "-diff", "rename", exitCodeFailed, format, "rename")
+		if want := "-diff is incompatible with -json and -format=sarif"; !strings.Contains(out, want) {
+			t.Errorf("%s: output does not contain %q", format, want)
+		}
+	}
+}
+
 // TestNoEnd tests that a missing SuggestedFix.End position is
 // correctly interpreted as if equal to SuggestedFix.Pos (see issue #64199).
 func TestNoEnd(t *testing.T) {
@@ -0,0 +1,51 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package taint defines an Analyzer that reports flows of untrusted
+// data from sources to sensitive sinks.
+//
+// # Analyzer taint
+//
+// taint: report flows of untrusted data to sensitive operations
+//
+// The taint analyzer tracks data obtained from sources, such as the
+// fields and methods of an incoming *http.Request, through the program
+// and reports places where it reaches a sink, such as the query of a
+// database/sql call, the command run by os/exec, or a conversion to a
+// html/template type that bypasses escaping, without first passing
+// through a sanitizer, such as html/template.HTMLEscapeString.
+// For example:
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		name := r.FormValue("name")
+//		db.Exec("DELETE FROM users WHERE name = '" + name + "'") // tainted data from net/http.Request.FormValue reaches database/sql.DB.Exec
+//	}
+//
+// The diagnostic lists, as related information, the steps of the flow
+// from the source to the sink.
+//
+// Flows are tracked through function calls, including calls to other
+// packages, by means of a summary of each function that records which
+// of its parameters flow to its results, to sinks, or into the memory
+// referenced by its other parameters. Calls whose callee is not known
+// statically, such as calls of interface methods, are assumed to pass
+// tainted arguments to their results and into the memory referenced by
+// their other arguments.
+//
+// The -sources, -sinks and -sanitizers flags each specify a
+// comma-separated list of additional names, using one of the forms:
+//
+//	dir/pkg.Function
+//	dir/pkg.Type.Method
+//	(*dir/pkg.Type).Method
+//	dir/pkg.Type.Field (sources only)
+//	dir/pkg.Type (sinks only)
+//
+// The results of a source function or method, and the values of a
+// source field, are tainted. A sink function or method must not be
+// called with tainted arguments; a suffix #N restricts this to its N'th
+// argument (from zero, not counting the receiver), and may be repeated.
+// A conversion of tainted data to a sink type is reported. The results
+// of a sanitizer are never tainted.
+package taint
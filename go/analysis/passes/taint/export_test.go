@@ -0,0 +1,37 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package taint
+
+// SaveFlags returns a function that restores the sources, sinks and
+// sanitizers, which the flags extend, to their current contents.
+func SaveFlags() (restore func()) {
+	saved := [2]nameSet{make(nameSet), make(nameSet)}
+	for i, ns := range [2]nameSet{sources, sanitizers} {
+		for name := range ns {
+			saved[i][name] = true
+		}
+	}
+	savedSinks := make(sinkSet)
+	for name, args := range sinks {
+		savedSinks[name] = args
+	}
+	return func() {
+		for i, ns := range [2]nameSet{sources, sanitizers} {
+			for name := range ns {
+				if !saved[i][name] {
+					delete(ns, name)
+				}
+			}
+		}
+		for name := range sinks {
+			if _, ok := savedSinks[name]; !ok {
+				delete(sinks, name)
+			}
+		}
+		for name, args := range savedSinks {
+			sinks[name] = args
+		}
+	}
+}
@@ -0,0 +1,883 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package taint
+
+import (
+	_ "embed"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/internal/analysisutil"
+	"golang.org/x/tools/go/ssa"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "taint",
+	Doc:       analysisutil.MustExtractDoc(doc, "taint"),
+	URL:       "https://pkg.go.dev/golang.org/x/tools/go/analysis/passes/taint",
+	Requires:  []*analysis.Analyzer{buildssa.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(summary)},
+}
+
+func init() {
+	Analyzer.Flags.Var(sources, "sources", "comma-separated list of additional sources of tainted data")
+	Analyzer.Flags.Var(sinks, "sinks", "comma-separated list of additional sinks that must not receive tainted data")
+	Analyzer.Flags.Var(sanitizers, "sanitizers", "comma-separated list of additional functions whose results are not tainted")
+}
+
+// sources are the functions, methods and fields whose values are tainted.
+var sources = nameSet{
+	"net/http.Request.Body":            true,
+	"net/http.Request.Form":            true,
+	"net/http.Request.Header":          true,
+	"net/http.Request.Host":            true,
+	"net/http.Request.MultipartForm":   true,
+	"net/http.Request.PostForm":        true,
+	"net/http.Request.RequestURI":      true,
+	"net/http.Request.Trailer":         true,
+	"net/http.Request.URL":             true,
+	"net/http.Request.BasicAuth":       true,
+	"net/http.Request.Cookie":          true,
+	"net/http.Request.Cookies":         true,
+	"net/http.Request.FormFile":        true,
+	"net/http.Request.FormValue":       true,
+	"net/http.Request.MultipartReader": true,
+	"net/http.Request.PostFormValue":   true,
+	"net/http.Request.Referer":         true,
+	"net/http.Request.UserAgent":       true,
+}
+
+// sinks are the functions and methods that must not be called with
+// tainted arguments, and the types to which tainted data must not be
+// converted. The value lists the indices of the arguments to check,
+// not counting the receiver, or nil for all of them.
+var sinks = sinkSet{
+	"database/sql.Conn.ExecContext":     {1},
+	"database/sql.Conn.PrepareContext":  {1},
+	"database/sql.Conn.QueryContext":    {1},
+	"database/sql.Conn.QueryRowContext": {1},
+	"database/sql.DB.Exec":              {0},
+	"database/sql.DB.ExecContext":       {1},
+	"database/sql.DB.Prepare":           {0},
+	"database/sql.DB.PrepareContext":    {1},
+	"database/sql.DB.Query":             {0},
+	"database/sql.DB.QueryContext":      {1},
+	"database/sql.DB.QueryRow":          {0},
+	"database/sql.DB.QueryRowContext":   {1},
+	"database/sql.Tx.Exec":              {0},
+	"database/sql.Tx.ExecContext":       {1},
+	"database/sql.Tx.Prepare":           {0},
+	"database/sql.Tx.PrepareContext":    {1},
+	"database/sql.Tx.Query":             {0},
+	"database/sql.Tx.QueryContext":      {1},
+	"database/sql.Tx.QueryRow":          {0},
+	"database/sql.Tx.QueryRowContext":   {1},
+	"os/exec.Command":                   nil,
+	"os/exec.CommandContext":            nil,
+	"html/template.CSS":                 nil,
+	"html/template.HTML":                nil,
+	"html/template.HTMLAttr":            nil,
+	"html/template.JS":                  nil,
+	"html/template.JSStr":               nil,
+	"html/template.Srcset":              nil,
+	"html/template.URL":                 nil,
+}
+
+// sanitizers are the functions whose results are never tainted.
+var sanitizers = nameSet{
+	"html.EscapeString":              true,
+	"html/template.HTMLEscapeString": true,
+	"html/template.HTMLEscaper":      true,
+	"html/template.JSEscapeString":   true,
+	"html/template.JSEscaper":        true,
+	"html/template.URLQueryEscaper":  true,
+	"net/url.PathEscape":             true,
+	"net/url.QueryEscape":            true,
+	"strconv.Atoi":                   true,
+	"strconv.ParseBool":              true,
+	"strconv.ParseFloat":             true,
+	"strconv.ParseInt":               true,
+	"strconv.ParseUint":              true,
+}
+
+// Labels of tainted data are the indices of the parameters of the
+// current function (followed by its free variables) from which the data
+// came, or fromSource.
+const fromSource = -1
+
+// Destinations of flows, other than parameters.
+const (
+	toResults = -1
+	toSink    = -2
+)
+
+// A flow is a way in which data flows through a function, from one of
+// its parameters or a source to its results, a sink, or the memory
+// referenced by one of its parameters.
+type flow struct {
+	From   int      // index of parameter, or fromSource
+	To     int      // index of parameter, toResults or toSink
+	Source string   // name of the source, if From == fromSource
+	Sink   string   // name of the sink, if To == toSink
+	Path   []string // steps of the flow from a source or to a sink, as "posn: message"
+}
+
+// A summary is a fact that records the flows through a function.
+// The parameters of a method include its receiver.
+type summary struct {
+	Flows []flow
+}
+
+func (*summary) AFact() {}
+
+func (s *summary) String() string {
+	var buf strings.Builder
+	buf.WriteString("taint(")
+	for i, f := range s.Flows {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		if f.From == fromSource {
+			buf.WriteString("source")
+		} else {
+			buf.WriteString(strconv.Itoa(f.From))
+		}
+		buf.WriteString("->")
+		switch f.To {
+		case toResults:
+			buf.WriteString("result")
+		case toSink:
+			buf.WriteString("sink")
+		default:
+			buf.WriteString(strconv.Itoa(f.To))
+		}
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// add adds a flow to the summary, unless it already has a flow with
+// the same ends, and reports whether it did.
+func (s *summary) add(f flow) bool {
+	for _, g := range s.Flows {
+		if g.From == f.From && g.To == f.To {
+			return false
+		}
+	}
+	s.Flows = append(s.Flows, f)
+	return true
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ssainput := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+
+	c := &checker{
+		pass:      pass,
+		summaries: make(map[*ssa.Function]*summary),
+		imported:  make(map[*types.Func]*summary),
+		reported:  make(map[token.Pos]bool),
+	}
+
+	// Compute the summaries of the functions of the package. As they
+	// may be recursive, iterate to a fixed point, analyzing a function
+	// again whenever the summary of one of its callees grows.
+	callers := make(map[*ssa.Function][]*ssa.Function)
+	for _, fn := range ssainput.SrcFuncs {
+		c.summaries[fn] = new(summary)
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if call, ok := instr.(ssa.CallInstruction); ok {
+					if callee := call.Common().StaticCallee(); callee != nil {
+						callee = origin(callee)
+						callers[callee] = append(callers[callee], fn)
+					}
+				}
+			}
+		}
+	}
+	queue := append([]*ssa.Function(nil), ssainput.SrcFuncs...)
+	queued := make(map[*ssa.Function]bool)
+	for _, fn := range queue {
+		queued[fn] = true
+	}
+	for len(queue) > 0 {
+		fn := queue[0]
+		queue = queue[1:]
+		queued[fn] = false
+
+		grew := false
+		for _, f := range c.analyze(fn).Flows {
+			if c.summaries[fn].add(f) {
+				grew = true
+			}
+		}
+		if grew {
+			for _, caller := range callers[fn] {
+				if !queued[caller] {
+					queued[caller] = true
+					queue = append(queue, caller)
+				}
+			}
+		}
+	}
+
+	// Report the flows from sources to sinks.
+	c.report = true
+	for _, fn := range ssainput.SrcFuncs {
+		c.analyze(fn)
+	}
+
+	for _, fn := range ssainput.SrcFuncs {
+		s := c.summaries[fn]
+		if obj, ok := fn.Object().(*types.Func); ok && len(s.Flows) > 0 {
+			sort.Slice(s.Flows, func(i, j int) bool {
+				x, y := s.Flows[i], s.Flows[j]
+				if x.From != y.From {
+					return x.From < y.From
+				}
+				return x.To < y.To
+			})
+			pass.ExportObjectFact(obj, s)
+		}
+	}
+	return nil, nil
+}
+
+// A checker holds the state of the analysis of a package.
+type checker struct {
+	pass      *analysis.Pass
+	summaries map[*ssa.Function]*summary // of the functions of the package
+	imported  map[*types.Func]*summary   // of the functions of other packages
+	report    bool                       // whether to report flows from sources to sinks
+	reported  map[token.Pos]bool
+}
+
+// summaryOf returns the summary of the function, which declares obj
+// if non-nil, or nil if unknown.
+func (c *checker) summaryOf(fn *ssa.Function, obj *types.Func) *summary {
+	if s, ok := c.summaries[fn]; ok {
+		return s
+	}
+	if obj == nil || obj.Pkg() == nil || obj.Pkg() == c.pass.Pkg {
+		return nil
+	}
+	s, ok := c.imported[obj]
+	if !ok {
+		// A function without a fact has no flows.
+		s = new(summary)
+		c.pass.ImportObjectFact(obj, s)
+		c.imported[obj] = s
+	}
+	return s
+}
+
+// analyze analyzes the flows of tainted data through a function,
+// reporting those from sources to sinks if c.report is set,
+// and returns its summary.
+func (c *checker) analyze(fn *ssa.Function) *summary {
+	nparams := len(fn.Params) + len(fn.FreeVars)
+	if fn.Blocks == nil {
+		// Without a body (e.g. in assembly), assume that
+		// every parameter flows to the results.
+		s := new(summary)
+		for i := 0; i < nparams; i++ {
+			s.add(flow{From: i, To: toResults})
+		}
+		return s
+	}
+
+	st := &funcState{
+		checker: c,
+		fn:      fn,
+		taints:  make(map[ssa.Value]taint),
+		summary: new(summary),
+	}
+	for _, p := range fn.Params {
+		st.add(p, st.paramIndex(p), &trace{pos: p.Pos(), msg: "parameter " + p.Name()})
+	}
+	for _, fv := range fn.FreeVars {
+		st.add(fv, st.paramIndex(fv), &trace{pos: fv.Pos(), msg: "captured variable " + fv.Name()})
+	}
+	for {
+		st.changed = false
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				st.visit(instr)
+			}
+		}
+		if !st.changed {
+			break
+		}
+	}
+	return st.summary
+}
+
+// A taint maps the labels of the data from which a value is derived
+// to the trace of how it got there.
+type taint map[int]*trace
+
+// A trace is the last step of the path by which data came to be
+// tainted, linked to the previous steps.
+type trace struct {
+	source   string    // name of the source, for data from a source
+	pos      token.Pos // position of the step in the current package
+	msg      string
+	external bool // msg is a step in another function, prefixed by its position
+	prev     *trace
+}
+
+// then returns the trace extended by a step.
+func (t *trace) then(pos token.Pos, msg string) *trace {
+	return &trace{source: t.source, pos: pos, msg: msg, prev: t}
+}
+
+// thenPath returns the trace extended by the steps of a path in a
+// summary, all of which are attributed to the position of the call.
+func (t *trace) thenPath(pos token.Pos, path []string) *trace {
+	for _, msg := range path {
+		t = &trace{source: t.source, pos: pos, msg: msg, external: true, prev: t}
+	}
+	return t
+}
+
+// steps returns the steps of the trace in order.
+func (t *trace) steps() []*trace {
+	var steps []*trace
+	for ; t != nil; t = t.prev {
+		steps = append(steps, t)
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
+
+// A funcState holds the state of the analysis of a function.
+type funcState struct {
+	*checker
+	fn      *ssa.Function
+	taints  map[ssa.Value]taint
+	summary *summary // flows found so far
+	changed bool     // whether taints changed in this iteration
+}
+
+// add records that v derives from data with the given label,
+// unless already known.
+func (st *funcState) add(v ssa.Value, label int, tr *trace) {
+	t := st.taints[v]
+	if t == nil {
+		t = make(taint)
+		st.taints[v] = t
+	}
+	if _, ok := t[label]; !ok {
+		t[label] = tr
+		st.changed = true
+	}
+}
+
+// merge records that v derives from the data of taint t.
+func (st *funcState) merge(v ssa.Value, t taint) {
+	for label, tr := range t {
+		st.add(v, label, tr)
+	}
+}
+
+// paramIndex returns the index of a parameter or free variable of the
+// function, or -1.
+func (st *funcState) paramIndex(v ssa.Value) int {
+	for i, p := range st.fn.Params {
+		if p == v {
+			return i
+		}
+	}
+	for i, fv := range st.fn.FreeVars {
+		if fv == v {
+			return len(st.fn.Params) + i
+		}
+	}
+	return -1
+}
+
+// path returns the steps of the trace, as stored in summaries.
+func (st *funcState) path(tr *trace) []string {
+	var path []string
+	for _, t := range tr.steps() {
+		msg := t.msg
+		if !t.external && t.pos.IsValid() {
+			msg = st.pass.Fset.Position(t.pos).String() + ": " + msg
+		}
+		path = append(path, msg)
+	}
+	return path
+}
+
+// record records a flow of data with the given label and trace.
+func (st *funcState) record(label, to int, tr *trace) {
+	f := flow{From: label, To: to}
+	if label == fromSource {
+		f.Source = tr.source
+		f.Path = st.path(tr)
+	}
+	st.summary.add(f)
+}
+
+func (st *funcState) visit(instr ssa.Instruction) {
+	switch instr := instr.(type) {
+	case *ssa.Call:
+		st.call(instr, instr, instr.Common())
+
+	case *ssa.Go:
+		st.call(instr, nil, instr.Common())
+
+	case *ssa.Defer:
+		st.call(instr, nil, instr.Common())
+
+	case *ssa.Store:
+		st.store(instr.Addr, st.taints[instr.Val])
+
+	case *ssa.MapUpdate:
+		st.store(instr.Map, st.taints[instr.Key])
+		st.store(instr.Map, st.taints[instr.Value])
+
+	case *ssa.Send:
+		st.store(instr.Chan, st.taints[instr.X])
+
+	case *ssa.Return:
+		for _, res := range instr.Results {
+			for label, tr := range st.taints[res] {
+				st.record(label, toResults, tr)
+			}
+		}
+
+	case *ssa.Field:
+		if !st.field(instr, instr.X.Type(), instr.Field) {
+			st.merge(instr, st.taints[instr.X])
+		}
+
+	case *ssa.FieldAddr:
+		if !st.field(instr, instr.X.Type(), instr.Field) {
+			st.merge(instr, st.taints[instr.X])
+		}
+
+	// The data of an element does not depend on its index or key.
+	case *ssa.Index:
+		st.merge(instr, st.taints[instr.X])
+	case *ssa.IndexAddr:
+		st.merge(instr, st.taints[instr.X])
+	case *ssa.Lookup:
+		st.merge(instr, st.taints[instr.X])
+	case *ssa.Slice:
+		st.merge(instr, st.taints[instr.X])
+
+	case *ssa.ChangeType:
+		st.convert(instr, instr.X)
+	case *ssa.Convert:
+		st.convert(instr, instr.X)
+	case *ssa.MultiConvert:
+		st.convert(instr, instr.X)
+
+	case *ssa.Alloc, *ssa.MakeSlice, *ssa.MakeMap, *ssa.MakeChan:
+		// New memory holds no data.
+
+	case ssa.Value:
+		for _, op := range instr.(ssa.Instruction).Operands(nil) {
+			if *op != nil {
+				st.merge(instr, st.taints[*op])
+			}
+		}
+	}
+}
+
+// field handles a selection of a field of a struct of type x (or a
+// pointer to one), and reports whether the field is a source.
+func (st *funcState) field(v ssa.Value, x types.Type, index int) bool {
+	if p, ok := x.Underlying().(*types.Pointer); ok {
+		x = p.Elem()
+	}
+	s, ok := x.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	name := typeName(x)
+	if name == "" {
+		return false
+	}
+	name += "." + s.Field(index).Name()
+	if !sources[name] {
+		return false
+	}
+	st.add(v, fromSource, &trace{source: name, pos: v.Pos(), msg: "source " + name})
+	return true
+}
+
+// convert handles a conversion of x to the type of v, which may be a
+// sink.
+func (st *funcState) convert(v ssa.Value, x ssa.Value) {
+	st.merge(v, st.taints[x])
+	if name := typeName(v.Type()); name != "" {
+		if _, ok := sinks[name]; ok {
+			for label, tr := range st.taints[x] {
+				st.reachSink(v.Pos(), name, label, tr, nil)
+			}
+		}
+	}
+}
+
+// store records that data of taint t is stored in the memory
+// referenced by addr. This taints addr and the values from which it
+// derives, and records the flows to the parameters among them.
+func (st *funcState) store(addr ssa.Value, t taint) {
+	if len(t) == 0 {
+		return
+	}
+	seen := make(map[ssa.Value]bool)
+	var visit func(v ssa.Value)
+	visit = func(v ssa.Value) {
+		if seen[v] {
+			return
+		}
+		seen[v] = true
+		st.merge(v, t)
+
+		switch v := v.(type) {
+		case *ssa.Parameter, *ssa.FreeVar:
+			index := st.paramIndex(v)
+			for label, tr := range t {
+				if label != index {
+					st.record(label, index, tr)
+				}
+			}
+		case *ssa.FieldAddr:
+			visit(v.X)
+		case *ssa.IndexAddr:
+			visit(v.X)
+		case *ssa.Slice:
+			visit(v.X)
+		case *ssa.UnOp:
+			// Memory referenced by a pointer loaded from X is
+			// assumed to belong to X.
+			if v.Op == token.MUL {
+				visit(v.X)
+			}
+		case *ssa.ChangeType:
+			visit(v.X)
+		case *ssa.Convert:
+			visit(v.X)
+		case *ssa.MultiConvert:
+			visit(v.X)
+		case *ssa.ChangeInterface:
+			visit(v.X)
+		case *ssa.MakeInterface:
+			visit(v.X)
+		case *ssa.SliceToArrayPointer:
+			visit(v.X)
+		case *ssa.TypeAssert:
+			visit(v.X)
+		case *ssa.Extract:
+			visit(v.Tuple)
+		case *ssa.Phi:
+			for _, edge := range v.Edges {
+				visit(edge)
+			}
+		}
+	}
+	visit(addr)
+}
+
+// call handles a function call, whose result (if used) is res.
+func (st *funcState) call(instr ssa.CallInstruction, res ssa.Value, common *ssa.CallCommon) {
+	pos := instr.Pos()
+	args := common.Args
+
+	if b, ok := common.Value.(*ssa.Builtin); ok {
+		switch b.Name() {
+		case "len", "cap":
+			// No data flows to the result.
+		case "copy":
+			st.store(args[0], st.taints[args[1]])
+		default:
+			if res != nil {
+				for _, arg := range args {
+					st.merge(res, st.taints[arg])
+				}
+			}
+		}
+		return
+	}
+
+	callee := common.StaticCallee()
+	if callee == nil {
+		// A dynamic call: the receiver or function value is an input too.
+		st.unknownCall(res, append([]ssa.Value{common.Value}, args...))
+		return
+	}
+	if mc, ok := common.Value.(*ssa.MakeClosure); ok {
+		args = append(args[:len(args):len(args)], mc.Bindings...)
+	}
+	callee = origin(callee)
+
+	// Only a declared function is named; the parameters of a
+	// wrapper may not match those of the method it wraps.
+	var obj *types.Func
+	if o, ok := callee.Object().(*types.Func); ok && callee.Prog.FuncValue(o) == callee {
+		obj = o
+	}
+	var name string
+	if obj != nil {
+		name = funcName(obj)
+	}
+	if name != "" && sanitizers[name] {
+		return
+	}
+	sinkArgs, isSink := sinks[name]
+	if name != "" && isSink {
+		skip := 0
+		if callee.Signature.Recv() != nil {
+			skip = 1
+		}
+		for i := skip; i < len(common.Args); i++ {
+			if sinkArgs != nil && !containsInt(sinkArgs, i-skip) {
+				continue
+			}
+			for label, tr := range st.taints[common.Args[i]] {
+				st.reachSink(pos, name, label, tr, nil)
+			}
+		}
+	}
+	if name != "" && sources[name] {
+		if res != nil {
+			st.add(res, fromSource, &trace{source: name, pos: pos, msg: "source " + name})
+		}
+		return
+	}
+
+	s := st.summaryOf(callee, obj)
+	if s == nil {
+		st.unknownCall(res, args)
+		return
+	}
+	for _, f := range s.Flows {
+		var t taint
+		if f.From == fromSource && len(f.Path) > 0 {
+			tr := &trace{source: f.Source, pos: pos, msg: f.Path[0], external: true}
+			t = taint{fromSource: tr.thenPath(pos, f.Path[1:]).then(pos, "returned by "+callee.String())}
+		} else if f.From < len(args) {
+			t = st.taints[args[f.From]]
+		}
+		switch {
+		case f.To == toResults:
+			if res != nil {
+				st.merge(res, t)
+			}
+		case f.To == toSink:
+			if !isSink { // a sink is reported at its call
+				for label, tr := range t {
+					st.reachSink(pos, f.Sink, label, tr.then(pos, "passed to "+callee.String()), f.Path)
+				}
+			}
+		case f.To < len(args):
+			st.store(args[f.To], t)
+		}
+	}
+}
+
+// unknownCall handles a call of an unknown function, assuming that
+// data flows from each argument to the results and to the memory
+// referenced by the other arguments.
+func (st *funcState) unknownCall(res ssa.Value, args []ssa.Value) {
+	for _, arg := range args {
+		t := st.taints[arg]
+		if res != nil {
+			st.merge(res, t)
+		}
+		for _, other := range args {
+			if other != arg && mayReference(other.Type()) {
+				st.store(other, t)
+			}
+		}
+	}
+}
+
+// reachSink handles data with the given label and trace reaching the
+// named sink at pos, either directly or, if path is non-nil, through
+// the call of a function whose summary has that path to the sink.
+// If the data comes from a source, the flow is reported; if from a
+// parameter, it is recorded in the summary.
+func (st *funcState) reachSink(pos token.Pos, sink string, label int, tr *trace, path []string) {
+	if label != fromSource {
+		if path == nil {
+			tr = tr.then(pos, "sink "+sink)
+		} else {
+			tr = tr.thenPath(pos, path)
+		}
+		st.summary.add(flow{From: label, To: toSink, Sink: sink, Path: st.path(tr)})
+		return
+	}
+
+	if !st.report || st.reported[pos] {
+		return
+	}
+	st.reported[pos] = true
+	var related []analysis.RelatedInformation
+	for _, t := range tr.thenPath(pos, path).steps() {
+		if t.pos.IsValid() {
+			related = append(related, analysis.RelatedInformation{Pos: t.pos, Message: t.msg})
+		}
+	}
+	st.pass.Report(analysis.Diagnostic{
+		Pos:     pos,
+		Message: fmt.Sprintf("tainted data from %s reaches %s", tr.source, sink),
+		Related: related,
+	})
+}
+
+// origin returns the generic function of which fn is an
+// instantiation, or fn itself.
+func origin(fn *ssa.Function) *ssa.Function {
+	if o := fn.Origin(); o != nil {
+		return o
+	}
+	return fn
+}
+
+// funcName returns the name of a function or method, as used in the
+// configuration, or "" if it has none.
+func funcName(fn *types.Func) string {
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		t := recv.Type()
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		if name := typeName(t); name != "" {
+			return name + "." + fn.Name()
+		}
+		return ""
+	}
+	if fn.Pkg() == nil {
+		return ""
+	}
+	return fn.Pkg().Path() + "." + fn.Name()
+}
+
+// typeName returns the name of a named type, as used in the
+// configuration, or "" if it has none.
+func typeName(t types.Type) string {
+	if n, ok := t.(*types.Named); ok && n.Obj().Pkg() != nil {
+		return n.Obj().Pkg().Path() + "." + n.Obj().Name()
+	}
+	return ""
+}
+
+// mayReference reports whether values of type t may reference memory
+// in which a function may store data.
+func mayReference(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Interface:
+		return true
+	}
+	return false
+}
+
+func containsInt(list []int, x int) bool {
+	for _, y := range list {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
+
+// nameSet is a set-of-names-valued flag.
+type nameSet map[string]bool
+
+func (ns nameSet) String() string {
+	var list []string
+	for name := range ns {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+func (ns nameSet) Set(flag string) error {
+	for _, name := range strings.Split(flag, ",") {
+		name, err := parseName(name)
+		if err != nil {
+			return err
+		}
+		ns[name] = true
+	}
+	return nil
+}
+
+// sinkSet is a flag whose value is a set of names of sinks, each with
+// the indices of the arguments to check, or nil for all of them.
+type sinkSet map[string][]int
+
+func (ss sinkSet) String() string {
+	var list []string
+	for name, args := range ss {
+		if args == nil {
+			list = append(list, name)
+		}
+		for _, i := range args {
+			list = append(list, fmt.Sprintf("%s#%d", name, i))
+		}
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+func (ss sinkSet) Set(flag string) error {
+	for _, item := range strings.Split(flag, ",") {
+		item, index, hasIndex := strings.Cut(item, "#")
+		name, err := parseName(item)
+		if err != nil {
+			return err
+		}
+		args, ok := ss[name]
+		if !hasIndex {
+			ss[name] = nil
+			continue
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			return fmt.Errorf("invalid argument index in %q", item+"#"+index)
+		}
+		if ok && args == nil {
+			continue // all arguments are already checked
+		}
+		if !containsInt(args, i) {
+			ss[name] = append(args, i)
+		}
+	}
+	return nil
+}
+
+// parseName parses a name in one of the forms accepted by the flags,
+// and returns it in the form dir/pkg.Name or dir/pkg.Type.Name.
+func parseName(name string) (string, error) {
+	if strings.HasPrefix(name, "(*") {
+		typ, method, ok := strings.Cut(name[len("(*"):], ").")
+		if !ok {
+			return "", fmt.Errorf("invalid name %q", name)
+		}
+		name = typ + "." + method
+	}
+	if i := strings.LastIndex(name, "/"); !strings.Contains(name[i+1:], ".") {
+		return "", fmt.Errorf("invalid name %q: want dir/pkg.Name", name)
+	}
+	return name, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package taint_test
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/passes/taint"
+)
+
+// setFlags configures the analyzer with the sources, sinks and
+// sanitizers of the packages web and sqldb in testdata, until the end
+// of the test.
+func setFlags(t *testing.T) {
+	t.Cleanup(taint.SaveFlags())
+	for flag, value := range map[string]string{
+		"sources":    "web.Request.URL,web.Request.Header,(*web.Request).FormValue,web.Request.UserAgent",
+		"sinks":      "(*sqldb.DB).Exec#0,sqldb.Command,web.HTML",
+		"sanitizers": "web.Escape",
+	} {
+		if err := taint.Analyzer.Flags.Set(flag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test(t *testing.T) {
+	setFlags(t)
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, taint.Analyzer, "a", "c")
+}
+
+// TestStd checks the built-in sources, sinks and sanitizers of the
+// standard library, without flags.
+func TestStd(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, taint.Analyzer, "std")
+}
+
+// TestPath checks the path of a flow through other packages.
+func TestPath(t *testing.T) {
+	setFlags(t)
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, taint.Analyzer, "c")
+	if len(results) != 1 || len(results[0].Diagnostics) == 0 {
+		t.Fatalf("got no diagnostics")
+	}
+	diag := results[0].Diagnostics[0]
+	want := []string{
+		"b.go:14:20: source web.Request.FormValue",
+		"returned by b.Name",
+		"passed to b.Query",
+		"b.go:9:26: parameter q",
+		"b.go:10:9: sink sqldb.DB.Exec",
+	}
+	if len(diag.Related) != len(want) {
+		t.Fatalf("got %d related steps, want %d: %v", len(diag.Related), len(want), diag.Related)
+	}
+	for i, rel := range diag.Related {
+		if !strings.HasSuffix(rel.Message, want[i]) {
+			t.Errorf("step %d: got %q, want suffix %q", i, rel.Message, want[i])
+		}
+	}
+}
+
+func TestFlags(t *testing.T) {
+	t.Cleanup(taint.SaveFlags())
+	for _, test := range []struct {
+		flag, value string
+	}{
+		{"sources", "FormValue"},
+		{"sinks", "sqldb.Exec#x"},
+		{"sanitizers", "(*web.Request.Escape"},
+	} {
+		if err := taint.Analyzer.Flags.Set(test.flag, test.value); err == nil {
+			t.Errorf("-%s=%s: no error", test.flag, test.value)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package a
+
+import (
+	"sqldb"
+	"web"
+)
+
+var db *sqldb.DB
+
+func direct(r *web.Request) {
+	name := r.FormValue("name")
+	db.Exec("DELETE FROM users WHERE name = '" + name + "'") // want `tainted data from web.Request.FormValue reaches sqldb.DB.Exec`
+	db.Exec("DELETE FROM users WHERE name = ?", name)        // ok: a query argument
+
+	db.Exec("SELECT * FROM pages WHERE path = " + r.URL.Path) // want `tainted data from web.Request.URL reaches sqldb.DB.Exec`
+	db.Exec(r.Header["X-Query"][0])                           // want `tainted data from web.Request.Header reaches sqldb.DB.Exec`
+
+	db.Exec(web.Escape(name)) // ok: sanitized
+
+	_ = web.HTML(name)             // want `tainted data from web.Request.FormValue reaches web.HTML`
+	_ = web.HTML(web.Escape(name)) // ok: sanitized
+
+	sqldb.Command("sh", "-c", name) // want `tainted data from web.Request.FormValue reaches sqldb.Command`
+}
+
+func query(db *sqldb.DB, q string) { // want query:`taint\(1->sink\)`
+	db.Exec(q)
+}
+
+func userAgent(r *web.Request) string { // want userAgent:`taint\(source->result\)`
+	return r.UserAgent()
+}
+
+func fill(dst *string, r *web.Request) { // want fill:`taint\(source->0\)`
+	*dst = r.UserAgent()
+}
+
+func join(parts []string) string { // want join:`taint\(0->result\)`
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[0] + "," + join(parts[1:])
+}
+
+type buffer struct{ data []byte }
+
+func (b *buffer) write(s string) { // want write:`taint\(1->0\)`
+	b.data = append(b.data, s...)
+}
+
+func (b *buffer) String() string { // want String:`taint\(0->result\)`
+	return string(b.data)
+}
+
+func indirect(r *web.Request) {
+	query(db, r.FormValue("q")) // want `tainted data from web.Request.FormValue reaches sqldb.DB.Exec`
+	query(db, "SELECT 1")
+
+	db.Exec(userAgent(r)) // want `tainted data from web.Request.UserAgent reaches sqldb.DB.Exec`
+
+	var s string
+	fill(&s, r)
+	db.Exec(s) // want `tainted data from web.Request.UserAgent reaches sqldb.DB.Exec`
+
+	db.Exec(join([]string{"a", r.FormValue("b")})) // want `tainted data from web.Request.FormValue reaches sqldb.DB.Exec`
+	db.Exec(join([]string{"a", "b"}))
+
+	var buf buffer
+	buf.write("SELECT * FROM t WHERE x = ")
+	buf.write(r.FormValue("x"))
+	db.Exec(buf.String()) // want `tainted data from web.Request.FormValue reaches sqldb.DB.Exec`
+}
+
+func dynamic(r *web.Request, f func(string) string) { // want dynamic:`taint\(1->sink\)`
+	db.Exec(f(r.FormValue("q"))) // want `tainted data from web.Request.FormValue reaches sqldb.DB.Exec`
+}
+
+func closure(r *web.Request) {
+	q := r.FormValue("q")
+	defer func() { // want `tainted data from web.Request.FormValue reaches sqldb.DB.Exec`
+		db.Exec(q)
+	}()
+}
@@ -0,0 +1,23 @@
+// Package b provides functions through which tainted data flows.
+package b
+
+import (
+	"sqldb"
+	"web"
+)
+
+func Query(db *sqldb.DB, q string) { // want Query:`taint\(1->sink\)`
+	db.Exec(q)
+}
+
+func Name(r *web.Request) string { // want Name:`taint\(source->result\)`
+	return r.FormValue("name")
+}
+
+func Quote(s string) string { // want Quote:`taint\(0->result\)`
+	return "'" + s + "'"
+}
+
+func Clean(s string) string {
+	return web.Escape(s)
+}
@@ -0,0 +1,13 @@
+package c
+
+import (
+	"b"
+	"sqldb"
+	"web"
+)
+
+func handler(db *sqldb.DB, r *web.Request) {
+	b.Query(db, "SELECT * FROM users WHERE name = "+b.Quote(b.Name(r))) // want `tainted data from web.Request.FormValue reaches sqldb.DB.Exec`
+	b.Query(db, "SELECT * FROM users WHERE name = "+b.Clean(b.Name(r)))
+	b.Query(db, "SELECT * FROM users WHERE name = "+b.Quote(r.UserAgent())) // want `tainted data from web.Request.UserAgent reaches sqldb.DB.Exec`
+}
@@ -0,0 +1,8 @@
+// Package sqldb mimics the sinks of database/sql.
+package sqldb
+
+type DB struct{}
+
+func (db *DB) Exec(query string, args ...interface{}) error { return nil }
+
+func Command(name string, args ...string) {}
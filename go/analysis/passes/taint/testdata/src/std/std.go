@@ -0,0 +1,19 @@
+package std
+
+import (
+	"database/sql"
+	"html/template"
+	"net/http"
+	"os/exec"
+)
+
+func handler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	name := r.FormValue("name")
+	db.Exec("DELETE FROM users WHERE name = '" + name + "'") // want `tainted data from net/http.Request.FormValue reaches database/sql.DB.Exec`
+	db.Query("SELECT * FROM users WHERE name = ?", name)     // ok: a query argument
+
+	exec.Command("sh", "-c", name) // want `tainted data from net/http.Request.FormValue reaches os/exec.Command`
+
+	_ = template.HTML(r.UserAgent())                            // want `tainted data from net/http.Request.UserAgent reaches html/template.HTML`
+	_ = template.HTML(template.HTMLEscapeString(r.UserAgent())) // ok: sanitized
+}
@@ -0,0 +1,19 @@
+// Package web mimics the sources of net/http.
+package web
+
+type Request struct {
+	URL    *URL
+	Header map[string][]string
+}
+
+type URL struct{ Path, RawQuery string }
+
+func (r *Request) FormValue(key string) string { return "" }
+
+func (r *Request) UserAgent() string { return "" }
+
+// Escape is a sanitizer.
+func Escape(s string) string { return s }
+
+// HTML is a sink type.
+type HTML string
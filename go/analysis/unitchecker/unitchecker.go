@@ -18,6 +18,14 @@
 // If you need a standalone tool, use multichecker,
 // which supports this mode but can also load packages
 // from source using go/packages.
+//
+// Because each compilation unit is analyzed by a separate process,
+// this driver never calls the RunProgram function of an analyzer:
+// there is no point at which the whole program is available.
+//
+// For the same reason, this driver rejects -format=sarif: go vet would
+// concatenate the logs of the packages, which is not a valid SARIF
+// file. Use the multichecker or singlechecker drivers to produce one.
 package unitchecker
 
 // TODO(adonovan):
@@ -124,13 +132,19 @@ Usage of %[1]s:
 // and calls os.Exit with an appropriate error code.
 // It assumes flags have already been set.
 func Run(configFile string, analyzers []*analysis.Analyzer) {
+	// The build system runs the tool in the directory of each package,
+	// so a relative file name would denote a different file for each.
+	if f := analysisflags.BaselineFile; f != "" && !filepath.IsAbs(f) {
+		log.Fatalf("-baseline=%s: the file name must be absolute, as go vet analyzes each package in its own directory", f)
+	}
+
 	cfg, err := readConfig(configFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	fset := token.NewFileSet()
-	results, err := run(fset, cfg, analyzers)
+	results, problems, err := run(fset, cfg, analyzers)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -143,6 +157,7 @@ func Run(configFile string, analyzers []*analysis.Analyzer) {
 			for _, res := range results {
 				tree.Add(fset, cfg.ID, res.a.Name, res.diagnostics, res.err)
 			}
+			tree.Add(fset, cfg.ID, analysisflags.IgnoreName, problems, nil)
 			tree.Print()
 		} else {
 			// plain text
@@ -159,6 +174,10 @@ func Run(configFile string, analyzers []*analysis.Analyzer) {
 					exit = 1
 				}
 			}
+			for _, diag := range problems {
+				analysisflags.PrintPlain(fset, diag)
+				exit = 1
+			}
 			os.Exit(exit)
 		}
 	}
@@ -234,7 +253,7 @@ var (
 	}
 )
 
-func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]result, error) {
+func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]result, []analysis.Diagnostic, error) {
 	// Load, parse, typecheck.
 	var files []*ast.File
 	for _, name := range cfg.GoFiles {
@@ -245,7 +264,7 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 				// report parse errors.
 				err = nil
 			}
-			return nil, err
+			return nil, nil, err
 		}
 		files = append(files, f)
 	}
@@ -272,7 +291,7 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 			// report type errors.
 			err = nil
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Register fact types with gob.
@@ -323,7 +342,7 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 	// Read facts from imported packages.
 	facts, err := facts.NewDecoder(pkg).Decode(makeFactImporter(cfg))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// In parallel, execute the DAG of analyzers.
@@ -420,15 +439,74 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 		results[i].diagnostics = act.diagnostics
 	}
 
+	// Discard the diagnostics suppressed by //lint:ignore directives,
+	// and report problems with the directives themselves. Also discard
+	// those outside the scope of their analyzer in the -config file.
+	var problems []analysis.Diagnostic
+	if !cfg.VetxOnly {
+		sup := analysisflags.NewSuppressions(fset)
+		sup.AddFiles(cfg.ID, files)
+		ran := make(map[string]bool)
+		for i := range results {
+			res := &results[i]
+			res.diagnostics = sup.Filter(res.a.Name, res.diagnostics)
+			res.diagnostics = analysisflags.Configuration.FilterScope(res.a.Name, cfg.ImportPath, res.diagnostics)
+			if res.err == nil {
+				ran[res.a.Name] = true
+			}
+		}
+		problems = sup.Problems(ran)[cfg.ID]
+
+		// Discard the diagnostics recorded in the baseline, or record them.
+		if analysisflags.BaselineFile != "" {
+			if err := applyBaseline(fset, cfg, results); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
 	data := facts.Encode()
 	if err := exportFacts(cfg, data); err != nil {
-		return nil, fmt.Errorf("failed to export analysis facts: %v", err)
+		return nil, nil, fmt.Errorf("failed to export analysis facts: %v", err)
 	}
 	if err := exportTypes(cfg, fset, pkg); err != nil {
-		return nil, fmt.Errorf("failed to export type information: %v", err)
+		return nil, nil, fmt.Errorf("failed to export type information: %v", err)
+	}
+
+	return results, problems, nil
+}
+
+// applyBaseline discards the diagnostics of the results that are
+// recorded in the -baseline file, and reports the stale entries of the
+// file for this package. With -write-baseline, it instead appends all the
+// diagnostics to the file.
+func applyBaseline(fset *token.FileSet, cfg *Config, results []result) error {
+	filename := analysisflags.BaselineFile
+	if analysisflags.WriteBaseline {
+		b := analysisflags.NewBaseline(fset)
+		var entries []analysisflags.BaselineEntry
+		for i := range results {
+			res := &results[i]
+			for _, diag := range res.diagnostics {
+				entries = append(entries, b.Entry(cfg.ID, res.a.Name, diag))
+			}
+			res.diagnostics = nil
+		}
+		return analysisflags.AppendBaseline(filename, entries)
 	}
 
-	return results, nil
+	b, err := analysisflags.ReadBaseline(fset, filename)
+	if err != nil {
+		return err
+	}
+	for i := range results {
+		res := &results[i]
+		if res.err == nil { // a failed analysis leaves its entries unchecked
+			res.diagnostics = b.Filter(cfg.ID, res.a.Name, res.diagnostics)
+		}
+	}
+	analysisflags.PrintStale(filename, b.Stale())
+	return nil
 }
 
 type result struct {
@@ -10,6 +10,7 @@ import (
 	"flag"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -191,3 +192,155 @@ func _() {
 		}
 	}
 }
+
+// TestBaseline checks that a baseline written by go vet for several
+// packages, each analyzed in its own directory, suppresses their
+// diagnostics, and that a relative baseline file name is rejected.
+func TestBaseline(t *testing.T) { packagestest.TestAll(t, testBaseline) }
+func testBaseline(t *testing.T, exporter packagestest.Exporter) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skipf("skipping fork/exec test on this platform")
+	}
+
+	exported := packagestest.Export(t, exporter, []packagestest.Module{{
+		Name: "golang.org/fake",
+		Files: map[string]interface{}{
+			"a/a.go": `package a
+
+func _() {
+	MyFunc123()
+}
+
+func MyFunc123() {}
+`,
+			"b/b.go": `package b
+
+func _() {
+	MyFunc123()
+}
+
+func MyFunc123() {}
+`,
+		}}})
+	defer exported.Cleanup()
+
+	vet := func(args ...string) (string, int) {
+		cmd := exec.Command("go", "vet", "-vettool="+os.Args[0], "-findcall.name=MyFunc123")
+		cmd.Args = append(cmd.Args, args...)
+		cmd.Args = append(cmd.Args, "golang.org/fake/a", "golang.org/fake/b")
+		cmd.Env = append(exported.Config.Env, "ENTRYPOINT=minivet")
+		cmd.Dir = exported.Config.Dir
+		out, err := cmd.CombinedOutput()
+		exitcode := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitcode = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		return string(out), exitcode
+	}
+
+	out, exitcode := vet("-baseline=baseline.json", "-write-baseline")
+	if exitcode == 0 || !strings.Contains(out, "must be absolute") {
+		t.Errorf("go vet with a relative -baseline: got exit code %d and output <<%s>>, want failure", exitcode, out)
+	}
+
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	if out, exitcode := vet("-baseline="+baseline, "-write-baseline"); exitcode != 0 {
+		t.Fatalf("go vet -write-baseline: exit code %d, output <<%s>>", exitcode, out)
+	}
+	data, err := os.ReadFile(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range []string{"golang.org/fake/a", "golang.org/fake/b"} {
+		if !strings.Contains(string(data), `"package":"`+pkg+`"`) {
+			t.Errorf("baseline lacks the diagnostic of %s:\n%s", pkg, data)
+		}
+	}
+
+	if out, exitcode := vet("-baseline=" + baseline); exitcode != 0 || strings.Contains(out, "MyFunc123") {
+		t.Errorf("go vet -baseline: got exit code %d and output <<%s>>, want no diagnostics", exitcode, out)
+	}
+}
+
+// TestConfigFile checks that go vet requires an absolute -config file,
+// and applies its settings to each package.
+func TestConfigFile(t *testing.T) { packagestest.TestAll(t, testConfigFile) }
+func testConfigFile(t *testing.T, exporter packagestest.Exporter) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skipf("skipping fork/exec test on this platform")
+	}
+
+	exported := packagestest.Export(t, exporter, []packagestest.Module{{
+		Name: "golang.org/fake",
+		Files: map[string]interface{}{
+			"a/a.go": `package a
+
+func _() {
+	MyFunc123()
+}
+
+func MyFunc123() {}
+`,
+			"a/config.json": `{"analyzers": {"findcall": {"flags": {"name": "MyFunc123"}}}}`,
+		}}})
+	defer exported.Cleanup()
+
+	vet := func(config string) (string, int) {
+		cmd := exec.Command("go", "vet", "-vettool="+os.Args[0], "-config="+config, "golang.org/fake/a")
+		cmd.Env = append(exported.Config.Env, "ENTRYPOINT=minivet")
+		cmd.Dir = exported.Config.Dir
+		out, err := cmd.CombinedOutput()
+		exitcode := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitcode = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		return string(out), exitcode
+	}
+
+	// config.json exists in the directory of the package,
+	// but a relative name is rejected all the same.
+	if out, exitcode := vet("config.json"); exitcode == 0 || !strings.Contains(out, "must be absolute") {
+		t.Errorf("go vet with a relative -config: got exit code %d and output <<%s>>, want failure", exitcode, out)
+	}
+
+	config := exported.File("golang.org/fake", "a/config.json")
+	if out, _ := vet(config); !strings.Contains(out, "call of MyFunc123") {
+		t.Errorf("go vet -config: got output <<%s>>, want a call of MyFunc123", out)
+	}
+}
+
+// TestSARIF checks that go vet rejects -format=sarif, as the logs of
+// the packages, which are analyzed separately, would not form a valid
+// SARIF file.
+func TestSARIF(t *testing.T) { packagestest.TestAll(t, testSARIF) }
+func testSARIF(t *testing.T, exporter packagestest.Exporter) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skipf("skipping fork/exec test on this platform")
+	}
+
+	exported := packagestest.Export(t, exporter, []packagestest.Module{{
+		Name: "golang.org/fake",
+		Files: map[string]interface{}{
+			"a/a.go": `package a
+
+func _() {
+	MyFunc123()
+}
+
+func MyFunc123() {}
+`,
+		}}})
+	defer exported.Cleanup()
+
+	cmd := exec.Command("go", "vet", "-vettool="+os.Args[0], "-findcall.name=MyFunc123", "-format=sarif", "golang.org/fake/a")
+	cmd.Env = append(exported.Config.Env, "ENTRYPOINT=minivet")
+	cmd.Dir = exported.Config.Dir
+	out, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "-format=sarif is not supported under go vet") {
+		t.Errorf("go vet -format=sarif: got error %v and output <<%s>>, want failure", err, out)
+	}
+}
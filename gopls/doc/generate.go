@@ -37,6 +37,7 @@ import (
 	"golang.org/x/tools/gopls/internal/lsp/mod"
 	"golang.org/x/tools/gopls/internal/lsp/safetoken"
 	"golang.org/x/tools/gopls/internal/lsp/source"
+	"golang.org/x/tools/gopls/internal/lsp/work"
 	"golang.org/x/tools/gopls/internal/settings"
 )
 
@@ -497,6 +498,12 @@ func loadLenses(commands []*settings.CommandJSON) []*settings.LensJSON {
 		}
 		all[k] = struct{}{}
 	}
+	for k := range work.LensFuncs() {
+		if _, ok := all[k]; ok {
+			panic(fmt.Sprintf("duplicate lens %q", string(k)))
+		}
+		all[k] = struct{}{}
+	}
 
 	var lenses []*settings.LensJSON
 
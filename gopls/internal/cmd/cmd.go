@@ -65,6 +65,9 @@ type Application struct {
 	// Support for remote LSP server.
 	Remote string `flag:"remote" help:"forward all commands to a remote lsp specified by this flag. With no special prefix, this is assumed to be a TCP address. If prefixed by 'unix;', the subsequent address is assumed to be a unix domain socket. If 'auto', or prefixed by 'auto;', the remote address is automatically resolved based on the executing environment."`
 
+	// RemoteToken authenticates to a remote LSP server that requires it.
+	RemoteToken string `flag:"remote.token" help:"token with which to authenticate to the remote lsp, if it requires one"`
+
 	// Verbose enables verbose logging.
 	Verbose bool `flag:"v,verbose" help:"verbose output"`
 
@@ -374,6 +377,11 @@ func (app *Application) connectRemote(ctx context.Context, remote string) (*conn
 	cc.Go(ctx,
 		protocol.Handlers(
 			protocol.ClientHandler(client, jsonrpc2.MethodNotFound)))
+	if app.RemoteToken != "" {
+		if err := lsprpc.Authenticate(ctx, cc, app.RemoteToken); err != nil {
+			return nil, err
+		}
+	}
 	return connection, connection.initialize(ctx, app.options)
 }
 
@@ -60,13 +60,15 @@ func (r *remote) ShortHelp() string {
 // listSessions is an inspect subcommand to list current sessions.
 type listSessions struct {
 	app *Application
+
+	Kill string `flag:"kill" help:"shut down the session with the given ID, instead of listing sessions"`
 }
 
 func (c *listSessions) Name() string   { return "sessions" }
 func (c *listSessions) Parent() string { return c.app.Name() }
-func (c *listSessions) Usage() string  { return "" }
+func (c *listSessions) Usage() string  { return "[sessions-flags]" }
 func (c *listSessions) ShortHelp() string {
-	return "print information about current gopls sessions"
+	return "print information about current gopls sessions, or shut one down"
 }
 
 const listSessionsExamples = `
@@ -81,6 +83,12 @@ $ gopls remote sessions
 2) list sessions for a specific daemon:
 
 $ gopls -remote=localhost:8082 remote sessions
+
+3) shut down session 3 of a daemon that requires authentication:
+
+$ gopls -remote=localhost:8082 -remote.token=secret remote sessions -kill=3
+
+sessions-flags:
 `
 
 func (c *listSessions) DetailedHelp(f *flag.FlagSet) {
@@ -93,7 +101,14 @@ func (c *listSessions) Run(ctx context.Context, args ...string) error {
 	if remote == "" {
 		remote = "auto"
 	}
-	state, err := lsprpc.QueryServerState(ctx, remote)
+	if c.Kill != "" {
+		if err := lsprpc.KillSession(ctx, remote, c.app.RemoteToken, c.Kill); err != nil {
+			return err
+		}
+		fmt.Printf("killed session %s\n", c.Kill)
+		return nil
+	}
+	state, err := lsprpc.QueryServerState(ctx, remote, c.app.RemoteToken)
 	if err != nil {
 		return err
 	}
@@ -151,7 +166,7 @@ func (c *startDebugging) Run(ctx context.Context, args ...string) error {
 		Addr: debugAddr,
 	}
 	var result command.DebuggingResult
-	if err := lsprpc.ExecuteCommand(ctx, remote, command.StartDebugging.ID(), debugArgs, &result); err != nil {
+	if err := lsprpc.ExecuteCommand(ctx, remote, c.app.RemoteToken, command.StartDebugging.ID(), debugArgs, &result); err != nil {
 		return err
 	}
 	if len(result.URLs) == 0 {
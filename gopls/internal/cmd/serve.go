@@ -34,6 +34,9 @@ type Serve struct {
 	Trace       bool          `flag:"rpc.trace" help:"print the full rpc trace in lsp inspector format"`
 	Debug       string        `flag:"debug" help:"serve debug information on the supplied address"`
 
+	Tokens         string        `flag:"listen.tokens" help:"when used with -listen, a file of tokens with which clients must authenticate, one per line, each optionally followed by the name of the client"`
+	SessionTimeout time.Duration `flag:"listen.session.timeout" help:"when used with -listen, shut down sessions that have received no messages for this duration"`
+
 	RemoteListenTimeout time.Duration `flag:"remote.listen.timeout" help:"when used with -remote=auto, the -listen.timeout value used to start the daemon"`
 	RemoteDebug         string        `flag:"remote.debug" help:"when used with -remote=auto, the -debug value used to start the daemon"`
 	RemoteLogfile       string        `flag:"remote.logfile" help:"when used with -remote=auto, the -logfile value used to start the daemon"`
@@ -95,12 +98,24 @@ func (s *Serve) Run(ctx context.Context, args ...string) error {
 	var ss jsonrpc2.StreamServer
 	if s.app.Remote != "" {
 		var err error
-		ss, err = lsprpc.NewForwarder(s.app.Remote, s.remoteArgs)
+		ss, err = lsprpc.NewForwarder(s.app.Remote, s.app.RemoteToken, s.remoteArgs)
 		if err != nil {
 			return fmt.Errorf("creating forwarder: %w", err)
 		}
 	} else {
-		ss = lsprpc.NewStreamServer(cache.New(nil), isDaemon, s.app.options)
+		server := lsprpc.NewStreamServer(cache.New(nil), isDaemon, s.app.options)
+		if isDaemon {
+			opts := lsprpc.DaemonOptions{IdleTimeout: s.SessionTimeout}
+			if s.Tokens != "" {
+				tokens, err := lsprpc.ReadTokens(s.Tokens)
+				if err != nil {
+					return fmt.Errorf("reading tokens: %w", err)
+				}
+				opts.Tokens = tokens
+			}
+			server.SetDaemonOptions(opts)
+		}
+		ss = server
 	}
 
 	var network, addr string
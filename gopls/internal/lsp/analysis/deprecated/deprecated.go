@@ -7,6 +7,7 @@ package deprecated
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
 	"go/format"
 	"go/token"
@@ -57,7 +58,13 @@ func checkDeprecated(pass *analysis.Pass) (interface{}, error) {
 			buf.Reset()
 			buf.WriteString("declaration")
 		}
-		pass.ReportRangef(node, "%s is deprecated: %s", buf, depr.Msg)
+		pass.Report(analysis.Diagnostic{
+			Pos:      node.Pos(),
+			End:      node.End(),
+			Message:  fmt.Sprintf("%s is deprecated: %s", buf, depr.Msg),
+			Severity: analysis.SeverityHint,
+			Tags:     []analysis.Tag{analysis.TagDeprecated},
+		})
 	}
 
 	nodeFilter := []ast.Node{(*ast.SelectorExpr)(nil)}
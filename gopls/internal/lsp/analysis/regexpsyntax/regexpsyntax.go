@@ -0,0 +1,76 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package regexpsyntax defines an Analyzer that checks the syntax of
+// constant regular expressions passed to the regexp package.
+package regexpsyntax
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"regexp"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `check the syntax of regular expressions
+
+This analyzer reports calls to functions of the regexp package, such as
+regexp.MustCompile or regexp.MatchString, whose pattern is a constant
+that is not a valid regular expression. For example:
+
+	var re = regexp.MustCompile("(a|b") // error: missing closing )
+
+Such calls fail, or panic, every time they are executed.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "regexpsyntax",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// PatternFuncs maps the full names of the functions of the regexp package
+// whose first argument is a pattern to the functions that compile it.
+var PatternFuncs = map[string]func(string) (*regexp.Regexp, error){
+	"regexp.Compile":          regexp.Compile,
+	"regexp.CompilePOSIX":     regexp.CompilePOSIX,
+	"regexp.Match":            regexp.Compile,
+	"regexp.MatchReader":      regexp.Compile,
+	"regexp.MatchString":      regexp.Compile,
+	"regexp.MustCompile":      regexp.Compile,
+	"regexp.MustCompilePOSIX": regexp.CompilePOSIX,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || len(call.Args) == 0 {
+			return
+		}
+		compile, ok := PatternFuncs[fn.FullName()]
+		if !ok {
+			return
+		}
+		arg := call.Args[0]
+		tv := pass.TypesInfo.Types[arg]
+		if tv.Value == nil || tv.Value.Kind() != constant.String {
+			return
+		}
+		if _, err := compile(constant.StringVal(tv.Value)); err != nil {
+			pass.ReportRangef(arg, "%v", err)
+		}
+	})
+	return nil, nil
+}
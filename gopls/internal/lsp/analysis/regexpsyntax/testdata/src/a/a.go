@@ -0,0 +1,26 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+import (
+	"regexp"
+	"strings"
+)
+
+const bad = `a[b`
+
+var (
+	_ = regexp.MustCompile(`^a+b*$`)
+	_ = regexp.MustCompile("(a|b")      // want "missing closing \\)"
+	_ = regexp.MustCompilePOSIX(`a\Cb`) // want "invalid escape sequence"
+	_ = regexp.MustCompile(bad)         // want "missing closing \\]"
+	_ = strings.Contains("(a|b", "a")
+)
+
+func _(s string) {
+	_, _ = regexp.Compile(s)
+	_, _ = regexp.MatchString(`\pX`, s) // want "invalid character class range"
+	_, _ = regexp.MatchString(`\w+`, s)
+}
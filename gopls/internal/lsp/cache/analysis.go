@@ -37,6 +37,7 @@ import (
 	"golang.org/x/tools/gopls/internal/lsp/frob"
 	"golang.org/x/tools/gopls/internal/lsp/progress"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/safetoken"
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/event/tag"
@@ -319,6 +320,31 @@ func (snapshot *Snapshot) Analyze(ctx context.Context, pkgs map[PackageID]unit,
 		roots = append(roots, root)
 	}
 
+	// The whole-program phase of an analyzer needs the facts of
+	// every package, so don't release a node's action summaries
+	// once its predecessors are done with them.
+	//
+	// A batch that omits some workspace packages (for example, one
+	// that analyzes only the packages of open files) is not a whole
+	// program: a RunProgram function would report false positives,
+	// such as an unused function whose only callers were omitted,
+	// so skip the whole-program phase in that case.
+	var programAnalyzers []*analysis.Analyzer
+	if whole, err := snapshot.isWholeProgram(ctx, pkgs); err != nil {
+		return nil, err
+	} else if whole {
+		for _, a := range enabled {
+			if _, ok := toSrc[a]; ok && a.RunProgram != nil {
+				programAnalyzers = append(programAnalyzers, a)
+			}
+		}
+	}
+	if len(programAnalyzers) > 0 {
+		for _, an := range nodes {
+			atomic.AddInt32(&an.unfinishedPreds, 1)
+		}
+	}
+
 	// Now that we have read all files,
 	// we no longer need the snapshot.
 	// (but options are needed for progress reporting)
@@ -469,9 +495,176 @@ func (snapshot *Snapshot) Analyze(ctx context.Context, pkgs map[PackageID]unit,
 			}
 		}
 	}
+
+	// Apply the whole-program phase of each analyzer that has one.
+	// As with the actions, failures are logged but otherwise ignored.
+	for _, a := range programAnalyzers {
+		diagnostics, err := runProgram(fset, nodes, roots, a, stableNames[a])
+		if err != nil {
+			event.Error(ctx, "whole-program analysis failed", err, tag.Package.Of(tagStr))
+			continue
+		}
+		for _, gobDiag := range diagnostics {
+			results = append(results, toSourceDiagnostic(toSrc[a], &gobDiag))
+		}
+	}
 	return results, nil
 }
 
+// runProgram applies the RunProgram function of analyzer a to the
+// packages of the batch on which its action succeeded, and returns its
+// diagnostics.
+//
+// Because action summaries may come from the cache, each package
+// provides only its types, imported from export data if necessary, and
+// its facts: its syntax, detailed type information, and Run result are
+// not retained.
+func runProgram(fset *token.FileSet, nodes map[PackageID]*analysisNode, roots []*analysisNode, a *analysis.Analyzer, stableName string) (_ []gobDiagnostic, err error) {
+	isRoot := make(map[*analysisNode]bool)
+	for _, root := range roots {
+		isRoot[root] = true
+	}
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids) // for determinism
+
+	// Gather the packages, and the facts of the first package with
+	// each path. (Variants share facts about their common objects.)
+	var (
+		pkgs    []*analysis.ProgramPackage
+		imports []*types.Package
+		byPath  = make(map[string]*types.Package)
+		data    = make(map[string][]byte)
+		files   = make(map[string]file.Handle) // by file name
+	)
+	for _, id := range ids {
+		an := nodes[PackageID(id)]
+		for _, fh := range an.files {
+			files[fh.URI().Path()] = fh
+		}
+		summary := an.summary.Actions[stableName]
+		if summary == nil || summary.Err != "" {
+			continue // a dependency not analyzed by a, or a failed action
+		}
+		pkg, err := an._import()
+		if err != nil {
+			continue
+		}
+		pkgs = append(pkgs, &analysis.ProgramPackage{Pkg: pkg, Root: isRoot[an]})
+		if _, ok := byPath[pkg.Path()]; !ok {
+			byPath[pkg.Path()] = pkg
+			imports = append(imports, pkg)
+			data[pkg.Path()] = summary.Facts
+		}
+	}
+
+	// Decode the facts of all the packages, as if they were
+	// the imports of a single package.
+	program := types.NewPackage("", "program")
+	program.SetImports(imports)
+	factset, err := facts.NewDecoderFunc(program, func(path string) *types.Package {
+		return byPath[path]
+	}).Decode(func(path string) ([]byte, error) {
+		return data[path], nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("internal error decoding analysis facts: %w", err)
+	}
+	factFilter := make(map[reflect.Type]bool)
+	for _, f := range a.FactTypes {
+		factFilter[reflect.TypeOf(f)] = true
+	}
+
+	// posToLocation converts from token.Pos to protocol form.
+	// Positions may belong to packages imported from export data,
+	// which records only lines and columns, so use those.
+	mappers := make(map[string]*protocol.Mapper)
+	posToLocation := func(start, end token.Pos) (protocol.Location, error) {
+		if !end.IsValid() {
+			end = start
+		}
+		startPosn := safetoken.StartPosition(fset, start)
+		m, ok := mappers[startPosn.Filename]
+		if !ok {
+			fh, ok := files[startPosn.Filename]
+			if !ok {
+				return protocol.Location{}, fmt.Errorf("position %v not within program", startPosn)
+			}
+			content, err := fh.Content()
+			if err != nil {
+				return protocol.Location{}, err
+			}
+			m = protocol.NewMapper(fh.URI(), content)
+			mappers[startPosn.Filename] = m
+		}
+		startPos, err := m.LineCol8Position(startPosn.Line, startPosn.Column)
+		if err != nil {
+			return protocol.Location{}, err
+		}
+		endPos := startPos
+		if endPosn := safetoken.EndPosition(fset, end); endPosn.Filename == startPosn.Filename {
+			if endPos, err = m.LineCol8Position(endPosn.Line, endPosn.Column); err != nil {
+				return protocol.Location{}, err
+			}
+		}
+		return m.RangeLocation(protocol.Range{Start: startPos, End: endPos}), nil
+	}
+
+	var diagnostics []gobDiagnostic
+	pass := &analysis.ProgramPass{
+		Analyzer: a,
+		Fset:     fset,
+		Packages: pkgs,
+		Report: func(d analysis.Diagnostic) {
+			diagnostic, err := toGobDiagnostic(posToLocation, a, d)
+			if err != nil {
+				bug.Reportf("internal error converting diagnostic from analyzer %q: %v", a.Name, err)
+				return
+			}
+			diagnostics = append(diagnostics, diagnostic)
+		},
+		AllObjectFacts:  func() []analysis.ObjectFact { return factset.AllObjectFacts(factFilter) },
+		AllPackageFacts: func() []analysis.PackageFact { return factset.AllPackageFacts(factFilter) },
+	}
+
+	// Recover from panics within the analyzer logic, as in action.exec.
+	defer func() {
+		if r := recover(); r != nil {
+			if bug.PanicOnBugs {
+				panic(r)
+			}
+			err = fmt.Errorf("whole-program analysis %s panicked: %v", a.Name, r)
+		}
+	}()
+	if err := a.RunProgram(pass); err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
+}
+
+// isWholeProgram reports whether the batch of root packages pkgs
+// includes every workspace package that has a file not ignored by the
+// snapshot, as when gopls analyzes everything.
+func (s *Snapshot) isWholeProgram(ctx context.Context, pkgs map[PackageID]unit) (bool, error) {
+	workspace, err := s.WorkspaceMetadata(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range workspace {
+		if _, ok := pkgs[m.ID]; ok {
+			continue
+		}
+		for _, uri := range m.CompiledGoFiles {
+			if !s.IgnoredFile(uri) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
 func (an *analysisNode) decrefPreds() {
 	if atomic.AddInt32(&an.unfinishedPreds, -1) == 0 {
 		an.summary.Actions = nil
@@ -1507,20 +1700,47 @@ func toGobDiagnostic(posToLocation func(start, end token.Pos) (protocol.Location
 		code = diag.Category
 	}
 
+	var tags []protocol.DiagnosticTag
+	for _, tag := range diag.Tags {
+		switch tag {
+		case analysis.TagUnnecessary:
+			tags = append(tags, protocol.Unnecessary)
+		case analysis.TagDeprecated:
+			tags = append(tags, protocol.Deprecated)
+		}
+	}
+
 	return gobDiagnostic{
 		Location: loc,
-		// Severity for analysis diagnostics is dynamic,
-		// based on user configuration per analyzer.
+		// The user's configuration of the analyzer, if any,
+		// takes precedence over this severity (see toSourceDiagnostic).
+		Severity:       toProtocolSeverity(diag.Severity),
 		Code:           code,
 		CodeHref:       diagURL,
 		Source:         a.Name,
 		Message:        diag.Message,
 		SuggestedFixes: fixes,
 		Related:        related,
-		// Analysis diagnostics do not contain tags.
+		Tags:           tags,
 	}, nil
 }
 
+// toProtocolSeverity converts an analysis.Severity to the protocol's
+// severity, or zero if unspecified.
+func toProtocolSeverity(severity analysis.Severity) protocol.DiagnosticSeverity {
+	switch severity {
+	case analysis.SeverityError:
+		return protocol.SeverityError
+	case analysis.SeverityWarning:
+		return protocol.SeverityWarning
+	case analysis.SeverityInfo:
+		return protocol.SeverityInformation
+	case analysis.SeverityHint:
+		return protocol.SeverityHint
+	}
+	return 0
+}
+
 // effectiveURL computes the effective URL of diag,
 // using the algorithm specified at Diagnostic.URL.
 func effectiveURL(a *analysis.Analyzer, diag analysis.Diagnostic) string {
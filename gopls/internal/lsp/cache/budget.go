@@ -0,0 +1,240 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/internal/event"
+)
+
+// This file implements the memoryBudget setting.
+//
+// A snapshot retains type-checked packages in two places only: the
+// active packages, which have an open file (closing a file resets them),
+// and the shared import graph, which holds the packages imported by open
+// packages. The packages of the import graph are thus the type-checked
+// packages of non-open packages held in memory; every other package
+// exists only as shallow export data in the filecache. Evicting the
+// import graph therefore reverts the non-open packages to export data,
+// from which each type-checking batch imports them afresh while the heap
+// remains over budget. Active packages are never evicted.
+//
+// Two other kinds of state can always be recomputed: the parsed files of
+// packages that are not open, from file contents, and the symbols of
+// dependency modules, from the module cache. The memoryMonitor
+// periodically samples the size of the heap, and when it exceeds the
+// budget releases the import graph of each view's current snapshot,
+// evicts such files from the session's parse cache, and drops the
+// session's dependency symbols.
+
+// memoryCheckInterval is the period at which the heap size is sampled.
+const memoryCheckInterval = 5 * time.Second
+
+// heapObjectsMetric is the runtime/metrics sample used to measure the heap.
+// It includes objects that are not yet swept, and so overestimates the live
+// heap slightly, which is appropriate for a soft limit.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// MemoryStats reports the activity of a session's memory budget.
+type MemoryStats struct {
+	Budget        uint64    // smallest budget of any view in bytes, or 0 if unlimited
+	HeapSize      uint64    // heap size at the last sample, in bytes
+	OverBudget    bool      // whether the last sample exceeded the budget
+	Evictions     int       // number of samples that triggered eviction
+	EvictedFiles  int       // total number of parsed files evicted
+	EvictedGraphs int       // total number of snapshot import graphs released
+	EvictedDeps   int       // total number of dependency modules whose symbols were dropped
+	LastEviction  time.Time // time of the most recent eviction, or zero
+}
+
+// A memoryMonitor enforces the memory budget of a session.
+//
+// While the heap is over budget, snapshots do not retain a shared import
+// graph, so that each type-checking batch imports its dependencies afresh
+// from export data and releases them when done.
+type memoryMonitor struct {
+	interval time.Duration                    // period of sampling
+	budget   func() uint64                    // the budget in bytes, or 0 if unlimited
+	sample   func() uint64                    // the current heap size in bytes
+	evict    func() (files, graphs, deps int) // releases recomputable state
+
+	done       chan struct{} // closed when monitoring is stopped
+	overBudget int32         // atomic; 1 while the last sample exceeded the budget
+
+	mu    sync.Mutex
+	stats MemoryStats
+}
+
+// newMemoryMonitor creates a memory monitor for the session and starts a
+// goroutine to sample the heap.
+//
+// Callers must call memoryMonitor.stop when the session is shut down.
+func newMemoryMonitor(ctx context.Context, s *Session) *memoryMonitor {
+	m := &memoryMonitor{
+		interval: memoryCheckInterval,
+		budget:   s.memoryBudget,
+		sample:   sampleHeapSize,
+		evict:    s.evict,
+		done:     make(chan struct{}),
+	}
+	go m.run(ctx)
+	return m
+}
+
+// stop causes the monitoring goroutine to exit.
+func (m *memoryMonitor) stop() {
+	close(m.done)
+}
+
+// isOverBudget reports whether the most recent sample of the heap exceeded
+// the memory budget. It is safe to call on a nil monitor.
+func (m *memoryMonitor) isOverBudget() bool {
+	return m != nil && atomic.LoadInt32(&m.overBudget) != 0
+}
+
+func (m *memoryMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		m.checkOnce(ctx)
+	}
+}
+
+// checkOnce samples the heap and, if it exceeds the budget, evicts
+// recomputable state.
+func (m *memoryMonitor) checkOnce(ctx context.Context) {
+	budget := m.budget()
+	if budget == 0 {
+		atomic.StoreInt32(&m.overBudget, 0)
+		m.mu.Lock()
+		m.stats.Budget = 0
+		m.stats.OverBudget = false
+		m.mu.Unlock()
+		return
+	}
+
+	heapSize := m.sample()
+	over := heapSize > budget
+	if over {
+		atomic.StoreInt32(&m.overBudget, 1)
+	} else {
+		atomic.StoreInt32(&m.overBudget, 0)
+	}
+
+	var files, graphs, deps int
+	if over {
+		files, graphs, deps = m.evict()
+		event.Log(ctx, fmt.Sprintf("heap size %d exceeds memory budget %d: evicted %d parsed files, %d import graphs and the symbols of %d modules",
+			heapSize, budget, files, graphs, deps))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Budget = budget
+	m.stats.HeapSize = heapSize
+	m.stats.OverBudget = over
+	if over {
+		m.stats.Evictions++
+		m.stats.EvictedFiles += files
+		m.stats.EvictedGraphs += graphs
+		m.stats.EvictedDeps += deps
+		m.stats.LastEviction = time.Now()
+	}
+}
+
+// sampleHeapSize returns the current number of bytes occupied by heap
+// objects.
+func sampleHeapSize() uint64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0 // metric unsupported by this runtime
+	}
+	return sample[0].Value.Uint64()
+}
+
+// MemoryStats returns information about the enforcement of the session's
+// memory budget. It is intended for debugging only.
+func (s *Session) MemoryStats() MemoryStats {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+	return s.memory.stats
+}
+
+// memoryBudget returns the smallest non-zero memory budget configured by any
+// of the session's views, or 0 if there is none.
+func (s *Session) memoryBudget() uint64 {
+	var budget uint64
+	for _, v := range s.Views() {
+		if b := v.folder.Options.MemoryBudgetBytes(); b > 0 && (budget == 0 || b < budget) {
+			budget = b
+		}
+	}
+	return budget
+}
+
+// evict releases recomputable state held by the session: parsed files that
+// do not belong to open packages, the shared import graph of each view's
+// current snapshot, and the symbols of dependency modules. It returns the
+// number of files, import graphs and modules evicted.
+func (s *Session) evict() (files, graphs, deps int) {
+	// Files in the directory of an open file likely belong to an open
+	// package, and are needed to type-check it.
+	openDirs := make(map[string]bool)
+	for _, o := range s.Overlays() {
+		openDirs[filepath.Dir(o.URI().Path())] = true
+	}
+	files = s.parseCache.evictUnless(func(uri protocol.DocumentURI) bool {
+		return openDirs[filepath.Dir(uri.Path())]
+	})
+	for _, v := range s.Views() {
+		snapshot, release, err := v.Snapshot()
+		if err != nil {
+			continue // view is shut down
+		}
+		if snapshot.evictImportGraph() {
+			graphs++
+		}
+		release()
+	}
+	deps = s.depSymbols.evict()
+	return files, graphs, deps
+}
+
+// evictImportGraph releases the snapshot's shared import graph, if it has
+// been computed, reporting whether there was one to release. An import graph
+// that is still being computed is left alone.
+func (s *Snapshot) evictImportGraph() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.importGraphDone != nil {
+		select {
+		case <-s.importGraphDone:
+		default:
+			return false // still being computed
+		}
+	}
+	if s.importGraph == nil {
+		return false
+	}
+	s.importGraph = nil
+	return true
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryMonitor(t *testing.T) {
+	var heapSize, evictions uint64
+	m := &memoryMonitor{
+		interval: time.Millisecond,
+		budget:   func() uint64 { return 100 },
+		sample:   func() uint64 { return atomic.LoadUint64(&heapSize) },
+		evict: func() (files, graphs, deps int) {
+			atomic.AddUint64(&evictions, 1)
+			return 3, 2, 1
+		},
+		done: make(chan struct{}),
+	}
+	stats := func() MemoryStats {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.stats
+	}
+	// await waits for the monitor to take a sample that satisfies cond.
+	await := func(what string, cond func(MemoryStats) bool) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); !cond(stats()); {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s; stats: %+v", what, stats())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		m.run(context.Background())
+		close(stopped)
+	}()
+
+	atomic.StoreUint64(&heapSize, 50)
+	await("a sample under budget", func(s MemoryStats) bool { return s.HeapSize == 50 })
+	if m.isOverBudget() || atomic.LoadUint64(&evictions) != 0 {
+		t.Errorf("under budget: isOverBudget() = %t, %d evictions, want false, 0", m.isOverBudget(), evictions)
+	}
+
+	// Over budget, each sample evicts.
+	atomic.StoreUint64(&heapSize, 200)
+	await("two evictions", func(s MemoryStats) bool { return s.Evictions >= 2 })
+	if !m.isOverBudget() {
+		t.Errorf("over budget: isOverBudget() = false")
+	}
+	if s := stats(); !s.OverBudget || s.Budget != 100 || s.EvictedFiles != 3*s.Evictions ||
+		s.EvictedGraphs != 2*s.Evictions || s.EvictedDeps != s.Evictions || s.LastEviction.IsZero() {
+		t.Errorf("over budget: unexpected stats %+v", s)
+	}
+
+	// Back under budget, eviction stops.
+	atomic.StoreUint64(&heapSize, 50)
+	await("a sample under budget", func(s MemoryStats) bool { return !s.OverBudget })
+	if m.isOverBudget() {
+		t.Errorf("back under budget: isOverBudget() = true")
+	}
+
+	m.stop()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("monitor did not stop")
+	}
+	var nilMonitor *memoryMonitor
+	if nilMonitor.isOverBudget() {
+		t.Errorf("nil monitor is over budget")
+	}
+}
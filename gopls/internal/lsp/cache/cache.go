@@ -15,6 +15,7 @@ import (
 	"golang.org/x/tools/internal/gocommand"
 	"golang.org/x/tools/internal/memoize"
 	"golang.org/x/tools/internal/robustio"
+	"golang.org/x/tools/internal/xcontext"
 )
 
 // New Creates a new cache for gopls operation results, using the given file
@@ -63,7 +64,9 @@ func NewSession(ctx context.Context, c *Cache) *Session {
 		gocmdRunner: &gocommand.Runner{},
 		overlayFS:   newOverlayFS(c),
 		parseCache:  newParseCache(1 * time.Minute), // keep recently parsed files for a minute, to optimize typing CPU
+		depSymbols:  new(depSymbolCache),
 	}
+	s.memory = newMemoryMonitor(xcontext.Detach(ctx), s)
 	event.Log(ctx, "New session", KeyCreateSession.Of(s))
 	return s
 }
@@ -131,8 +131,10 @@ func (s *Snapshot) TypeCheck(ctx context.Context, ids ...PackageID) ([]Package_,
 // the shared import graph means we don't run the risk of pinning duplicate
 // copies of common imports, if active packages are computed in separate type
 // checking batches.
+//
+// While the session is over its memory budget, getImportGraph returns nil.
 func (s *Snapshot) getImportGraph(ctx context.Context) *importGraph {
-	if !preserveImportGraph {
+	if !preserveImportGraph || s.view.memory.isOverBudget() {
 		return nil
 	}
 	s.mu.Lock()
@@ -337,11 +337,23 @@ func toSourceDiagnostic(srcAnalyzer *settings.Analyzer, gobDiag *gobDiagnostic)
 		kinds = append(kinds, protocol.QuickFix)
 	}
 
+	// The severity configured for the analyzer takes precedence
+	// over that of the diagnostic.
 	severity := srcAnalyzer.Severity
+	if severity == 0 {
+		severity = gobDiag.Severity
+	}
 	if severity == 0 {
 		severity = protocol.SeverityWarning
 	}
 
+	tags := append([]protocol.DiagnosticTag(nil), srcAnalyzer.Tag...)
+	for _, tag := range gobDiag.Tags {
+		if !containsTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
 	diag := &source.Diagnostic{
 		URI:      gobDiag.Location.URI,
 		Range:    gobDiag.Location.Range,
@@ -351,7 +363,7 @@ func toSourceDiagnostic(srcAnalyzer *settings.Analyzer, gobDiag *gobDiagnostic)
 		Source:   source.AnalyzerErrorKind(gobDiag.Source),
 		Message:  gobDiag.Message,
 		Related:  related,
-		Tags:     srcAnalyzer.Tag,
+		Tags:     tags,
 	}
 	if source.CanFix(srcAnalyzer, diag) {
 		fixes := suggestedAnalysisFixes(gobDiag, kinds)
@@ -373,12 +385,21 @@ func toSourceDiagnostic(srcAnalyzer *settings.Analyzer, gobDiag *gobDiagnostic)
 	}
 
 	// If the fixes only delete code, assume that the diagnostic is reporting dead code.
-	if onlyDeletions(diag.SuggestedFixes) {
+	if onlyDeletions(diag.SuggestedFixes) && !containsTag(diag.Tags, protocol.Unnecessary) {
 		diag.Tags = append(diag.Tags, protocol.Unnecessary)
 	}
 	return diag
 }
 
+func containsTag(tags []protocol.DiagnosticTag, tag protocol.DiagnosticTag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // onlyDeletions returns true if fixes is non-empty and all of the suggested
 // fixes are deletions.
 func onlyDeletions(fixes []source.SuggestedFix) bool {
@@ -8,7 +8,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -19,8 +18,6 @@ import (
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/event/tag"
-	"golang.org/x/tools/internal/gocommand"
-	"golang.org/x/tools/internal/memoize"
 )
 
 // ParseMod parses a go.mod file, using a cache. It may return partial results and an error.
@@ -206,84 +203,6 @@ func sumFilename(modURI protocol.DocumentURI) string {
 	return strings.TrimSuffix(modURI.Path(), ".mod") + ".sum"
 }
 
-// ModWhy returns the "go mod why" result for each module named in a
-// require statement in the go.mod file.
-// TODO(adonovan): move to new mod_why.go file.
-func (s *Snapshot) ModWhy(ctx context.Context, fh file.Handle) (map[string]string, error) {
-	uri := fh.URI()
-
-	if s.FileKind(fh) != file.Mod {
-		return nil, fmt.Errorf("%s is not a go.mod file", uri)
-	}
-
-	s.mu.Lock()
-	entry, hit := s.modWhyHandles.Get(uri)
-	s.mu.Unlock()
-
-	type modWhyResult struct {
-		why map[string]string
-		err error
-	}
-
-	// cache miss?
-	if !hit {
-		handle := memoize.NewPromise("modWhy", func(ctx context.Context, arg interface{}) interface{} {
-			why, err := modWhyImpl(ctx, arg.(*Snapshot), fh)
-			return modWhyResult{why, err}
-		})
-
-		entry = handle
-		s.mu.Lock()
-		s.modWhyHandles.Set(uri, entry, nil)
-		s.mu.Unlock()
-	}
-
-	// Await result.
-	v, err := s.awaitPromise(ctx, entry)
-	if err != nil {
-		return nil, err
-	}
-	res := v.(modWhyResult)
-	return res.why, res.err
-}
-
-// modWhyImpl returns the result of "go mod why -m" on the specified go.mod file.
-func modWhyImpl(ctx context.Context, snapshot *Snapshot, fh file.Handle) (map[string]string, error) {
-	ctx, done := event.Start(ctx, "cache.ModWhy", tag.URI.Of(fh.URI()))
-	defer done()
-
-	pm, err := snapshot.ParseMod(ctx, fh)
-	if err != nil {
-		return nil, err
-	}
-	// No requires to explain.
-	if len(pm.File.Require) == 0 {
-		return nil, nil // empty result
-	}
-	// Run `go mod why` on all the dependencies.
-	inv := &gocommand.Invocation{
-		Verb:       "mod",
-		Args:       []string{"why", "-m"},
-		WorkingDir: filepath.Dir(fh.URI().Path()),
-	}
-	for _, req := range pm.File.Require {
-		inv.Args = append(inv.Args, req.Mod.Path)
-	}
-	stdout, err := snapshot.RunGoCommandDirect(ctx, Normal, inv)
-	if err != nil {
-		return nil, err
-	}
-	whyList := strings.Split(stdout.String(), "\n\n")
-	if len(whyList) != len(pm.File.Require) {
-		return nil, fmt.Errorf("mismatched number of results: got %v, want %v", len(whyList), len(pm.File.Require))
-	}
-	why := make(map[string]string, len(pm.File.Require))
-	for i, req := range pm.File.Require {
-		why[req.Mod.Path] = whyList[i]
-	}
-	return why, nil
-}
-
 // extractGoCommandErrors tries to parse errors that come from the go command
 // and shape them into go.mod diagnostics.
 // TODO: rename this to 'load errors'
@@ -370,7 +370,7 @@ func directnessDiagnostic(m *protocol.Mapper, req *modfile.Require, computeEdits
 		}
 	}
 	// If the dependency should be indirect, add the // indirect.
-	edits, err := switchDirectness(req, m, computeEdits)
+	edits, err := SwitchDirectness(req, m, computeEdits)
 	if err != nil {
 		return nil, err
 	}
@@ -420,9 +420,9 @@ func missingModuleDiagnostic(pm *ParsedModule, req *modfile.Require) (*Diagnosti
 	}, nil
 }
 
-// switchDirectness gets the edits needed to change an indirect dependency to
+// SwitchDirectness gets the edits needed to change an indirect dependency to
 // direct and vice versa.
-func switchDirectness(req *modfile.Require, m *protocol.Mapper, computeEdits settings.DiffFunction) ([]protocol.TextEdit, error) {
+func SwitchDirectness(req *modfile.Require, m *protocol.Mapper, computeEdits settings.DiffFunction) ([]protocol.TextEdit, error) {
 	// We need a private copy of the parsed go.mod file, since we're going to
 	// modify it.
 	copied, err := modfile.Parse("", m.Content, nil)
@@ -0,0 +1,175 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/event/tag"
+	"golang.org/x/tools/internal/gocommand"
+	"golang.org/x/tools/internal/memoize"
+)
+
+// ModWhy returns the "go mod why" result for each module named in a
+// require statement in the go.mod file.
+func (s *Snapshot) ModWhy(ctx context.Context, fh file.Handle) (map[string]string, error) {
+	uri := fh.URI()
+
+	if s.FileKind(fh) != file.Mod {
+		return nil, fmt.Errorf("%s is not a go.mod file", uri)
+	}
+
+	s.mu.Lock()
+	entry, hit := s.modWhyHandles.Get(uri)
+	s.mu.Unlock()
+
+	type modWhyResult struct {
+		why map[string]string
+		err error
+	}
+
+	// cache miss?
+	if !hit {
+		handle := memoize.NewPromise("modWhy", func(ctx context.Context, arg interface{}) interface{} {
+			why, err := modWhyImpl(ctx, arg.(*Snapshot), fh)
+			return modWhyResult{why, err}
+		})
+
+		entry = handle
+		s.mu.Lock()
+		s.modWhyHandles.Set(uri, entry, nil)
+		s.mu.Unlock()
+	}
+
+	// Await result.
+	v, err := s.awaitPromise(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	res := v.(modWhyResult)
+	return res.why, res.err
+}
+
+// modWhyImpl returns the result of "go mod why -m" on the specified go.mod file.
+func modWhyImpl(ctx context.Context, snapshot *Snapshot, fh file.Handle) (map[string]string, error) {
+	ctx, done := event.Start(ctx, "cache.ModWhy", tag.URI.Of(fh.URI()))
+	defer done()
+
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	// No requires to explain.
+	if len(pm.File.Require) == 0 {
+		return nil, nil // empty result
+	}
+	// Run `go mod why` on all the dependencies.
+	inv := &gocommand.Invocation{
+		Verb:       "mod",
+		Args:       []string{"why", "-m"},
+		WorkingDir: filepath.Dir(fh.URI().Path()),
+	}
+	for _, req := range pm.File.Require {
+		inv.Args = append(inv.Args, req.Mod.Path)
+	}
+	stdout, err := snapshot.RunGoCommandDirect(ctx, Normal, inv)
+	if err != nil {
+		return nil, err
+	}
+	whyList := strings.Split(stdout.String(), "\n\n")
+	if len(whyList) != len(pm.File.Require) {
+		return nil, fmt.Errorf("mismatched number of results: got %v, want %v", len(whyList), len(pm.File.Require))
+	}
+	why := make(map[string]string, len(pm.File.Require))
+	for i, req := range pm.File.Require {
+		why[req.Mod.Path] = whyList[i]
+	}
+	return why, nil
+}
+
+// ModGraph returns the inverse of the "go mod graph" result for the
+// go.mod file: for each module path in the module graph, the modules
+// that require it, as "path@version" strings (or just "path" for the
+// main module), sorted and without duplicates.
+func (s *Snapshot) ModGraph(ctx context.Context, fh file.Handle) (map[string][]string, error) {
+	uri := fh.URI()
+
+	if s.FileKind(fh) != file.Mod {
+		return nil, fmt.Errorf("%s is not a go.mod file", uri)
+	}
+
+	s.mu.Lock()
+	entry, hit := s.modGraphHandles.Get(uri)
+	s.mu.Unlock()
+
+	type modGraphResult struct {
+		requiredBy map[string][]string
+		err        error
+	}
+
+	// cache miss?
+	if !hit {
+		handle := memoize.NewPromise("modGraph", func(ctx context.Context, arg interface{}) interface{} {
+			requiredBy, err := modGraphImpl(ctx, arg.(*Snapshot), fh)
+			return modGraphResult{requiredBy, err}
+		})
+
+		entry = handle
+		s.mu.Lock()
+		s.modGraphHandles.Set(uri, entry, nil)
+		s.mu.Unlock()
+	}
+
+	// Await result.
+	v, err := s.awaitPromise(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	res := v.(modGraphResult)
+	return res.requiredBy, res.err
+}
+
+// modGraphImpl runs "go mod graph" for the specified go.mod file and
+// inverts its edges.
+func modGraphImpl(ctx context.Context, snapshot *Snapshot, fh file.Handle) (map[string][]string, error) {
+	ctx, done := event.Start(ctx, "cache.ModGraph", tag.URI.Of(fh.URI()))
+	defer done()
+
+	inv := &gocommand.Invocation{
+		Verb:       "mod",
+		Args:       []string{"graph"},
+		WorkingDir: filepath.Dir(fh.URI().Path()),
+	}
+	stdout, err := snapshot.RunGoCommandDirect(ctx, Normal, inv)
+	if err != nil {
+		return nil, err
+	}
+	// Each line is of the form "requirer required@version", where the
+	// requirer is either the main module path or a path@version.
+	seen := make(map[[2]string]bool)
+	requiredBy := make(map[string][]string)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		from := fields[0]
+		to, _, _ := strings.Cut(fields[1], "@")
+		if edge := [2]string{from, to}; !seen[edge] {
+			seen[edge] = true
+			requiredBy[to] = append(requiredBy[to], from)
+		}
+	}
+	for _, from := range requiredBy {
+		sort.Strings(from)
+	}
+	return requiredBy, nil
+}
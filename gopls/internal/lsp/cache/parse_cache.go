@@ -284,6 +284,32 @@ func (c *parseCache) gcOnce() {
 	}
 }
 
+// evictUnless removes all entries from the cache whose URI does not satisfy
+// keep, regardless of their age, and returns the number of entries removed.
+func (c *parseCache) evictUnless(keep func(protocol.DocumentURI) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.lru[:0]
+	for _, e := range c.lru {
+		if keep(e.key.uri) {
+			kept = append(kept, e)
+		} else {
+			delete(c.m, e.key)
+		}
+	}
+	evicted := len(c.lru) - len(kept)
+	for i := len(kept); i < len(c.lru); i++ {
+		c.lru[i] = nil // aid GC
+	}
+	c.lru = kept
+	for i, e := range c.lru {
+		e.lruIndex = i
+	}
+	heap.Init(&c.lru)
+	return evicted
+}
+
 // allocateSpace reserves the next n bytes of token.Pos space in the
 // cache.
 //
@@ -175,6 +175,41 @@ func TestParseCache_TimeEviction(t *testing.T) {
 	}
 }
 
+func TestParseCache_EvictUnless(t *testing.T) {
+	skipIfNoParseCache(t)
+
+	ctx := context.Background()
+	fset := token.NewFileSet()
+	uri := protocol.DocumentURI("file:///myfile")
+	fh := makeFakeFileHandle(uri, []byte("package p\n\nconst _ = \"foo\""))
+
+	cache := newParseCache(time.Hour)
+	cache.stop() // we'll manage eviction manually, for testing.
+
+	files := append(dummyFileHandles(parseCacheMinFiles), fh)
+	pgfs0, err := cache.parseFiles(ctx, fset, ParseFull, false, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Eviction ignores the minimum number of files, and the age of entries.
+	keep := func(u protocol.DocumentURI) bool { return u == uri }
+	if got, want := cache.evictUnless(keep), parseCacheMinFiles; got != want {
+		t.Errorf("evictUnless evicted %d files, want %d", got, want)
+	}
+
+	pgfs1, err := cache.parseFiles(ctx, fset, ParseFull, false, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pgfs0[0] == pgfs1[0] {
+		t.Errorf("after eviction, got unexpected cache hit for %s", pgfs0[0].URI)
+	}
+	if last := len(files) - 1; pgfs0[last] != pgfs1[last] {
+		t.Errorf("after eviction, got unexpected cache miss for %s", uri)
+	}
+}
+
 func TestParseCache_Duplicates(t *testing.T) {
 	skipIfNoParseCache(t)
 
@@ -41,6 +41,8 @@ type Session struct {
 	viewMap map[protocol.DocumentURI]*View // file->best view
 
 	parseCache *parseCache
+	depSymbols *depSymbolCache // symbols of dependency modules
+	memory     *memoryMonitor  // enforces the memoryBudget setting
 
 	*overlayFS
 }
@@ -66,6 +68,7 @@ func (s *Session) Shutdown(ctx context.Context) {
 		view.shutdown()
 	}
 	s.parseCache.stop()
+	s.memory.stop()
 	event.Log(ctx, "Shutdown session", KeyShutdownSession.Of(s))
 }
 
@@ -130,6 +133,8 @@ func (s *Session) createView(ctx context.Context, def *viewDefinition, folder *F
 		initializationSema:   make(chan struct{}, 1),
 		baseCtx:              baseCtx,
 		parseCache:           s.parseCache,
+		depSymbols:           s.depSymbols,
+		memory:               s.memory,
 		fs:                   s.overlayFS,
 		viewDefinition:       def,
 	}
@@ -169,9 +174,11 @@ func (s *Session) createView(ctx context.Context, def *viewDefinition, folder *F
 		modTidyHandles:   new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
 		modVulnHandles:   new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
 		modWhyHandles:    new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		modGraphHandles:  new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
 		pkgIndex:         typerefs.NewPackageIndex(),
 		moduleUpgrades:   new(persistent.Map[protocol.DocumentURI, map[string]string]),
 		vulns:            new(persistent.Map[protocol.DocumentURI, *vulncheck.Result]),
+		foundModules:     new(persistent.Map[protocol.DocumentURI, []protocol.DocumentURI]),
 	}
 	// Save one reference in the view.
 	v.releaseSnapshot = v.snapshot.Acquire()
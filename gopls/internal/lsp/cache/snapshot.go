@@ -151,9 +151,10 @@ type Snapshot struct {
 	// Preserve go.mod-related handles to avoid garbage-collecting the results
 	// of various calls to the go command. The handles need not refer to only
 	// the view's go.mod file.
-	modTidyHandles *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modTidyResult]
-	modWhyHandles  *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modWhyResult]
-	modVulnHandles *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modVulnResult]
+	modTidyHandles  *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modTidyResult]
+	modWhyHandles   *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modWhyResult]
+	modGraphHandles *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modGraphResult]
+	modVulnHandles  *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modVulnResult]
 
 	// importGraph holds a shared import graph to use for type-checking. Adding
 	// more packages to this import graph can speed up type checking, at the
@@ -177,6 +178,10 @@ type Snapshot struct {
 
 	// vulns maps each go.mod file's URI to its known vulnerabilities.
 	vulns *persistent.Map[protocol.DocumentURI, *vulncheck.Result]
+
+	// foundModules memoizes the result of FindModules for each root
+	// directory. It is discarded when a go.mod file or a directory changes.
+	foundModules *persistent.Map[protocol.DocumentURI, []protocol.DocumentURI]
 }
 
 var globalSnapshotID uint64
@@ -250,9 +255,11 @@ func (s *Snapshot) destroy(destroyedBy string) {
 	s.modTidyHandles.Destroy()
 	s.modVulnHandles.Destroy()
 	s.modWhyHandles.Destroy()
+	s.modGraphHandles.Destroy()
 	s.unloadableFiles.Destroy()
 	s.moduleUpgrades.Destroy()
 	s.vulns.Destroy()
+	s.foundModules.Destroy()
 }
 
 // SequenceID is the sequence id of this snapshot within its containing
@@ -454,12 +461,25 @@ func (s *Snapshot) RunGoCommandPiped(ctx context.Context, mode InvocationFlags,
 // TODO(rfindley): the signature of RunGoModUpdateCommands is very confusing.
 // Simplify it.
 func (s *Snapshot) RunGoModUpdateCommands(ctx context.Context, wd string, run func(invoke func(...string) (*bytes.Buffer, error)) error) ([]byte, []byte, error) {
+	return s.runGoModUpdateCommands(ctx, wd, false, run)
+}
+
+// RunOfflineGoModUpdateCommands is like RunGoModUpdateCommands, but the go
+// commands may use only the modules in the module cache (GOPROXY=off).
+func (s *Snapshot) RunOfflineGoModUpdateCommands(ctx context.Context, wd string, run func(invoke func(...string) (*bytes.Buffer, error)) error) ([]byte, []byte, error) {
+	return s.runGoModUpdateCommands(ctx, wd, true, run)
+}
+
+func (s *Snapshot) runGoModUpdateCommands(ctx context.Context, wd string, offline bool, run func(invoke func(...string) (*bytes.Buffer, error)) error) ([]byte, []byte, error) {
 	flags := WriteTemporaryModFile | AllowNetwork
 	tmpURI, inv, cleanup, err := s.goCommandInvocation(ctx, flags, &gocommand.Invocation{WorkingDir: wd})
 	if err != nil {
 		return nil, nil, err
 	}
 	defer cleanup()
+	if offline {
+		inv.Env = append(inv.Env, "GOPROXY=off")
+	}
 	invoke := func(args ...string) (*bytes.Buffer, error) {
 		inv.Verb = args[0]
 		inv.Args = args[1:]
@@ -697,6 +717,10 @@ func (index XrefIndex) Lookup(targets map[PackagePath]map[objectpath.Path]struct
 	return xrefs.Lookup(index.m, index.data, targets)
 }
 
+func (index XrefIndex) Referenced(targets map[PackagePath]map[objectpath.Path]struct{}) map[PackagePath]map[objectpath.Path]struct{} {
+	return xrefs.Referenced(index.data, targets)
+}
+
 func (s *Snapshot) MethodSets(ctx context.Context, ids ...PackageID) ([]*methodsets.Index, error) {
 	ctx, done := event.Start(ctx, "cache.snapshot.MethodSets")
 	defer done()
@@ -1852,11 +1876,13 @@ func (s *Snapshot) clone(ctx, bgCtx context.Context, changed StateChange) (*Snap
 		parseWorkHandles:  cloneWithout(s.parseWorkHandles, changedFiles),
 		modTidyHandles:    cloneWithout(s.modTidyHandles, changedFiles),
 		modWhyHandles:     cloneWithout(s.modWhyHandles, changedFiles),
+		modGraphHandles:   cloneWithout(s.modGraphHandles, changedFiles),
 		modVulnHandles:    cloneWithout(s.modVulnHandles, changedFiles),
 		importGraph:       s.importGraph,
 		pkgIndex:          s.pkgIndex,
 		moduleUpgrades:    cloneWith(s.moduleUpgrades, changed.ModuleUpgrades),
 		vulns:             cloneWith(s.vulns, changed.Vulns),
+		foundModules:      s.foundModules.Clone(),
 	}
 
 	// Create a lease on the new snapshot.
@@ -1881,6 +1907,16 @@ func (s *Snapshot) clone(ctx, bgCtx context.Context, changed StateChange) (*Snap
 		}
 	}
 
+	// A go.mod file that is created or deleted, directly or with its
+	// directory (which has no extension), changes the modules found.
+	for uri := range changedFiles {
+		if isGoMod(uri) || filepath.Ext(uri.Path()) == "" {
+			result.foundModules.Destroy()
+			result.foundModules = new(persistent.Map[protocol.DocumentURI, []protocol.DocumentURI])
+			break
+		}
+	}
+
 	// Collect observed file handles for changed URIs from the old snapshot, if
 	// they exist. Importantly, we don't call ReadFile here: consider the case
 	// where a file is added on disk; we don't want to read the newly added file
@@ -2025,6 +2061,7 @@ func (s *Snapshot) clone(ctx, bgCtx context.Context, changed StateChange) (*Snap
 			//
 			// TODO(rfindley): no tests fail if I delete the line below.
 			result.modWhyHandles.Clear()
+			result.modGraphHandles.Clear()
 			result.modVulnHandles.Clear()
 		}
 	}
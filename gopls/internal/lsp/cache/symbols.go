@@ -6,14 +6,27 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
 	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/tools/gopls/internal/astutil"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/source"
+	"golang.org/x/tools/gopls/internal/pathutil"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/memoize"
 )
 
 // symbolize returns the result of symbolizing the file identified by uri, using a cache.
@@ -73,6 +86,201 @@ func symbolizeImpl(ctx context.Context, snapshot *Snapshot, fh file.Handle) ([]S
 	return w.symbols, w.firstError
 }
 
+// DependencySymbols returns the symbols of all packages of the modules in
+// the module cache that provide loaded packages, including packages that
+// are not imported by the workspace and so are not loaded.
+//
+// The files of each package are symbolized without regard to build
+// constraints. Since the module cache is immutable, the symbols of each
+// module are memoized by the session, until it exceeds its memory budget.
+func (s *Snapshot) DependencySymbols(ctx context.Context) ([]*source.PackageSymbols, error) {
+	meta, err := s.AllMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading metadata: %v", err)
+	}
+	modcache := s.view.gomodcache
+	if modcache == "" {
+		return nil, nil
+	}
+	modules := make(map[string]string) // module directory -> module path
+	for _, m := range meta {
+		if mod := m.Module; mod != nil && mod.Dir != "" && pathutil.InDir(modcache, mod.Dir) {
+			modules[mod.Dir] = mod.Path
+		}
+	}
+
+	var (
+		group    errgroup.Group
+		resultMu sync.Mutex
+		result   []*source.PackageSymbols
+	)
+	group.SetLimit(runtime.GOMAXPROCS(-1))
+	for dir, path := range modules {
+		dir, path := dir, path
+		group.Go(func() error {
+			pkgs, err := s.view.depSymbols.get(ctx, dir, path)
+			if err != nil {
+				return err
+			}
+			resultMu.Lock()
+			result = append(result, pkgs...)
+			resultMu.Unlock()
+			return nil
+		})
+	}
+	// As with Symbols, partial results are better than none.
+	if err := group.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		event.Error(ctx, "getting dependency symbols", err)
+	}
+	return result, nil
+}
+
+// A depSymbolCache memoizes the symbols of each module directory in the
+// module cache, whose contents never change. It is shared by the views of
+// a session, and emptied when the session exceeds its memory budget.
+type depSymbolCache struct {
+	mu       sync.Mutex
+	promises map[string]*memoize.Promise // keyed by module directory
+}
+
+// get returns the symbols of the packages of the module with the given
+// path in directory dir of the module cache.
+func (c *depSymbolCache) get(ctx context.Context, dir, path string) ([]*source.PackageSymbols, error) {
+	c.mu.Lock()
+	promise, ok := c.promises[dir]
+	if !ok {
+		if c.promises == nil {
+			c.promises = make(map[string]*memoize.Promise)
+		}
+		promise = memoize.NewPromise("moduleSymbols", func(ctx context.Context, _ interface{}) interface{} {
+			return symbolizeModule(ctx, dir, path)
+		})
+		c.promises[dir] = promise
+	}
+	c.mu.Unlock()
+
+	v, err := promise.Get(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*source.PackageSymbols), nil
+}
+
+// size returns the number of modules whose symbols are held by the cache,
+// and the total number of those symbols.
+func (c *depSymbolCache) size() (modules, symbols int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, promise := range c.promises {
+		pkgs, ok := promise.Cached().([]*source.PackageSymbols)
+		if !ok {
+			continue
+		}
+		modules++
+		for _, pkg := range pkgs {
+			for _, syms := range pkg.Files {
+				symbols += len(syms)
+			}
+		}
+	}
+	return modules, symbols
+}
+
+// evict removes all computed entries from the cache, and returns the
+// number of entries removed. Entries still being computed are kept.
+func (c *depSymbolCache) evict() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evicted := 0
+	for dir, promise := range c.promises {
+		if promise.Cached() != nil {
+			delete(c.promises, dir)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// symbolizeModule walks the directory tree of a module, and extracts the
+// symbols of the non-test Go files of each of its packages. Files that
+// cannot be read or parsed are skipped, as are testdata and vendor
+// directories.
+func symbolizeModule(ctx context.Context, dir, path string) []*source.PackageSymbols {
+	pkgs := make(map[string]*source.PackageSymbols) // keyed by directory
+	filepath.WalkDir(dir, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable directories
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		base := d.Name()
+		if d.IsDir() {
+			if filename != dir && (base == "testdata" || base == "vendor" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(base, ".go") || strings.HasSuffix(base, "_test.go") || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+			return nil
+		}
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil
+		}
+		uri := protocol.URIFromPath(filename)
+		name, symbols, ok := symbolizeSource(uri, src)
+		if !ok {
+			return nil
+		}
+		pkgDir := filepath.Dir(filename)
+		pkg := pkgs[pkgDir]
+		if pkg == nil {
+			pkgPath := path
+			if rel, err := filepath.Rel(dir, pkgDir); err == nil && rel != "." {
+				pkgPath += "/" + filepath.ToSlash(rel)
+			}
+			pkg = &source.PackageSymbols{
+				PkgPath: source.PackagePath(pkgPath),
+				Name:    source.PackageName(name),
+				Files:   make(map[protocol.DocumentURI][]Symbol),
+			}
+			pkgs[pkgDir] = pkg
+		}
+		pkg.Files[uri] = symbols
+		return nil
+	})
+
+	result := make([]*source.PackageSymbols, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		result = append(result, pkg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PkgPath < result[j].PkgPath })
+	return result
+}
+
+// symbolizeSource parses the Go source of the file identified by uri, and
+// returns its package name and symbols. It reports false if the package
+// clause cannot be parsed.
+func symbolizeSource(uri protocol.DocumentURI, src []byte) (string, []Symbol, bool) {
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, uri.Path(), src, parser.SkipObjectResolution)
+	if f == nil || f.Name == nil || f.Name.Name == "_" {
+		return "", nil, false
+	}
+	w := &symbolWalker{
+		tokFile: fset.File(f.Package),
+		mapper:  protocol.NewMapper(uri, src),
+	}
+	w.fileDecls(f.Decls)
+	return f.Name.Name, w.symbols, true
+}
+
 type symbolWalker struct {
 	// for computing positions
 	tokFile *token.File
@@ -0,0 +1,41 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDepSymbolCache(t *testing.T) {
+	dir := t.TempDir()
+	src := "package a\n\nfunc F() {}\n\ntype T int\n"
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "a.go"), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var c depSymbolCache
+	pkgs, err := c.get(context.Background(), dir, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 || pkgs[0].PkgPath != "example.com/m/a" {
+		t.Fatalf("get returned %d packages, want example.com/m/a", len(pkgs))
+	}
+	if modules, symbols := c.size(); modules != 1 || symbols != 2 {
+		t.Errorf("size() = %d modules, %d symbols, want 1, 2", modules, symbols)
+	}
+	if n := c.evict(); n != 1 {
+		t.Errorf("evict() = %d, want 1", n)
+	}
+	if modules, symbols := c.size(); modules != 0 || symbols != 0 {
+		t.Errorf("after eviction, size() = %d modules, %d symbols, want 0, 0", modules, symbols)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+)
+
+// SessionUsage estimates the resources held on behalf of a session, as
+// opposed to state shared with other sessions through the cache.
+//
+// The byte counts are sizes of source text: the memory occupied by the
+// corresponding syntax trees and type information is typically several
+// times larger, but grows in proportion.
+type SessionUsage struct {
+	Views        int    // number of views
+	Packages     int    // number of packages known to the views
+	Files        int    // number of files read by the views
+	FileBytes    uint64 // size of the contents of those files
+	Overlays     int    // number of open files
+	OverlayBytes uint64 // size of the contents of open files
+	ParsedFiles  int    // number of files held by the session's parse cache
+	ParsedBytes  uint64 // size of the source of those files
+	DepModules   int    // number of dependency modules whose symbols are held
+	DepSymbols   int    // number of symbols of those modules
+}
+
+// Bytes returns the total size of the source text held by the session.
+func (u SessionUsage) Bytes() uint64 {
+	return u.FileBytes + u.ParsedBytes
+}
+
+// Usage returns an estimate of the resources held by the session.
+// It is intended for debugging and for the management of daemon sessions.
+func (s *Session) Usage() SessionUsage {
+	var u SessionUsage
+	for _, o := range s.Overlays() {
+		u.Overlays++
+		u.OverlayBytes += uint64(len(o.content))
+	}
+
+	// Views may share files: count each once.
+	seen := make(map[protocol.DocumentURI]bool)
+	for _, v := range s.Views() {
+		snapshot, release, err := v.Snapshot()
+		if err != nil {
+			continue // view is shut down
+		}
+		u.Views++
+		snapshot.mu.Lock()
+		u.Packages += len(snapshot.meta.metadata)
+		snapshot.files.Range(func(uri protocol.DocumentURI, fh file.Handle) {
+			if seen[uri] {
+				return
+			}
+			seen[uri] = true
+			u.Files++
+			if content, err := fh.Content(); err == nil {
+				u.FileBytes += uint64(len(content))
+			}
+		})
+		snapshot.mu.Unlock()
+		release()
+	}
+
+	u.ParsedFiles, u.ParsedBytes = s.parseCache.size()
+	u.DepModules, u.DepSymbols = s.depSymbols.size()
+	return u
+}
+
+// size returns the number of parsed files held by the cache, and the size
+// of their source.
+func (c *parseCache) size() (files int, bytes uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range c.m {
+		if pgf, ok := e.promise.Cached().(*ParsedGoFile); ok {
+			files++
+			bytes += uint64(len(pgf.Src))
+		}
+	}
+	return files, bytes
+}
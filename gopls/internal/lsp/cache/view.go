@@ -76,6 +76,12 @@ type View struct {
 	// parseCache holds an LRU cache of recently parsed files.
 	parseCache *parseCache
 
+	// depSymbols holds the symbols of dependency modules.
+	depSymbols *depSymbolCache
+
+	// memory enforces the session's memory budget.
+	memory *memoryMonitor
+
 	// fs is the file source used to populate this view.
 	fs *overlayFS
 
@@ -322,7 +328,7 @@ const (
 
 // goEnv holds important environment variables that gopls cares about.
 type goEnv struct {
-	gocache, gopath, goroot, goprivate, gomodcache, gowork, goflags string
+	gocache, gopath, goroot, goprivate, gomodcache, gowork, goflags, goarch string
 
 	// go111module holds the value of GO111MODULE as reported by go env.
 	//
@@ -384,6 +390,7 @@ func (env *goEnv) vars() map[string]*string {
 		"GO111MODULE": &env.go111module,
 		"GOWORK":      &env.gowork,
 		"GOFLAGS":     &env.goflags,
+		"GOARCH":      &env.goarch,
 	}
 }
 
@@ -1082,6 +1089,18 @@ func (s *Snapshot) IsGoPrivatePath(target string) bool {
 	return globsMatchPath(s.view.goprivate, target)
 }
 
+// GOARCH returns the target architecture of the view, as reported by
+// go env.
+func (s *Snapshot) GOARCH() string {
+	return s.view.goarch
+}
+
+// GOMODCACHE returns the module cache directory of the view, as reported
+// by go env.
+func (s *Snapshot) GOMODCACHE() string {
+	return s.view.gomodcache
+}
+
 // ModuleUpgrades returns known module upgrades for the dependencies of
 // modfile.
 func (s *Snapshot) ModuleUpgrades(modfile protocol.DocumentURI) map[string]string {
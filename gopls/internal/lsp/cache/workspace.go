@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"golang.org/x/mod/modfile"
@@ -85,6 +86,38 @@ var errExhausted = errors.New("exhausted")
 // which point this limit was decreased to 100K.
 const fileLimit = 100_000
 
+// FindModules returns the go.mod files of the modules found by walking
+// the directory tree rooted at root, skipping directories that are
+// ignored by the go command or excluded by the directoryFilters setting
+// (interpreted relative to root). The search gives up silently after
+// visiting a large number of files.
+//
+// The result is memoized for the snapshot and its successors, until a
+// go.mod file changes. Callers must not modify it.
+func (s *Snapshot) FindModules(root protocol.DocumentURI) ([]protocol.DocumentURI, error) {
+	s.mu.Lock()
+	uris, ok := s.foundModules.Get(root)
+	s.mu.Unlock()
+	if ok {
+		return uris, nil
+	}
+
+	excludePath := pathExcludedByFilterFunc(root.Path(), s.view.gomodcache, s.Options())
+	modFiles, err := findModules(root, excludePath, 0)
+	if err != nil && err != errExhausted {
+		return nil, err
+	}
+	for uri := range modFiles {
+		uris = append(uris, uri)
+	}
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+
+	s.mu.Lock()
+	s.foundModules.Set(root, uris, nil)
+	s.mu.Unlock()
+	return uris, nil
+}
+
 // findModules recursively walks the root directory looking for go.mod files,
 // returning the set of modules it discovers. If modLimit is non-zero,
 // searching stops once modLimit modules have been found.
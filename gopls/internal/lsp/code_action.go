@@ -108,6 +108,35 @@ func (s *server) CodeAction(ctx context.Context, params *protocol.CodeActionPara
 			actions = append(actions, fixes...)
 		}
 
+		// Offer fixes for the require statements in range based on why
+		// each module is required, unless a diagnostic already did.
+		if want[protocol.QuickFix] {
+			reqFixes, err := mod.RequireQuickFixes(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				event.Error(ctx, "computing go.mod require fixes", err)
+			}
+			titles := make(map[string]bool)
+			for _, action := range actions {
+				titles[action.Title] = true
+			}
+			for _, fix := range reqFixes {
+				if titles[fix.Title] {
+					continue
+				}
+				action := protocol.CodeAction{
+					Title:   fix.Title,
+					Kind:    fix.ActionKind,
+					Command: fix.Command,
+				}
+				if edits, ok := fix.Edits[uri]; ok {
+					action.Edit = &protocol.WorkspaceEdit{
+						DocumentChanges: documentChanges(fh, edits),
+					}
+				}
+				actions = append(actions, action)
+			}
+		}
+
 		return actions, nil
 
 	case file.Go:
@@ -455,6 +484,10 @@ func refactorRewrite(ctx context.Context, snapshot source.Snapshot, pkg source.P
 		actions = append(actions, action)
 	}
 
+	if action, ok := source.ReorderStructFields(pkg, pgf, fh, rng); ok {
+		actions = append(actions, action)
+	}
+
 	start, end, err := pgf.RangePos(rng)
 	if err != nil {
 		return nil, err
@@ -14,6 +14,7 @@ import (
 	"golang.org/x/tools/gopls/internal/lsp/mod"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/gopls/internal/lsp/source"
+	"golang.org/x/tools/gopls/internal/lsp/work"
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/event/tag"
 )
@@ -33,6 +34,8 @@ func (s *server) CodeLens(ctx context.Context, params *protocol.CodeLensParams)
 		lenses = mod.LensFuncs()
 	case file.Go:
 		lenses = source.LensFuncs()
+	case file.Work:
+		lenses = work.LensFuncs()
 	default:
 		// Unsupported file kind for a code lens.
 		return nil, nil
@@ -56,7 +59,43 @@ func (s *server) CodeLens(ctx context.Context, params *protocol.CodeLensParams)
 		if cmp := protocol.CompareRange(a.Range, b.Range); cmp != 0 {
 			return cmp < 0
 		}
-		return a.Command.Command < b.Command.Command
+		return lensCommand(a) < lensCommand(b)
 	})
 	return result, nil
 }
+
+// lensCommand returns the command name of a code lens, or "" if the
+// lens is unresolved.
+func lensCommand(lens protocol.CodeLens) string {
+	if lens.Command == nil {
+		return ""
+	}
+	return lens.Command.Command
+}
+
+// ResolveCodeLens computes the command of a lens returned unresolved by
+// CodeLens. Only the references lens is resolved lazily.
+func (s *server) ResolveCodeLens(ctx context.Context, lens *protocol.CodeLens) (*protocol.CodeLens, error) {
+	ctx, done := event.Start(ctx, "lsp.Server.resolveCodeLens")
+	defer done()
+
+	if lens.Command != nil {
+		return lens, nil // already resolved
+	}
+	args, err := source.DecodeReferencesCodeLens(lens)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, args.Location.URI, file.Go)
+	defer release()
+	if !ok {
+		return nil, err
+	}
+	cmd, err := source.ResolveReferencesCodeLens(ctx, snapshot, fh, args)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *lens
+	resolved.Command = cmd
+	return &resolved, nil
+}
@@ -13,19 +13,25 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"runtime/pprof"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"unicode"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/cover"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/gopls/internal/bug"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/lsp/cache"
 	"golang.org/x/tools/gopls/internal/lsp/command"
 	"golang.org/x/tools/gopls/internal/lsp/debug"
+	"golang.org/x/tools/gopls/internal/lsp/mod"
 	"golang.org/x/tools/gopls/internal/lsp/progress"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/gopls/internal/lsp/source"
@@ -504,14 +510,15 @@ func (c *commandHandler) runTests(ctx context.Context, snapshot *cache.Snapshot,
 	// create output
 	buf := &bytes.Buffer{}
 	ew := progress.NewEventWriter(ctx, "test")
-	out := io.MultiWriter(ew, progress.NewWorkDoneWriter(ctx, work), buf)
+	results := &testResultWriter{report: func(line string) { work.Report(ctx, line, 0) }}
+	out := io.MultiWriter(ew, results, buf)
 
 	// Run `go test -run Func` on each test.
 	var failedTests int
 	for _, funcName := range tests {
 		inv := &gocommand.Invocation{
 			Verb:       "test",
-			Args:       []string{pkgPath, "-v", "-count=1", "-run", fmt.Sprintf("^%s$", funcName)},
+			Args:       []string{pkgPath, "-v", "-count=1", "-run", testRunPattern(funcName)},
 			WorkingDir: filepath.Dir(uri.Path()),
 		}
 		if err := snapshot.RunGoCommandPiped(ctx, source.Normal, inv, out, out); err != nil {
@@ -571,6 +578,63 @@ func (c *commandHandler) runTests(ctx context.Context, snapshot *cache.Snapshot,
 	return nil
 }
 
+// testRunPattern returns the argument of the go test -run flag that
+// selects exactly the named test, which may be a subtest such as
+// "TestFoo/some case".
+func testRunPattern(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(rewriteSubtestName(part)) + "$"
+	}
+	return strings.Join(parts, "/")
+}
+
+// rewriteSubtestName applies the transformation of the testing package to
+// the name of a subtest, which replaces spaces and escapes unprintable
+// characters.
+func rewriteSubtestName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteByte('_')
+		case !strconv.IsPrint(r):
+			s := strconv.QuoteRune(r)
+			b.WriteString(s[1 : len(s)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// A testResultWriter reports the result of each test and subtest in the
+// verbose output of go test, such as "--- PASS: TestFoo/case (0.00s)",
+// typically as a progress notification.
+type testResultWriter struct {
+	report func(line string)
+	line   []byte // incomplete last line of output
+}
+
+func (w *testResultWriter) Write(p []byte) (int, error) {
+	w.line = append(w.line, p...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSpace(string(w.line[:i]))
+		w.line = w.line[i+1:]
+		for _, prefix := range []string{"--- PASS: ", "--- FAIL: ", "--- SKIP: "} {
+			if strings.HasPrefix(line, prefix) {
+				w.report(line)
+			}
+		}
+	}
+	// Don't fail just because of a failure to report progress.
+	return len(p), nil
+}
+
 func (c *commandHandler) Generate(ctx context.Context, args command.GenerateArgs) error {
 	title := "Running go generate ."
 	if args.Recursive {
@@ -627,11 +691,41 @@ func (c *commandHandler) GoGetPackage(ctx context.Context, args command.GoGetPac
 	})
 }
 
+func (c *commandHandler) AddCachedDependency(ctx context.Context, args command.CachedDependencyArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI:   args.URI,
+		progress: "Running go get",
+	}, func(ctx context.Context, deps commandDeps) error {
+		// Run go get without network access, so that it resolves the module
+		// and its requirements from the module cache, and verifies their
+		// checksums as usual.
+		dep := module.Version{Path: args.Module, Version: args.Version}
+		target := dep.String()
+		if args.Package != "" {
+			target = args.Package + "@" + dep.Version
+		}
+		newModBytes, newSumBytes, err := deps.snapshot.RunOfflineGoModUpdateCommands(ctx, filepath.Dir(args.URI.Path()), func(invoke func(...string) (*bytes.Buffer, error)) error {
+			_, err := invoke("get", "-d", target)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("adding %s from the module cache: %v", dep, err)
+		}
+		return c.s.applyGoModUpdate(ctx, deps.snapshot, args.URI, newModBytes, newSumBytes)
+	})
+}
+
 func (s *server) runGoModUpdateCommands(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, run func(invoke func(...string) (*bytes.Buffer, error)) error) error {
 	newModBytes, newSumBytes, err := snapshot.RunGoModUpdateCommands(ctx, filepath.Dir(uri.Path()), run)
 	if err != nil {
 		return err
 	}
+	return s.applyGoModUpdate(ctx, snapshot, uri, newModBytes, newSumBytes)
+}
+
+// applyGoModUpdate asks the client to replace the go.mod and go.sum files
+// of the module containing uri with the given contents.
+func (s *server) applyGoModUpdate(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, newModBytes, newSumBytes []byte) error {
 	modURI := snapshot.GoModForFile(uri)
 	sumURI := protocol.URIFromPath(strings.TrimSuffix(modURI.Path(), ".mod") + ".sum")
 	modEdits, err := collectFileEdits(ctx, snapshot, modURI, newModBytes)
@@ -776,18 +870,108 @@ func (c *commandHandler) ToggleGCDetails(ctx context.Context, args command.URIAr
 			return err
 		}
 		c.s.gcOptimizationDetailsMu.Lock()
-		if _, ok := c.s.gcOptimizationDetails[meta.ID]; ok {
+		_, enabled := c.s.gcOptimizationDetails[meta.ID]
+		if enabled {
 			delete(c.s.gcOptimizationDetails, meta.ID)
 			c.s.clearDiagnosticSource(gcDetailsSource)
 		} else {
-			c.s.gcOptimizationDetails[meta.ID] = struct{}{}
+			c.s.gcOptimizationDetails[meta.ID] = nil // computed by diagnostics
 		}
 		c.s.gcOptimizationDetailsMu.Unlock()
+		if enabled {
+			c.s.refreshInlayHints(ctx, deps.snapshot)
+		}
 		c.s.diagnoseSnapshot(deps.snapshot, nil, false, 0)
 		return nil
 	})
 }
 
+func (c *commandHandler) Coverage(ctx context.Context, args command.CoverageArgs) error {
+	if args.Clear {
+		return c.run(ctx, commandConfig{
+			forURI: args.URI,
+		}, func(ctx context.Context, deps commandDeps) error {
+			c.s.coverageMu.Lock()
+			c.s.coverage = nil
+			c.s.coverageMu.Unlock()
+			c.s.clearDiagnosticSource(coverageSource)
+			c.s.diagnoseSnapshot(deps.snapshot, nil, false, 0)
+			return nil
+		})
+	}
+	return c.run(ctx, commandConfig{
+		async:       args.Profile == "", // may be slow
+		requireSave: true,
+		progress:    "Computing test coverage",
+		forURI:      args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		profile := args.Profile
+		if profile == "" {
+			f, err := os.CreateTemp("", "gopls-coverage-*.out")
+			if err != nil {
+				return err
+			}
+			f.Close()
+			profile = f.Name()
+			defer os.Remove(profile)
+			if err := runCoverage(ctx, deps.snapshot, args.URI, profile); err != nil {
+				return err
+			}
+		}
+		profiles, err := cover.ParseProfiles(profile)
+		if err != nil {
+			return err
+		}
+		loaded, err := source.LoadCoverage(ctx, deps.snapshot, profiles)
+		if err != nil {
+			return err
+		}
+		if len(loaded) == 0 {
+			return fmt.Errorf("no coverage of workspace files in profile %s", profile)
+		}
+
+		c.s.coverageMu.Lock()
+		coverage := make(map[protocol.DocumentURI]*source.FileCoverage)
+		for uri, fc := range c.s.coverage {
+			coverage[uri] = fc
+		}
+		for uri, fc := range loaded {
+			coverage[uri] = fc
+		}
+		c.s.coverage = coverage
+		c.s.coverageMu.Unlock()
+
+		c.s.diagnoseSnapshot(deps.snapshot, nil, false, 0)
+		return nil
+	})
+}
+
+// runCoverage runs the tests of the package containing uri, writing a
+// coverage profile to the named file.
+func runCoverage(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, profile string) error {
+	meta, err := source.NarrowestMetadataForFile(ctx, snapshot, uri)
+	if err != nil {
+		return err
+	}
+	pkgPath := meta.PkgPath
+	if meta.ForTest != "" {
+		pkgPath = meta.ForTest
+	}
+	var buf bytes.Buffer
+	inv := &gocommand.Invocation{
+		Verb:       "test",
+		Args:       []string{string(pkgPath), "-coverprofile=" + profile},
+		WorkingDir: filepath.Dir(uri.Path()),
+	}
+	if err := snapshot.RunGoCommandPiped(ctx, source.Normal, inv, &buf, &buf); err != nil {
+		// Failing tests still produce a profile.
+		if info, statErr := os.Stat(profile); statErr != nil || info.Size() == 0 {
+			return fmt.Errorf("running tests with coverage: %v\n%s", err, buf.String())
+		}
+	}
+	return nil
+}
+
 func (c *commandHandler) ListKnownPackages(ctx context.Context, args command.URIArg) (command.ListKnownPackagesResult, error) {
 	var result command.ListKnownPackagesResult
 	err := c.run(ctx, commandConfig{
@@ -803,6 +987,19 @@ func (c *commandHandler) ListKnownPackages(ctx context.Context, args command.URI
 	return result, err
 }
 
+func (c *commandHandler) ModuleWhy(ctx context.Context, args command.ModuleWhyArgs) (command.ModuleWhyResult, error) {
+	var result command.ModuleWhyResult
+	err := c.run(ctx, commandConfig{
+		progress: "Explaining requirement",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = mod.ModuleWhy(ctx, deps.snapshot, deps.fh, args.Module)
+		return err
+	})
+	return result, err
+}
+
 func (c *commandHandler) ListImports(ctx context.Context, args command.URIArg) (command.ListImportsResult, error) {
 	var result command.ListImportsResult
 	err := c.run(ctx, commandConfig{
@@ -848,6 +1045,27 @@ func (c *commandHandler) ListImports(ctx context.Context, args command.URIArg) (
 	return result, err
 }
 
+func (c *commandHandler) References(ctx context.Context, args command.ReferencesArgs) ([]protocol.Location, error) {
+	var result []protocol.Location
+	err := c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = source.References(ctx, deps.snapshot, deps.fh, args.Location.Range.Start, false)
+		if err != nil {
+			return err
+		}
+		// LSP has no request by which the server can open the
+		// client's references view, so the client must display the
+		// result itself. But a lone reference can be shown directly.
+		if len(result) == 1 {
+			openClientEditor(ctx, c.s.client, result[0])
+		}
+		return nil
+	})
+	return result, err
+}
+
 func (c *commandHandler) AddImport(ctx context.Context, args command.AddImportArgs) error {
 	return c.run(ctx, commandConfig{
 		progress: "Adding import",
@@ -1187,6 +1405,36 @@ func (c *commandHandler) RunGoWorkCommand(ctx context.Context, args command.RunG
 	})
 }
 
+// GoWorkUse adds a module to, or removes it from, the use directives of
+// the view's go.work file.
+func (c *commandHandler) GoWorkUse(ctx context.Context, args command.GoWorkUseArgs) error {
+	return c.run(ctx, commandConfig{
+		progress: "Updating go.work",
+		forView:  args.ViewID,
+	}, func(ctx context.Context, deps commandDeps) error {
+		snapshot := deps.snapshot
+		goworkURI, off := snapshot.View().GOWORK()
+		if off || goworkURI == "" {
+			return fmt.Errorf("no go.work file to update")
+		}
+		fh, err := snapshot.ReadFile(ctx, goworkURI)
+		if err != nil {
+			return fmt.Errorf("reading current go.work file: %v", err)
+		}
+		if !fh.SameContentsOnDisk() {
+			return fmt.Errorf("must save workspace file %s before running go work commands", goworkURI)
+		}
+		goArgs := []string{"use", args.Dir}
+		if args.Drop {
+			goArgs = []string{"edit", "-dropuse=" + args.Dir}
+		}
+		// Use directives are relative to the directory of the go.work
+		// file, so run the go command there.
+		gowork := goworkURI.Path()
+		return c.invokeGoWork(ctx, filepath.Dir(gowork), gowork, goArgs)
+	})
+}
+
 func (c *commandHandler) invokeGoWork(ctx context.Context, viewDir, gowork string, args []string) error {
 	inv := gocommand.Invocation{
 		Verb:       "work",
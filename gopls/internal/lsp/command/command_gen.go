@@ -22,21 +22,26 @@ import (
 // These commands may be requested by ExecuteCommand, CodeLens,
 // CodeAction, and other LSP requests.
 const (
+	AddCachedDependency     Command = "add_cached_dependency"
 	AddDependency           Command = "add_dependency"
 	AddImport               Command = "add_import"
 	AddTelemetryCounters    Command = "add_telemetry_counters"
 	ApplyFix                Command = "apply_fix"
 	ChangeSignature         Command = "change_signature"
 	CheckUpgrades           Command = "check_upgrades"
+	Coverage                Command = "coverage"
 	EditGoDirective         Command = "edit_go_directive"
 	FetchVulncheckResult    Command = "fetch_vulncheck_result"
 	GCDetails               Command = "gc_details"
 	Generate                Command = "generate"
 	GoGetPackage            Command = "go_get_package"
+	GoWorkUse               Command = "go_work_use"
 	ListImports             Command = "list_imports"
 	ListKnownPackages       Command = "list_known_packages"
 	MaybePromptForTelemetry Command = "maybe_prompt_for_telemetry"
 	MemStats                Command = "mem_stats"
+	ModuleWhy               Command = "module_why"
+	References              Command = "references"
 	RegenerateCgo           Command = "regenerate_cgo"
 	RemoveDependency        Command = "remove_dependency"
 	ResetGoModDiagnostics   Command = "reset_go_mod_diagnostics"
@@ -56,21 +61,26 @@ const (
 )
 
 var Commands = []Command{
+	AddCachedDependency,
 	AddDependency,
 	AddImport,
 	AddTelemetryCounters,
 	ApplyFix,
 	ChangeSignature,
 	CheckUpgrades,
+	Coverage,
 	EditGoDirective,
 	FetchVulncheckResult,
 	GCDetails,
 	Generate,
 	GoGetPackage,
+	GoWorkUse,
 	ListImports,
 	ListKnownPackages,
 	MaybePromptForTelemetry,
 	MemStats,
+	ModuleWhy,
+	References,
 	RegenerateCgo,
 	RemoveDependency,
 	ResetGoModDiagnostics,
@@ -91,6 +101,12 @@ var Commands = []Command{
 
 func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Interface) (interface{}, error) {
 	switch params.Command {
+	case "gopls.add_cached_dependency":
+		var a0 CachedDependencyArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.AddCachedDependency(ctx, a0)
 	case "gopls.add_dependency":
 		var a0 DependencyArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -127,6 +143,12 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.CheckUpgrades(ctx, a0)
+	case "gopls.coverage":
+		var a0 CoverageArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.Coverage(ctx, a0)
 	case "gopls.edit_go_directive":
 		var a0 EditGoDirectiveArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -157,6 +179,12 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.GoGetPackage(ctx, a0)
+	case "gopls.go_work_use":
+		var a0 GoWorkUseArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.GoWorkUse(ctx, a0)
 	case "gopls.list_imports":
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -173,6 +201,18 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 		return nil, s.MaybePromptForTelemetry(ctx)
 	case "gopls.mem_stats":
 		return s.MemStats(ctx)
+	case "gopls.module_why":
+		var a0 ModuleWhyArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.ModuleWhy(ctx, a0)
+	case "gopls.references":
+		var a0 ReferencesArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.References(ctx, a0)
 	case "gopls.regenerate_cgo":
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -271,6 +311,18 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 	return nil, fmt.Errorf("unsupported command %q", params.Command)
 }
 
+func NewAddCachedDependencyCommand(title string, a0 CachedDependencyArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.add_cached_dependency",
+		Arguments: args,
+	}, nil
+}
+
 func NewAddDependencyCommand(title string, a0 DependencyArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -343,6 +395,18 @@ func NewCheckUpgradesCommand(title string, a0 CheckUpgradesArgs) (protocol.Comma
 	}, nil
 }
 
+func NewCoverageCommand(title string, a0 CoverageArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.coverage",
+		Arguments: args,
+	}, nil
+}
+
 func NewEditGoDirectiveCommand(title string, a0 EditGoDirectiveArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -403,6 +467,18 @@ func NewGoGetPackageCommand(title string, a0 GoGetPackageArgs) (protocol.Command
 	}, nil
 }
 
+func NewGoWorkUseCommand(title string, a0 GoWorkUseArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.go_work_use",
+		Arguments: args,
+	}, nil
+}
+
 func NewListImportsCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -451,6 +527,30 @@ func NewMemStatsCommand(title string) (protocol.Command, error) {
 	}, nil
 }
 
+func NewModuleWhyCommand(title string, a0 ModuleWhyArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.module_why",
+		Arguments: args,
+	}, nil
+}
+
+func NewReferencesCommand(title string, a0 ReferencesArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.references",
+		Arguments: args,
+	}, nil
+}
+
 func NewRegenerateCgoCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
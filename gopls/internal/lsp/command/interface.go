@@ -105,21 +105,58 @@ type Interface interface {
 	// Reset diagnostics in the go.mod file of a module.
 	ResetGoModDiagnostics(context.Context, ResetGoModDiagnosticsArgs) error
 
+	// ModuleWhy: Explain why a module is required
+	//
+	// Reports why a module is required by the given go.mod file: the
+	// shortest chain of imports from a package of the main module to a
+	// package of the module, as computed by `go mod why -m`, and the
+	// modules of the module graph that require it.
+	ModuleWhy(context.Context, ModuleWhyArgs) (ModuleWhyResult, error)
+
 	// GoGetPackage: go get a package
 	//
 	// Runs `go get` to fetch a package.
 	GoGetPackage(context.Context, GoGetPackageArgs) error
 
+	// AddCachedDependency: Add a dependency from the module cache
+	//
+	// Runs `go get` for a module version in the module cache, or one of
+	// its packages, without network access, to add it and its requirements
+	// to the go.mod and go.sum files of the module containing the given
+	// file. It is used by the completion of packages of modules that are
+	// not yet required.
+	AddCachedDependency(context.Context, CachedDependencyArgs) error
+
+	// Coverage: Show test coverage
+	//
+	// Runs `go test -coverprofile` for the package of the given file, or
+	// loads an existing coverage profile, and reports the coverage of each
+	// file in the workspace: statements that were not executed by tests are
+	// reported as diagnostics, and hovering over the name of a function
+	// shows the percentage of its statements that were executed. The
+	// coverage of a file is discarded once it is edited.
+	Coverage(context.Context, CoverageArgs) error
+
 	// GCDetails: Toggle gc_details
 	//
-	// Toggle the calculation of gc annotations.
+	// Toggle the calculation of gc annotations: the optimization decisions
+	// of the compiler, such as escapes to the heap, inlining, and bounds and
+	// nil checks, in the package of the file. They are shown as inlay hints,
+	// whose tooltips and hovers explain the escape of values, or as
+	// diagnostics if the client does not support inlay hints, and are
+	// recomputed whenever the files of the package are saved.
 	GCDetails(context.Context, protocol.DocumentURI) error
 
 	// TODO: deprecate GCDetails in favor of ToggleGCDetails below.
 
 	// ToggleGCDetails: Toggle gc_details
 	//
-	// Toggle the calculation of gc annotations.
+	// Toggle the calculation of gc annotations: the optimization decisions
+	// of the compiler, such as escapes to the heap, inlining, and bounds and
+	// nil checks, in the package of the file. They are shown as inlay hints,
+	// whose tooltips and hovers explain the escape of values, or as
+	// diagnostics if the client does not support inlay hints, and are
+	// recomputed whenever the files of the package are saved.
 	ToggleGCDetails(context.Context, URIArg) error
 
 	// ListKnownPackages: List known packages
@@ -133,6 +170,21 @@ type Interface interface {
 	// belongs to.
 	ListImports(context.Context, URIArg) (ListImportsResult, error)
 
+	// References: Show references
+	//
+	// Returns the references to the symbol declared at the given location,
+	// for presentation in the client's references view. This is the
+	// command of the references code lens, which displays the number of
+	// references to (and implementations of) each top-level function, type
+	// and method.
+	//
+	// LSP provides no means for the server to open the references view,
+	// so clients must handle the result of this command themselves; for
+	// example, VS Code can pass it to editor.action.showReferences. If
+	// there is exactly one reference, the server also asks the client to
+	// show it, using window/showDocument.
+	References(context.Context, ReferencesArgs) ([]protocol.Location, error)
+
 	// AddImport: Add an import
 	//
 	// Ask the server to add an import path to a given Go file.  The method will
@@ -191,6 +243,15 @@ type Interface interface {
 	// edits to the current go.work file.
 	RunGoWorkCommand(context.Context, RunGoWorkArgs) error
 
+	// GoWorkUse: Add or remove a go.work module
+	//
+	// Adds a module to the use directives of the current go.work file,
+	// by running `go work use`, or removes it, by running
+	// `go work edit -dropuse`. The go_work_use code lens offers this for
+	// each module used by a go.work file, and for each module beneath its
+	// directory that it does not use.
+	GoWorkUse(context.Context, GoWorkUseArgs) error
+
 	// AddTelemetryCounters: update the given telemetry counters.
 	//
 	// Gopls will prepend "fwd/" to all the counters updated using this command
@@ -291,6 +352,17 @@ type GoGetPackageArgs struct {
 	AddRequire bool
 }
 
+type CachedDependencyArgs struct {
+	// Any document URI within the relevant module.
+	URI protocol.DocumentURI
+	// The path and version of the module in the module cache.
+	Module  string
+	Version string
+	// The package of the module to be imported, if any, whose imports are
+	// resolved too.
+	Package string
+}
+
 type AddImportArgs struct {
 	// ImportPath is the target import path that should
 	// be added to the URI file
@@ -317,6 +389,23 @@ type ListImportsResult struct {
 	PackageImports []PackageImport
 }
 
+type ModuleWhyArgs struct {
+	// The go.mod file.
+	URI protocol.DocumentURI
+	// The path of a module required by the go.mod file.
+	Module string
+}
+
+type ModuleWhyResult struct {
+	// ImportChain is the shortest chain of import paths from a package of
+	// the main module to a package of the module. It is empty if the main
+	// module does not need the module.
+	ImportChain []string
+	// RequiredBy lists the modules of the module graph that require the
+	// module, as "path@version" (or just "path" for the main module).
+	RequiredBy []string
+}
+
 type FileImport struct {
 	// Path is the import path of the import.
 	Path string
@@ -518,6 +607,15 @@ type RunGoWorkArgs struct {
 	Args      []string // Args to pass to `go work`
 }
 
+// GoWorkUseArgs holds the arguments to the GoWorkUse command, which
+// adds a use directive for a module to the go.work file of a view, or
+// drops one.
+type GoWorkUseArgs struct {
+	ViewID string // ID of the view whose go.work file to update
+	Dir    string // The module directory, as written in a use directive
+	Drop   bool   // Whether to remove the module, rather than add it
+}
+
 // AddTelemetryCountersArgs holds the arguments to the AddCounters command
 // that updates the telemetry counters.
 type AddTelemetryCountersArgs struct {
@@ -526,6 +624,27 @@ type AddTelemetryCountersArgs struct {
 	Values []int64  // Values added to the corresponding counters. Must be non-negative.
 }
 
+// CoverageArgs specifies the test coverage to show.
+type CoverageArgs struct {
+	// A file of the package whose tests are run, or, when loading or
+	// clearing coverage, any file of the workspace.
+	URI protocol.DocumentURI
+	// The path of an existing coverage profile, as produced by
+	// `go test -coverprofile`, to load instead of running tests.
+	Profile string
+	// Whether to discard all test coverage instead.
+	Clear bool
+}
+
+// ReferencesArgs specifies the symbol whose references are requested.
+type ReferencesArgs struct {
+	// The location of the declaring identifier.
+	Location protocol.Location
+	// Whether the symbol is a type or method, and so may have
+	// implementations.
+	Implementations bool
+}
+
 // ChangeSignatureArgs specifies a "change signature" refactoring to perform.
 type ChangeSignatureArgs struct {
 	RemoveParameter protocol.Location
@@ -0,0 +1,95 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTestRunPattern(t *testing.T) {
+	for _, test := range []struct {
+		name, want string
+	}{
+		{"TestFoo", `^TestFoo$`},
+		{"TestFoo/simple", `^TestFoo$/^simple$`},
+		{"TestFoo/with spaces", `^TestFoo$/^with_spaces$`},
+		{"TestFoo/tab\there", `^TestFoo$/^tab_here$`},
+		{"TestFoo/bell\a", `^TestFoo$/^bell\\a$`},
+		{"TestFoo/nul\x00", `^TestFoo$/^nul\\x00$`},
+		{"TestFoo/a/b", `^TestFoo$/^a$/^b$`},
+		{"TestFoo/x+y (z)", `^TestFoo$/^x\+y_\(z\)$`},
+		{"TestFoo/héllo", `^TestFoo$/^héllo$`},
+	} {
+		if got := testRunPattern(test.name); got != test.want {
+			t.Errorf("testRunPattern(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRewriteSubtestName(t *testing.T) {
+	for _, test := range []struct {
+		name, want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"two words", "two_words"},
+		{" leading and trailing ", "_leading_and_trailing_"},
+		{"line\nbreak", "line_break"},
+		{"esc\x1b", `esc\x1b`},
+		{"del\x7f", `del\x7f`},
+		{"nbsp\u00a0", "nbsp_"},
+		{"zero\u200bwidth", `zero\u200bwidth`},
+	} {
+		if got := rewriteSubtestName(test.name); got != test.want {
+			t.Errorf("rewriteSubtestName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTestResultWriter(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		writes []string
+		want   []string
+	}{
+		{
+			"whole lines",
+			[]string{"=== RUN   TestFoo\n--- PASS: TestFoo (0.00s)\nPASS\n"},
+			[]string{"--- PASS: TestFoo (0.00s)"},
+		},
+		{
+			"subtests",
+			[]string{"--- FAIL: TestFoo (0.01s)\n    --- PASS: TestFoo/a (0.00s)\n    --- FAIL: TestFoo/b_c (0.01s)\n    --- SKIP: TestFoo/d (0.00s)\n"},
+			[]string{"--- FAIL: TestFoo (0.01s)", "--- PASS: TestFoo/a (0.00s)", "--- FAIL: TestFoo/b_c (0.01s)", "--- SKIP: TestFoo/d (0.00s)"},
+		},
+		{
+			"split writes",
+			[]string{"--- PA", "SS: TestFoo/a (0.", "00s)", "\n--- FAIL: Te", "stFoo (0.00s)\n"},
+			[]string{"--- PASS: TestFoo/a (0.00s)", "--- FAIL: TestFoo (0.00s)"},
+		},
+		{
+			"incomplete last line",
+			[]string{"--- PASS: TestFoo (0.00s)"},
+			nil,
+		},
+		{
+			"other output",
+			[]string{"ok  \texample.com/a\t0.01s\n", "x --- PASS: not at start\n", "--- BENCH: BenchmarkFoo\n"},
+			nil,
+		},
+	} {
+		var got []string
+		w := &testResultWriter{report: func(line string) { got = append(got, line) }}
+		for _, s := range test.writes {
+			if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+				t.Errorf("%s: Write(%q) = %d, %v", test.name, s, n, err)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: reported %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -131,6 +131,7 @@ func toProtocolCompletionItems(candidates []completion.CompletionItem, rng proto
 			},
 			InsertTextFormat:    &options.InsertTextFormat,
 			AdditionalTextEdits: candidate.AdditionalTextEdits,
+			Command:             candidate.Command,
 			// This is a hack so that the client sorts completion results in the order
 			// according to their score. This can be removed upon the resolution of
 			// https://github.com/Microsoft/language-server-protocol/issues/348.
@@ -832,6 +832,35 @@ var SessionTmpl = template.Must(template.Must(BaseTemplate.Clone()).Parse(`
 From: <b>{{template "cachelink" .Cache.ID}}</b><br>
 <h2>Views</h2>
 <ul>{{range .Views}}<li>{{.Name}} is {{template "viewlink" .ID}} in {{.Folder}}</li>{{end}}</ul>
+<h2>Memory usage</h2>
+{{with .Usage}}
+<table>
+<tr><td class="label">Views</td><td class="value">{{.Views}}</td></tr>
+<tr><td class="label">Packages</td><td class="value">{{.Packages}}</td></tr>
+<tr><td class="label">Files</td><td class="value">{{.Files}} ({{fuint64 .FileBytes}} bytes)</td></tr>
+<tr><td class="label">Open files</td><td class="value">{{.Overlays}} ({{fuint64 .OverlayBytes}} bytes)</td></tr>
+<tr><td class="label">Parsed files</td><td class="value">{{.ParsedFiles}} ({{fuint64 .ParsedBytes}} bytes)</td></tr>
+<tr><td class="label">Dependency symbols</td><td class="value">{{.DepSymbols}} in {{.DepModules}} modules</td></tr>
+<tr><td class="label">Total source bytes</td><td class="value">{{fuint64 .Bytes}}</td></tr>
+</table>
+{{end}}
+<h2>Memory budget</h2>
+{{with .MemoryStats}}
+{{if .Budget}}
+<table>
+<tr><td class="label">Budget bytes</td><td class="value">{{fuint64 .Budget}}</td></tr>
+<tr><td class="label">Sampled heap bytes</td><td class="value">{{fuint64 .HeapSize}}</td></tr>
+<tr><td class="label">Over budget</td><td class="value">{{.OverBudget}}</td></tr>
+<tr><td class="label">Evictions</td><td class="value">{{.Evictions}}</td></tr>
+<tr><td class="label">Evicted parsed files</td><td class="value">{{.EvictedFiles}}</td></tr>
+<tr><td class="label">Released import graphs</td><td class="value">{{.EvictedGraphs}}</td></tr>
+<tr><td class="label">Dropped dependency modules</td><td class="value">{{.EvictedDeps}}</td></tr>
+<tr><td class="label">Last eviction</td><td class="value">{{if .Evictions}}{{.LastEviction}}{{else}}never{{end}}</td></tr>
+</table>
+{{else}}
+No memory budget is set.
+{{end}}
+{{end}}
 <h2>Overlays</h2>
 {{$session := .}}
 <ul>{{range .Overlays}}
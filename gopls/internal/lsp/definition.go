@@ -12,6 +12,7 @@ import (
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/gopls/internal/lsp/source"
 	"golang.org/x/tools/gopls/internal/lsp/template"
+	"golang.org/x/tools/gopls/internal/lsp/work"
 	"golang.org/x/tools/gopls/internal/telemetry"
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/event/tag"
@@ -34,9 +35,11 @@ func (s *server) Definition(ctx context.Context, params *protocol.DefinitionPara
 	}
 	switch kind := snapshot.FileKind(fh); kind {
 	case file.Tmpl:
-		return template.Definition(snapshot, fh, params.Position)
+		return template.Definition(ctx, snapshot, fh, params.Position)
 	case file.Go:
 		return source.Definition(ctx, snapshot, fh, params.Position)
+	case file.Work:
+		return work.Definition(ctx, snapshot, fh, params.Position)
 	default:
 		return nil, fmt.Errorf("can't find definitions for file type %s", kind)
 	}
@@ -50,6 +50,8 @@ const (
 	workSource
 	modCheckUpgradesSource
 	modVulncheckSource // source.Govulncheck + source.Vulncheck
+	unusedSource
+	coverageSource
 )
 
 // A diagnosticReport holds results for a single diagnostic source.
@@ -112,6 +114,10 @@ func (d diagnosticSource) String() string {
 		return "FromCheckForUpgrades"
 	case modVulncheckSource:
 		return "FromModVulncheck"
+	case unusedSource:
+		return "FromUnused"
+	case coverageSource:
+		return "FromCoverage"
 	default:
 		return fmt.Sprintf("From?%d?", d)
 	}
@@ -361,9 +367,12 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot, analyze
 	// error progress reports will be closed.
 	s.showCriticalErrorStatus(ctx, snapshot, criticalErr)
 
+	// Report test coverage.
+	s.diagnoseCoverage(ctx, snapshot)
+
 	// Diagnose template (.tmpl) files.
 	for _, f := range snapshot.Templates() {
-		diags := template.Diagnose(f)
+		diags := template.Diagnose(ctx, snapshot, f)
 		s.storeDiagnostics(snapshot, f.URI(), typeCheckSource, diags, true)
 	}
 
@@ -432,6 +441,29 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot, analyze
 	}
 }
 
+// diagnoseCoverage reports the test coverage loaded by the coverage
+// command for each file whose content is unchanged since the tests ran.
+func (s *server) diagnoseCoverage(ctx context.Context, snapshot *cache.Snapshot) {
+	s.coverageMu.Lock()
+	coverage := s.coverage
+	s.coverageMu.Unlock()
+
+	for uri, fc := range coverage {
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			if ctx.Err() == nil {
+				event.Error(ctx, "reading covered file", err, snapshot.Labels()...)
+			}
+			continue
+		}
+		var diags []*source.Diagnostic
+		if fh.Identity().Hash == fc.Hash {
+			diags = source.CoverageDiagnostics(uri, fc)
+		}
+		s.storeDiagnostics(snapshot, uri, coverageSource, diags, false)
+	}
+}
+
 // diagnosePkgs type checks packages in toDiagnose, and analyzes packages in
 // toAnalyze, merging their diagnostics. Packages in toAnalyze must be a subset
 // of the packages in toDiagnose.
@@ -453,6 +485,7 @@ func (s *server) diagnosePkgs(ctx context.Context, snapshot *cache.Snapshot, toD
 		wg            sync.WaitGroup
 		pkgDiags      map[protocol.DocumentURI][]*source.Diagnostic
 		analysisDiags = make(map[protocol.DocumentURI][]*source.Diagnostic)
+		unusedDiags   map[protocol.DocumentURI][]*source.Diagnostic
 	)
 
 	// Collect package diagnostics.
@@ -496,6 +529,17 @@ func (s *server) diagnosePkgs(ctx context.Context, snapshot *cache.Snapshot, toD
 		}
 	}()
 
+	// Report unused declarations, if enabled.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var err error
+		unusedDiags, err = source.UnusedDeclarations(ctx, snapshot, toAnalyze)
+		if err != nil {
+			event.Error(ctx, "warning: finding unused declarations", err, snapshot.Labels()...)
+		}
+	}()
+
 	wg.Wait()
 
 	// TODO(rfindley): remove the guards against snapshot.IsBuiltin, after the
@@ -543,29 +587,44 @@ func (s *server) diagnosePkgs(ctx context.Context, snapshot *cache.Snapshot, toD
 		s.storeDiagnostics(snapshot, uri, typeCheckSource, diags, true)
 	}
 
-	// Process requested gc_details diagnostics.
+	// Store unused declaration diagnostics for every file of an analyzed
+	// package, so that fixed or disabled reports are cleared.
+	for id := range toAnalyze {
+		for _, uri := range toDiagnose[id].CompiledGoFiles {
+			s.storeDiagnostics(snapshot, uri, unusedSource, unusedDiags[uri], true)
+		}
+	}
+
+	// Process requested gc_details.
+	//
+	// Packages are compiled again only when their files have been saved
+	// with changes since they were last compiled, and are not compiled
+	// while they have unsaved changes, since the compiler reads files from
+	// disk.
 	//
-	// TODO(rfindley): this could be improved:
-	//   1. This should memoize its results if the package has not changed.
-	//   2. This should not even run gc_details if the package contains unsaved
-	//      files.
-	//   3. See note below about using FindFile.
-	var toGCDetail map[source.PackageID]*source.Metadata
+	// TODO(rfindley): see note below about using FindFile.
+	var toGCDetail map[*source.Metadata]*source.PackageGCDetails
 	s.gcOptimizationDetailsMu.Lock()
-	for id := range s.gcOptimizationDetails {
+	for id, pd := range s.gcOptimizationDetails {
 		if m, ok := toDiagnose[id]; ok {
 			if toGCDetail == nil {
-				toGCDetail = make(map[source.PackageID]*source.Metadata)
+				toGCDetail = make(map[*source.Metadata]*source.PackageGCDetails)
 			}
-			toGCDetail[id] = m
+			toGCDetail[m] = pd
 		}
 	}
 	s.gcOptimizationDetailsMu.Unlock()
 
-	for _, m := range toGCDetail {
-		gcReports, err := source.GCOptimizationDetails(ctx, snapshot, m)
-		if err != nil {
-			event.Error(ctx, "warning: gc details", err, append(snapshot.Labels(), tag.Package.Of(string(m.ID)))...)
+	options := snapshot.Options()
+	refresh := false
+	for m, pd := range toGCDetail {
+		var updated *source.PackageGCDetails
+		if source.NeedsGCDetails(ctx, snapshot, m, pd) {
+			var err error
+			updated, err = source.GCOptimizationDetails(ctx, snapshot, m)
+			if err != nil {
+				event.Error(ctx, "warning: gc details", err, append(snapshot.Labels(), tag.Package.Of(string(m.ID)))...)
+			}
 		}
 		s.gcOptimizationDetailsMu.Lock()
 		_, enableGCDetails := s.gcOptimizationDetails[m.ID]
@@ -575,21 +634,33 @@ func (s *server) diagnosePkgs(ctx context.Context, snapshot *cache.Snapshot, toD
 		// results. This ensures that the toggling of GC details and clearing of
 		// diagnostics does not race with storing the results here.
 		if enableGCDetails {
-			for uri, diags := range gcReports {
-				// TODO(rfindley): remove the use of FindFile here, and use ReadFile
-				// instead. Isn't it enough to know that the package came from the
-				// snapshot? Any reports should apply to the snapshot.
-				fh := snapshot.FindFile(uri)
-				// Don't publish gc details for unsaved buffers, since the underlying
-				// logic operates on the file on disk.
-				if fh == nil || !fh.SameContentsOnDisk() {
-					continue
+			if updated != nil {
+				s.gcOptimizationDetails[m.ID] = updated
+				pd = updated
+				refresh = true
+			}
+			// Clients that support inlay hints request the details as hints.
+			if pd != nil && !options.InlayHintSupported {
+				for uri, fd := range pd.Files {
+					// TODO(rfindley): remove the use of FindFile here, and use ReadFile
+					// instead. Isn't it enough to know that the package came from the
+					// snapshot? Any reports should apply to the snapshot.
+					fh := snapshot.FindFile(uri)
+					// Don't publish gc details for buffers that have changed since the
+					// package was compiled, since the underlying logic operates on the
+					// file on disk.
+					if fh == nil || fh.Identity().Hash != fd.Hash {
+						continue
+					}
+					s.storeDiagnostics(snapshot, uri, gcDetailsSource, source.GCDetailsDiagnostics(uri, fd, options), true)
 				}
-				s.storeDiagnostics(snapshot, uri, gcDetailsSource, diags, true)
 			}
 		}
 		s.gcOptimizationDetailsMu.Unlock()
 	}
+	if refresh {
+		s.refreshInlayHints(ctx, snapshot)
+	}
 }
 
 // mustPublishDiagnostics marks the uri as needing publication, independent of
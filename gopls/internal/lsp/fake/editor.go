@@ -1094,6 +1094,14 @@ func (e *Editor) CodeLens(ctx context.Context, path string) ([]protocol.CodeLens
 	return lens, nil
 }
 
+// ResolveCodeLens executes a codeLens/resolve request on the server.
+func (e *Editor) ResolveCodeLens(ctx context.Context, lens protocol.CodeLens) (*protocol.CodeLens, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	return e.Server.ResolveCodeLens(ctx, &lens)
+}
+
 // Completion executes a completion request on the server.
 func (e *Editor) Completion(ctx context.Context, loc protocol.Location) (*protocol.CompletionList, error) {
 	if e.Server == nil {
@@ -1122,6 +1130,24 @@ func (e *Editor) AcceptCompletion(ctx context.Context, loc protocol.Location, it
 	if e.Server == nil {
 		return nil
 	}
+	if err := e.acceptCompletionEdits(ctx, loc, item); err != nil {
+		return err
+	}
+	// As a client would, execute the command of the item, if any, once its
+	// edits are applied. The command may call back to apply edits, so the
+	// editor must not be locked.
+	if item.Command != nil {
+		if _, err := e.ExecuteCommand(ctx, &protocol.ExecuteCommandParams{
+			Command:   item.Command.Command,
+			Arguments: item.Command.Arguments,
+		}); err != nil {
+			return fmt.Errorf("executing completion command: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *Editor) acceptCompletionEdits(ctx context.Context, loc protocol.Location, item protocol.CompletionItem) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	path := e.sandbox.Workdir.URIToPath(loc.URI)
@@ -1262,6 +1288,10 @@ func (e *Editor) SignatureHelp(ctx context.Context, loc protocol.Location) (*pro
 }
 
 func (e *Editor) RenameFile(ctx context.Context, oldPath, newPath string) error {
+	if err := e.willRenameFile(ctx, oldPath, newPath); err != nil {
+		return err
+	}
+
 	closed, opened, err := e.renameBuffers(ctx, oldPath, newPath)
 	if err != nil {
 		return err
@@ -1285,6 +1315,39 @@ func (e *Editor) RenameFile(ctx context.Context, oldPath, newPath string) error
 	return nil
 }
 
+// willRenameFile sends a workspace/willRenameFiles request for the renaming
+// of oldPath->newPath, if the server is interested, and applies the
+// resulting edits.
+func (e *Editor) willRenameFile(ctx context.Context, oldPath, newPath string) error {
+	if e.Server == nil {
+		return nil
+	}
+	e.mu.Lock()
+	workspace := e.serverCapabilities.Workspace
+	e.mu.Unlock()
+	if workspace == nil || workspace.FileOperations == nil || workspace.FileOperations.WillRename == nil {
+		return nil
+	}
+	edit, err := e.Server.WillRenameFiles(ctx, &protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{
+			OldURI: string(e.sandbox.Workdir.URI(oldPath)),
+			NewURI: string(e.sandbox.Workdir.URI(newPath)),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("willRenameFiles: %w", err)
+	}
+	if edit == nil {
+		return nil
+	}
+	for _, change := range edit.DocumentChanges {
+		if err := e.applyDocumentChange(ctx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // renameBuffers renames in-memory buffers affected by the renaming of
 // oldPath->newPath, returning the resulting text documents that must be closed
 // and opened over the LSP.
@@ -145,11 +145,12 @@ See https://github.com/golang/go/issues/45732 for more information.`,
 		return nil, err
 	}
 
+	folderPattern := protocol.FolderPattern
 	return &protocol.InitializeResult{
 		Capabilities: protocol.ServerCapabilities{
 			CallHierarchyProvider: &protocol.Or_ServerCapabilities_callHierarchyProvider{Value: true},
 			CodeActionProvider:    codeActionProvider,
-			CodeLensProvider:      &protocol.CodeLensOptions{}, // must be non-nil to enable the code lens capability
+			CodeLensProvider:      &protocol.CodeLensOptions{ResolveProvider: true},
 			CompletionProvider: &protocol.CompletionOptions{
 				TriggerCharacters: []string{"."},
 			},
@@ -193,6 +194,15 @@ See https://github.com/golang/go/issues/45732 for more information.`,
 					Supported:           true,
 					ChangeNotifications: "workspace/didChangeWorkspaceFolders",
 				},
+				FileOperations: &protocol.FileOperationOptions{
+					// Renaming a directory may require updating go.work.
+					WillRename: &protocol.FileOperationRegistrationOptions{
+						Filters: []protocol.FileOperationFilter{{
+							Scheme:  "file",
+							Pattern: protocol.FileOperationPattern{Glob: "**", Matches: &folderPattern},
+						}},
+					},
+				},
 			},
 		},
 		ServerInfo: &protocol.PServerInfoMsg_initialize{
@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/cache"
 	"golang.org/x/tools/gopls/internal/lsp/mod"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/gopls/internal/lsp/source"
@@ -36,7 +37,13 @@ func (s *server) Hover(ctx context.Context, params *protocol.HoverParams) (_ *pr
 	case file.Mod:
 		return mod.Hover(ctx, snapshot, fh, params.Position)
 	case file.Go:
-		return source.Hover(ctx, snapshot, fh, params.Position)
+		hover, err := source.Hover(ctx, snapshot, fh, params.Position)
+		if err != nil || hover == nil {
+			return hover, err
+		}
+		s.addCoverageHover(ctx, snapshot, fh, params.Position, hover)
+		s.addGCDetailsHover(snapshot, fh, hover)
+		return hover, nil
 	case file.Tmpl:
 		return template.Hover(ctx, snapshot, fh, params.Position)
 	case file.Work:
@@ -44,3 +51,34 @@ func (s *server) Hover(ctx context.Context, params *protocol.HoverParams) (_ *pr
 	}
 	return nil, nil
 }
+
+// addCoverageHover appends the test coverage of the function declared at
+// pos, if any, to the hover.
+func (s *server) addCoverageHover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pos protocol.Position, hover *protocol.Hover) {
+	s.coverageMu.Lock()
+	fc := s.coverage[fh.URI()]
+	s.coverageMu.Unlock()
+	if fc == nil || fc.Hash != fh.Identity().Hash {
+		return
+	}
+	text, err := source.FuncCoverageHover(ctx, snapshot, fh, pos, fc)
+	if err != nil {
+		event.Error(ctx, "computing function coverage", err, tag.URI.Of(fh.URI()))
+		return
+	}
+	if text != "" {
+		hover.Contents.Value += "\n\n" + text
+	}
+}
+
+// addGCDetailsHover appends the explanation of the optimization details
+// reported within the range of the hover, if any, to the hover.
+func (s *server) addGCDetailsHover(snapshot *cache.Snapshot, fh file.Handle, hover *protocol.Hover) {
+	fd := s.gcDetailsForFile(fh)
+	if fd == nil {
+		return
+	}
+	if text := source.GCDetailsHover(fd, hover.Range, snapshot.Options()); text != "" {
+		hover.Contents.Value += "\n\n" + text
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/cache"
 	"golang.org/x/tools/gopls/internal/lsp/mod"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/gopls/internal/lsp/source"
@@ -28,7 +29,42 @@ func (s *server) InlayHint(ctx context.Context, params *protocol.InlayHintParams
 	case file.Mod:
 		return mod.InlayHint(ctx, snapshot, fh, params.Range)
 	case file.Go:
-		return source.InlayHint(ctx, snapshot, fh, params.Range)
+		hints, err := source.InlayHint(ctx, snapshot, fh, params.Range)
+		if err != nil {
+			return nil, err
+		}
+		if fd := s.gcDetailsForFile(fh); fd != nil {
+			hints = append(hints, source.GCDetailsInlayHints(fd, params.Range, snapshot.Options())...)
+		}
+		return hints, nil
 	}
 	return nil, nil
 }
+
+// gcDetailsForFile returns the optimization details of the file, if they
+// have been requested and computed for its current content.
+func (s *server) gcDetailsForFile(fh file.Handle) *source.FileGCDetails {
+	s.gcOptimizationDetailsMu.Lock()
+	defer s.gcOptimizationDetailsMu.Unlock()
+	for _, pd := range s.gcOptimizationDetails {
+		if pd == nil {
+			continue
+		}
+		if fd := pd.Files[fh.URI()]; fd != nil && fd.Hash == fh.Identity().Hash {
+			return fd
+		}
+	}
+	return nil
+}
+
+// refreshInlayHints asks the client to request inlay hints again, if the
+// client supports inlay hints and such requests.
+func (s *server) refreshInlayHints(ctx context.Context, snapshot *cache.Snapshot) {
+	options := snapshot.Options()
+	if !options.InlayHintSupported || !options.InlayHintRefreshSupported {
+		return
+	}
+	if err := s.client.InlayHintRefresh(ctx); err != nil {
+		event.Error(ctx, "refreshing inlay hints", err)
+	}
+}
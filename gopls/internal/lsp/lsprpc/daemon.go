@@ -0,0 +1,277 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsprpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/tools/gopls/internal/lsp/cache"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// This file implements the management of the sessions of a gopls daemon:
+// authentication of clients by token, shutdown of idle sessions, and
+// shutdown of sessions on request.
+
+// DaemonOptions configures access control and resource management for the
+// sessions of a StreamServer.
+type DaemonOptions struct {
+	// Tokens maps each token that a client may present in its handshake to
+	// the name of the client. If it is non-empty, connections must
+	// authenticate with one of the tokens before sending any other message,
+	// and may list and kill only the sessions authenticated with the same
+	// token.
+	Tokens map[string]string
+
+	// IdleTimeout is the duration after which a session that has received
+	// no messages is shut down. If it is zero, idle sessions are kept.
+	IdleTimeout time.Duration
+}
+
+// ReadTokens reads the tokens that clients of a daemon may authenticate
+// with from the named file.
+//
+// Each non-blank line of the file that does not start with '#' holds a
+// token, optionally followed by the name of the client that uses it. The
+// name defaults to the line number.
+func ReadTokens(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		name := fmt.Sprintf("client%d", line)
+		if len(fields) > 1 {
+			name = strings.Join(fields[1:], " ")
+		}
+		if _, ok := tokens[fields[0]]; ok {
+			return nil, fmt.Errorf("%s:%d: duplicate token", filename, line)
+		}
+		tokens[fields[0]] = name
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s: no tokens", filename)
+	}
+	return tokens, nil
+}
+
+// SetDaemonOptions configures the management of the sessions of s.
+// It must be called before s serves any stream.
+func (s *StreamServer) SetDaemonOptions(opts DaemonOptions) {
+	s.daemonOptions = opts
+}
+
+// A daemonSession holds the state of a session served by a StreamServer.
+type daemonSession struct {
+	session *cache.Session
+	conn    jsonrpc2.Conn
+
+	lastActivity int64 // atomic; UnixNano time of the last message received
+
+	mu            sync.Mutex
+	authenticated bool
+	token         string // the token the client authenticated with, if any
+	client        string // name of the client's token, if any
+}
+
+// touch records activity on the session.
+func (ds *daemonSession) touch() {
+	atomic.StoreInt64(&ds.lastActivity, time.Now().UnixNano())
+}
+
+func (ds *daemonSession) idleSince() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&ds.lastActivity))
+}
+
+func (ds *daemonSession) isAuthenticated() bool {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.authenticated
+}
+
+func (ds *daemonSession) credentials() (token, client string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.token, ds.client
+}
+
+// manages reports whether the client of ds may list and kill the other
+// session: that is, whether both authenticated with the same token, or
+// the daemon does not authenticate clients.
+func (ds *daemonSession) manages(other *daemonSession) bool {
+	token, _ := ds.credentials()
+	otherToken, _ := other.credentials()
+	return token == otherToken
+}
+
+// addSession registers a session served on conn.
+func (s *StreamServer) addSession(session *cache.Session, conn jsonrpc2.Conn) *daemonSession {
+	ds := &daemonSession{
+		session:       session,
+		conn:          conn,
+		authenticated: len(s.daemonOptions.Tokens) == 0,
+	}
+	ds.touch()
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[string]*daemonSession)
+	}
+	s.sessions[session.ID()] = ds
+	return ds
+}
+
+func (s *StreamServer) dropSession(ds *daemonSession) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	delete(s.sessions, ds.session.ID())
+}
+
+// managedSessions returns the current sessions that the client of ds may
+// manage, ordered by ID.
+func (s *StreamServer) managedSessions(ds *daemonSession) []*daemonSession {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	sessions := make([]*daemonSession, 0, len(s.sessions))
+	for _, other := range s.sessions {
+		if ds.manages(other) {
+			sessions = append(sessions, other)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].session.ID() < sessions[j].session.ID()
+	})
+	return sessions
+}
+
+// authenticate authenticates ds with the token, reporting whether it is
+// valid.
+func (s *StreamServer) authenticate(ds *daemonSession, token string) bool {
+	tokens := s.daemonOptions.Tokens
+	if len(tokens) == 0 {
+		return true
+	}
+	// Compare with all tokens, in constant time, so as not to reveal which
+	// prefixes are valid.
+	var client string
+	found := false
+	for t, name := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			client = name
+			found = true
+		}
+	}
+	if found {
+		ds.mu.Lock()
+		ds.authenticated = true
+		ds.token = token
+		ds.client = client
+		ds.mu.Unlock()
+	}
+	return found
+}
+
+// killSession shuts down the session with the given ID, by closing its
+// connection, on behalf of the client of the caller session. Sessions the
+// caller may not manage are reported as not existing.
+func (s *StreamServer) killSession(caller *daemonSession, id string) error {
+	s.sessionsMu.Lock()
+	ds, ok := s.sessions[id]
+	s.sessionsMu.Unlock()
+	if !ok || !caller.manages(ds) {
+		return fmt.Errorf("no session %q", id)
+	}
+	if s.daemon {
+		log.Printf("Session %s: killed on request of session %s", id, caller.session.ID())
+	}
+	return ds.conn.Close()
+}
+
+// evictWhenIdle shuts down the session when it has been idle for the
+// configured timeout, after telling the client why. It returns when the
+// session's connection is closed.
+func (s *StreamServer) evictWhenIdle(ctx context.Context, ds *daemonSession, client protocol.Client) {
+	timeout := s.daemonOptions.IdleTimeout
+	interval := timeout / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ds.conn.Done():
+			return
+		case <-ticker.C:
+		}
+		idle := time.Since(ds.idleSince())
+		if idle < timeout {
+			continue
+		}
+		if s.daemon {
+			log.Printf("Session %s: idle for %v, shutting down", ds.session.ID(), idle.Round(time.Second))
+		}
+		msg := fmt.Sprintf("The gopls daemon shut down this session, which had no activity for %v.", timeout)
+		client.ShowMessage(ctx, &protocol.ShowMessageParams{Type: protocol.Info, Message: msg})
+		ds.conn.Close()
+		return
+	}
+}
+
+// Authenticate performs the handshake with a gopls daemon over conn,
+// presenting the token if it is non-empty.
+func Authenticate(ctx context.Context, conn jsonrpc2.Conn, token string) error {
+	goplsPath, err := os.Executable()
+	if err != nil {
+		goplsPath = ""
+	}
+	req := handshakeRequest{GoplsPath: goplsPath, Token: token}
+	var resp handshakeResponse
+	if err := protocol.Call(ctx, conn, handshakeMethod, req, &resp); err != nil {
+		return fmt.Errorf("gopls daemon handshake: %w", err)
+	}
+	return nil
+}
+
+// KillSession asks the gopls daemon at addr to shut down the session with
+// the given ID.
+func KillSession(ctx context.Context, addr, token, id string) error {
+	serverConn, err := dialRemote(ctx, addr, token)
+	if err != nil {
+		return err
+	}
+	defer serverConn.Close()
+	params := killSessionParams{SessionID: id}
+	if err := protocol.Call(ctx, serverConn, killSessionMethod, params, nil); err != nil {
+		return fmt.Errorf("killing session %s: %w", id, err)
+	}
+	return nil
+}
+
+type killSessionParams struct {
+	SessionID string `json:"sessionID"`
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsprpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/gopls/internal/lsp/cache"
+	"golang.org/x/tools/gopls/internal/lsp/debug"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/internal/jsonrpc2"
+	"golang.org/x/tools/internal/jsonrpc2/servertest"
+)
+
+func TestReadTokens(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tokens")
+	const content = `
+# tokens for the team
+s3cret alice
+t0ken
+`
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTokens(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"s3cret": "alice", "t0ken": "client4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadTokens() = %v, want %v", got, want)
+	}
+}
+
+func TestDaemonSessions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serveCtx := debug.WithInstance(ctx, "", "")
+	ss := NewStreamServer(cache.New(nil), true, nil)
+	ss.serverForTest = fakeServer{}
+	ss.SetDaemonOptions(DaemonOptions{Tokens: map[string]string{"s3cret": "alice", "b0b": "bob"}})
+	ts := servertest.NewTCPServer(serveCtx, ss, nil)
+	defer checkClose(t, ts.Close)
+	addr := "tcp;" + ts.Addr
+
+	if _, err := QueryServerState(ctx, addr, ""); err == nil {
+		t.Error("QueryServerState without a token succeeded unexpectedly")
+	}
+	if _, err := QueryServerState(ctx, addr, "wrong"); err == nil {
+		t.Error("QueryServerState with an invalid token succeeded unexpectedly")
+	}
+
+	// Connect a client, which will be killed.
+	victim, err := dialRemote(ctx, addr, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Connect a client of bob, whom alice may neither see nor kill.
+	bystander, err := dialRemote(ctx, addr, "b0b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bystander.Close()
+	bobState, err := QueryServerState(ctx, addr, "b0b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bystanderID string
+	for _, c := range bobState.Clients {
+		if c.Client != "bob" {
+			t.Errorf("bob sees session %s of client %q", c.SessionID, c.Client)
+		}
+		if c.SessionID != bobState.CurrentClientID {
+			bystanderID = c.SessionID
+		}
+	}
+	if bystanderID == "" {
+		t.Fatalf("got sessions %+v, want the bystander and the current session of client bob", bobState.Clients)
+	}
+	state, err := QueryServerState(ctx, addr, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sessions that failed to authenticate may not have exited yet, but
+	// have no client name.
+	var victimID, currentClient string
+	for _, c := range state.Clients {
+		if c.Client == "bob" {
+			t.Errorf("alice sees session %s of client bob", c.SessionID)
+		}
+		if c.SessionID == state.CurrentClientID {
+			currentClient = c.Client
+		} else if c.Client == "alice" {
+			victimID = c.SessionID
+		}
+	}
+	if currentClient != "alice" || victimID == "" {
+		t.Fatalf("got sessions %+v, want the victim and the current session of client alice", state.Clients)
+	}
+
+	if err := KillSession(ctx, addr, "s3cret", bystanderID); err == nil {
+		t.Error("alice killed a session of bob")
+	}
+	select {
+	case <-bystander.Done():
+		t.Error("session of bob disconnected after alice tried to kill it")
+	default:
+	}
+
+	if err := KillSession(ctx, addr, "s3cret", victimID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-victim.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for killed session to disconnect")
+	}
+	if err := KillSession(ctx, addr, "s3cret", victimID); err == nil {
+		t.Error("killing a session twice succeeded unexpectedly")
+	}
+}
+
+type messageClient struct {
+	protocol.Client
+
+	messages chan string
+}
+
+func (c messageClient) ShowMessage(ctx context.Context, params *protocol.ShowMessageParams) error {
+	c.messages <- params.Message
+	return nil
+}
+
+func TestIdleSessionEviction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ss := NewStreamServer(cache.New(nil), false, nil)
+	ss.serverForTest = fakeServer{}
+	ss.SetDaemonOptions(DaemonOptions{IdleTimeout: 50 * time.Millisecond})
+	ts := servertest.NewPipeServer(ss, nil)
+	defer checkClose(t, ts.Close)
+
+	client := messageClient{messages: make(chan string, 1)}
+	cc := ts.Connect(ctx)
+	cc.Go(ctx, protocol.ClientHandler(client, jsonrpc2.MethodNotFound))
+
+	select {
+	case <-cc.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for idle session to be shut down")
+	}
+	select {
+	case <-client.messages:
+	default:
+		t.Error("client was not told why its session was shut down")
+	}
+}
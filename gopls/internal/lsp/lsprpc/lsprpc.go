@@ -45,6 +45,12 @@ type StreamServer struct {
 
 	// serverForTest may be set to a test fake for testing.
 	serverForTest protocol.Server
+
+	// daemonOptions configures the management of sessions.
+	daemonOptions DaemonOptions
+
+	sessionsMu sync.Mutex
+	sessions   map[string]*daemonSession // by session ID
 }
 
 // NewStreamServer creates a StreamServer using the shared cache. If
@@ -97,11 +103,16 @@ func (s *StreamServer) ServeStream(ctx context.Context, conn jsonrpc2.Conn) erro
 		executable = ""
 	}
 	ctx = protocol.WithClient(ctx, client)
+	ds := s.addSession(session, conn)
+	defer s.dropSession(ds)
 	conn.Go(ctx,
 		protocol.Handlers(
-			handshaker(session, executable, s.daemon,
+			s.handshaker(ds, executable,
 				protocol.ServerHandler(server,
 					jsonrpc2.MethodNotFound))))
+	if s.daemonOptions.IdleTimeout > 0 {
+		go s.evictWhenIdle(ctx, ds, client)
+	}
 	if s.daemon {
 		log.Printf("Session %s: connected", session.ID())
 		defer log.Printf("Session %s: exited", session.ID())
@@ -124,29 +135,36 @@ type Forwarder struct {
 	// information changes.
 	serverConn jsonrpc2.Conn
 	serverID   string
+
+	// token authenticates the forwarder to the remote, if it is non-empty.
+	token string
 }
 
 // NewForwarder creates a new Forwarder, ready to forward connections to the
-// remote server specified by rawAddr. If provided and rawAddr indicates an
-// 'automatic' address (starting with 'auto;'), argFunc may be used to start a
-// remote server for the auto-discovered address.
-func NewForwarder(rawAddr string, argFunc func(network, address string) []string) (*Forwarder, error) {
+// remote server specified by rawAddr, authenticating with token if it is
+// non-empty. If provided and rawAddr indicates an 'automatic' address
+// (starting with 'auto;'), argFunc may be used to start a remote server for
+// the auto-discovered address.
+func NewForwarder(rawAddr, token string, argFunc func(network, address string) []string) (*Forwarder, error) {
 	dialer, err := NewAutoDialer(rawAddr, argFunc)
 	if err != nil {
 		return nil, err
 	}
 	fwd := &Forwarder{
 		dialer: dialer,
+		token:  token,
 	}
 	return fwd, nil
 }
 
-// QueryServerState queries the server state of the current server.
-func QueryServerState(ctx context.Context, addr string) (*ServerState, error) {
-	serverConn, err := dialRemote(ctx, addr)
+// QueryServerState queries the server state of the current server,
+// authenticating with token if it is non-empty.
+func QueryServerState(ctx context.Context, addr, token string) (*ServerState, error) {
+	serverConn, err := dialRemote(ctx, addr, token)
 	if err != nil {
 		return nil, err
 	}
+	defer serverConn.Close()
 	var state ServerState
 	if err := protocol.Call(ctx, serverConn, sessionsMethod, nil, &state); err != nil {
 		return nil, fmt.Errorf("querying server state: %w", err)
@@ -156,8 +174,9 @@ func QueryServerState(ctx context.Context, addr string) (*ServerState, error) {
 
 // dialRemote is used for making calls into the gopls daemon. addr should be a
 // URL, possibly on the synthetic 'auto' network (e.g. tcp://..., unix://...,
-// or auto://...).
-func dialRemote(ctx context.Context, addr string) (jsonrpc2.Conn, error) {
+// or auto://...). If token is non-empty, the connection is authenticated
+// with it.
+func dialRemote(ctx context.Context, addr, token string) (jsonrpc2.Conn, error) {
 	network, address := ParseAddr(addr)
 	if network == AutoNetwork {
 		gp, err := os.Executable()
@@ -172,14 +191,21 @@ func dialRemote(ctx context.Context, addr string) (jsonrpc2.Conn, error) {
 	}
 	serverConn := jsonrpc2.NewConn(jsonrpc2.NewHeaderStream(netConn))
 	serverConn.Go(ctx, jsonrpc2.MethodNotFound)
+	if token != "" {
+		if err := Authenticate(ctx, serverConn, token); err != nil {
+			serverConn.Close()
+			return nil, err
+		}
+	}
 	return serverConn, nil
 }
 
-func ExecuteCommand(ctx context.Context, addr string, id string, request, result interface{}) error {
-	serverConn, err := dialRemote(ctx, addr)
+func ExecuteCommand(ctx context.Context, addr, token string, id string, request, result interface{}) error {
+	serverConn, err := dialRemote(ctx, addr, token)
 	if err != nil {
 		return err
 	}
+	defer serverConn.Close()
 	args, err := command.MarshalArgs(request)
 	if err != nil {
 		return err
@@ -255,6 +281,7 @@ func (f *Forwarder) handshake(ctx context.Context) {
 		hreq = handshakeRequest{
 			ServerID:  f.serverID,
 			GoplsPath: goplsPath,
+			Token:     f.token,
 		}
 		hresp handshakeResponse
 	)
@@ -424,6 +451,8 @@ type handshakeRequest struct {
 	// GoplsPath is the path to the Gopls binary running the current client
 	// process.
 	GoplsPath string `json:"goplsPath"`
+	// Token authenticates the client, if the server requires it.
+	Token string `json:"token,omitempty"`
 }
 
 // A handshakeResponse is returned by the LSP server to tell the LSP client
@@ -447,11 +476,18 @@ type ClientSession struct {
 	SessionID string `json:"sessionID"`
 	Logfile   string `json:"logfile"`
 	DebugAddr string `json:"debugAddr"`
+	// Client is the name of the token with which the client authenticated,
+	// if any.
+	Client string `json:"client,omitempty"`
+	// LastActivity is the time at which the session last received a message.
+	LastActivity time.Time `json:"lastActivity"`
+	// Usage estimates the resources held by the session.
+	Usage cache.SessionUsage `json:"usage"`
 }
 
 // ServerState holds information about the gopls daemon process, including its
-// debug information and debug information of all of its current connected
-// clients.
+// debug information and debug information of its current connected clients
+// that the requesting client may manage (see DaemonOptions.Tokens).
 type ServerState struct {
 	Logfile         string          `json:"logfile"`
 	DebugAddr       string          `json:"debugAddr"`
@@ -461,12 +497,25 @@ type ServerState struct {
 }
 
 const (
-	handshakeMethod = "gopls/handshake"
-	sessionsMethod  = "gopls/sessions"
+	handshakeMethod   = "gopls/handshake"
+	sessionsMethod    = "gopls/sessions"
+	killSessionMethod = "gopls/killSession"
 )
 
-func handshaker(session *cache.Session, goplsPath string, logHandshakes bool, handler jsonrpc2.Handler) jsonrpc2.Handler {
+// errUnauthenticated is returned for messages on connections that have
+// not authenticated with a valid token, when the server requires it.
+var errUnauthenticated = fmt.Errorf("gopls daemon requires authentication (see -remote.token): %w", jsonrpc2.ErrInvalidRequest)
+
+func (s *StreamServer) handshaker(ds *daemonSession, goplsPath string, handler jsonrpc2.Handler) jsonrpc2.Handler {
+	session, logHandshakes := ds.session, s.daemon
 	return func(ctx context.Context, reply jsonrpc2.Replier, r jsonrpc2.Request) error {
+		ds.touch()
+		if r.Method() != handshakeMethod && !ds.isAuthenticated() {
+			if logHandshakes {
+				log.Printf("Session %s: rejected unauthenticated %s message", session.ID(), r.Method())
+			}
+			return reply(ctx, nil, errUnauthenticated)
+		}
 		switch r.Method() {
 		case handshakeMethod:
 			// We log.Printf in this handler, rather than event.Log when we want logs
@@ -480,6 +529,12 @@ func handshaker(session *cache.Session, goplsPath string, logHandshakes bool, ha
 				sendError(ctx, reply, err)
 				return nil
 			}
+			if !s.authenticate(ds, req.Token) {
+				if logHandshakes {
+					log.Printf("Session %s: handshake with invalid token", session.ID())
+				}
+				return reply(ctx, nil, fmt.Errorf("invalid token: %w", jsonrpc2.ErrInvalidRequest))
+			}
 			if logHandshakes {
 				log.Printf("Session %s: got handshake. Logfile: %q, Debug addr: %q", session.ID(), req.Logfile, req.DebugAddr)
 			}
@@ -505,18 +560,36 @@ func handshaker(session *cache.Session, goplsPath string, logHandshakes bool, ha
 				GoplsPath:       goplsPath,
 				CurrentClientID: session.ID(),
 			}
-			if di := debug.GetInstance(ctx); di != nil {
+			di := debug.GetInstance(ctx)
+			if di != nil {
 				resp.Logfile = di.Logfile
 				resp.DebugAddr = di.ListenedDebugAddress()
-				for _, c := range di.State.Clients() {
-					resp.Clients = append(resp.Clients, ClientSession{
-						SessionID: c.Session.ID(),
-						Logfile:   c.Logfile,
-						DebugAddr: c.DebugAddress,
-					})
+			}
+			for _, other := range s.managedSessions(ds) {
+				_, client := other.credentials()
+				c := ClientSession{
+					SessionID:    other.session.ID(),
+					Client:       client,
+					LastActivity: other.idleSince(),
+					Usage:        other.session.Usage(),
+				}
+				if di != nil {
+					if dc := di.State.Client(c.SessionID); dc != nil {
+						c.Logfile = dc.Logfile
+						c.DebugAddr = dc.DebugAddress
+					}
 				}
+				resp.Clients = append(resp.Clients, c)
 			}
 			return reply(ctx, resp, nil)
+
+		case killSessionMethod:
+			var params killSessionParams
+			if err := json.Unmarshal(r.Params(), &params); err != nil {
+				sendError(ctx, reply, err)
+				return nil
+			}
+			return reply(ctx, nil, s.killSession(ds, params.SessionID))
 		}
 		return handler(ctx, reply, r)
 	}
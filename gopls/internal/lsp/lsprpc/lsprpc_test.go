@@ -126,7 +126,7 @@ func setupForwarding(ctx context.Context, t *testing.T, s protocol.Server) (dire
 	ss.serverForTest = s
 	tsDirect := servertest.NewTCPServer(serveCtx, ss, nil)
 
-	forwarder, err := NewForwarder("tcp;"+tsDirect.Addr, nil)
+	forwarder, err := NewForwarder("tcp;"+tsDirect.Addr, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -221,7 +221,7 @@ func TestDebugInfoLifecycle(t *testing.T) {
 	ss := NewStreamServer(cache, false, nil)
 	tsBackend := servertest.NewTCPServer(serverCtx, ss, nil)
 
-	forwarder, err := NewForwarder("tcp;"+tsBackend.Addr, nil)
+	forwarder, err := NewForwarder("tcp;"+tsBackend.Addr, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -106,6 +106,12 @@ func hoverOnRequireStatement(ctx context.Context, pm *source.ParsedModule, offse
 	if !ok {
 		return nil, nil
 	}
+	// Get the modules that require it, from `go mod graph`. This is
+	// supplementary, so a failure only omits it from the hover.
+	graph, err := snapshot.ModGraph(ctx, fh)
+	if err != nil {
+		event.Error(ctx, fmt.Sprintf("hover: computing module graph of %s", fh.URI()), err)
+	}
 
 	// Get the range to highlight for the hover.
 	// TODO(hyangah): adjust the hover range to include the version number
@@ -119,11 +125,12 @@ func hoverOnRequireStatement(ctx context.Context, pm *source.ParsedModule, offse
 	header := formatHeader(req.Mod.Path, options)
 	explanation = formatExplanation(explanation, req, options, isPrivate)
 	vulns := formatVulnerabilities(affecting, nonaffecting, osvs, options, fromGovulncheck)
+	requiredBy := formatRequiredBy(graph[req.Mod.Path], options.PreferredContentFormat == protocol.Markdown)
 
 	return &protocol.Hover{
 		Contents: protocol.MarkupContent{
 			Kind:  options.PreferredContentFormat,
-			Value: header + vulns + explanation,
+			Value: header + vulns + explanation + requiredBy,
 		},
 		Range: rng,
 	}, nil
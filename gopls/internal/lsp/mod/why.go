@@ -0,0 +1,154 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/cache"
+	"golang.org/x/tools/gopls/internal/lsp/command"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/source"
+)
+
+// ModuleWhy reports why the module with the given path is required by
+// the go.mod file fh.
+func ModuleWhy(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, modpath string) (command.ModuleWhyResult, error) {
+	var result command.ModuleWhyResult
+	why, err := snapshot.ModWhy(ctx, fh)
+	if err != nil {
+		return result, err
+	}
+	explanation, ok := why[modpath]
+	if !ok {
+		return result, fmt.Errorf("%s is not required by %s", modpath, fh.URI().Path())
+	}
+	graph, err := snapshot.ModGraph(ctx, fh)
+	if err != nil {
+		return result, err
+	}
+	result.ImportChain = importChain(explanation)
+	result.RequiredBy = graph[modpath]
+	return result, nil
+}
+
+// importChain returns the chain of import paths of a "go mod why -m"
+// explanation, or nil if the main module does not need the module.
+//
+// An explanation is of one of these forms:
+//
+//	# golang.org/x/text
+//	(main module does not need module golang.org/x/text)
+//
+//	# golang.org/x/text
+//	example.com/m
+//	rsc.io/quote
+//	golang.org/x/text/language
+func importChain(explanation string) []string {
+	lines := strings.Split(strings.TrimSpace(explanation), "\n")
+	if len(lines) < 3 || strings.HasPrefix(lines[1], "(") {
+		return nil
+	}
+	return lines[1:]
+}
+
+// RequireQuickFixes returns quick fixes for the require statements of
+// the go.mod file within the given range, based on why each module is
+// required: removing modules that the main module does not need, and
+// correcting "// indirect" comments.
+func RequireQuickFixes(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]source.SuggestedFix, error) {
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil || pm.File == nil || pm.File.Module == nil {
+		return nil, err
+	}
+	start, end, err := pm.Mapper.RangeOffsets(rng)
+	if err != nil {
+		return nil, err
+	}
+	var reqs []*modfile.Require
+	for _, req := range pm.File.Require {
+		if req.Syntax.Start.Byte <= end && start <= req.Syntax.End.Byte {
+			reqs = append(reqs, req)
+		}
+	}
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	why, err := snapshot.ModWhy(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixes []source.SuggestedFix
+	for _, req := range reqs {
+		explanation, ok := why[req.Mod.Path]
+		if !ok {
+			continue
+		}
+		chain := importChain(explanation)
+		if chain == nil {
+			// Keep the same title as the tidy diagnostic's fix, so that
+			// the two are deduplicated.
+			title := fmt.Sprintf("Remove dependency: %s", req.Mod.Path)
+			cmd, err := command.NewRemoveDependencyCommand(title, command.RemoveDependencyArgs{
+				URI:        fh.URI(),
+				ModulePath: req.Mod.Path,
+			})
+			if err != nil {
+				return nil, err
+			}
+			fixes = append(fixes, cache.SuggestedFixFromCommand(cmd, protocol.QuickFix))
+			continue
+		}
+		// The module is needed directly if a package of the main module
+		// imports one of its packages.
+		importer := chain[len(chain)-2]
+		direct := inModule(importer, pm.File.Module.Mod.Path)
+		if direct != req.Indirect {
+			continue // correctly marked
+		}
+		direction := "indirect"
+		if direct {
+			direction = "direct"
+		}
+		edits, err := cache.SwitchDirectness(req, pm.Mapper, snapshot.Options().ComputeEdits)
+		if err != nil {
+			return nil, err
+		}
+		fixes = append(fixes, source.SuggestedFix{
+			Title:      fmt.Sprintf("Change %s to %s", req.Mod.Path, direction),
+			Edits:      map[protocol.DocumentURI][]protocol.TextEdit{fh.URI(): edits},
+			ActionKind: protocol.QuickFix,
+		})
+	}
+	return fixes, nil
+}
+
+// inModule reports whether the package path belongs to the module path.
+func inModule(pkgPath, modPath string) bool {
+	return pkgPath == modPath || strings.HasPrefix(pkgPath, modPath+"/")
+}
+
+// formatRequiredBy formats the list of modules that require a module,
+// for display in a hover.
+func formatRequiredBy(requiredBy []string, markdown bool) string {
+	if len(requiredBy) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nRequired by:")
+	for _, m := range requiredBy {
+		if markdown {
+			fmt.Fprintf(&b, "\n- `%s`", m)
+		} else {
+			fmt.Fprintf(&b, "\n  - %s", m)
+		}
+	}
+	return b.String()
+}
@@ -74,6 +74,14 @@ func ClientName(name string) RunOption {
 	})
 }
 
+// CapabilitiesJSON sets JSON client capabilities to overlay over the
+// editor's default client capabilities.
+func CapabilitiesJSON(capabilities []byte) RunOption {
+	return optionSetter(func(opts *runConfig) {
+		opts.editor.CapabilitiesJSON = capabilities
+	})
+}
+
 // Settings sets user-provided configuration for the LSP server.
 //
 // As a special case, the env setting must not be provided via Settings: use
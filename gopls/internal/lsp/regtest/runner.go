@@ -404,7 +404,7 @@ func (r *Runner) separateProcessServer(optsHook func(*settings.Options)) jsonrpc
 }
 
 func newForwarder(network, address string) *lsprpc.Forwarder {
-	server, err := lsprpc.NewForwarder(network+";"+address, nil)
+	server, err := lsprpc.NewForwarder(network+";"+address, "", nil)
 	if err != nil {
 		// This should never happen, as we are passing an explicit address.
 		panic(fmt.Sprintf("internal error: unable to create forwarder: %v", err))
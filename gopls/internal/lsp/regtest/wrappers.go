@@ -347,6 +347,17 @@ func (e *Env) CodeLens(path string) []protocol.CodeLens {
 	return lens
 }
 
+// ResolveCodeLens resolves the command of an unresolved code lens, calling
+// t.Fatal on any error.
+func (e *Env) ResolveCodeLens(lens protocol.CodeLens) protocol.CodeLens {
+	e.T.Helper()
+	resolved, err := e.Editor.ResolveCodeLens(e.Ctx, lens)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return *resolved
+}
+
 // ExecuteCodeLensCommand executes the command for the code lens matching the
 // given command name.
 func (e *Env) ExecuteCodeLensCommand(path string, cmd command.Command, result interface{}) {
@@ -355,7 +366,7 @@ func (e *Env) ExecuteCodeLensCommand(path string, cmd command.Command, result in
 	var lens protocol.CodeLens
 	var found bool
 	for _, l := range lenses {
-		if l.Command.Command == cmd.ID() {
+		if l.Command != nil && l.Command.Command == cmd.ID() {
 			lens = l
 			found = true
 		}
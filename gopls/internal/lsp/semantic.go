@@ -176,8 +176,10 @@ const (
 	tokString    tokenType = "string"
 	tokNumber    tokenType = "number"
 	tokOperator  tokenType = "operator"
+	tokProperty  tokenType = "property"
+	tokRegexp    tokenType = "regexp"
 
-	tokMacro tokenType = "macro" // for templates
+	tokMacro tokenType = "macro" // for templates, and directives within strings
 )
 
 func (e *encoded) token(start token.Pos, leng int, typ tokenType, mods []string) {
@@ -287,6 +289,9 @@ func (e *encoded) inspector(n ast.Node) bool {
 	case *ast.AssignStmt:
 		e.token(x.TokPos, len(x.Tok.String()), tokOperator, nil)
 	case *ast.BasicLit:
+		if x.Kind == token.STRING && e.embedded(x) {
+			break
+		}
 		if strings.Contains(x.Value, "\n") {
 			// has to be a string.
 			e.multiline(x.Pos(), x.End(), x.Value, tokString)
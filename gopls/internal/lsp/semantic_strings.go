@@ -0,0 +1,316 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/gopls/internal/lsp/analysis/regexpsyntax"
+	"golang.org/x/tools/gopls/internal/lsp/safetoken"
+	"golang.org/x/tools/internal/fmtstr"
+)
+
+// This file implements the semantic tokens of the languages embedded in
+// Go string literals: the directives of printf format strings and the
+// elements of time layouts, which are marked as macros, regular
+// expressions, and the keys of struct tags, which are marked as
+// properties. The rest of such a literal is marked as a string.
+
+// An embeddedToken is a token within the value of a string literal.
+type embeddedToken struct {
+	start, end int // byte offsets within the value of the literal
+	typ        tokenType
+}
+
+// embedded reports whether the string literal lit, the top of the
+// stack, holds an embedded language, in which case it emits the tokens of
+// the literal.
+func (e *encoded) embedded(lit *ast.BasicLit) bool {
+	if len(e.stack) < 2 {
+		return false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return false
+	}
+	var toks []embeddedToken
+	switch parent := e.stack[len(e.stack)-2].(type) {
+	case *ast.Field:
+		if parent.Tag != lit {
+			return false
+		}
+		toks = structTagTokens(value)
+	case *ast.CallExpr:
+		if e.ti == nil {
+			return false
+		}
+		fn, ok := typeutil.Callee(e.ti, parent).(*types.Func)
+		if !ok {
+			return false
+		}
+		isArg := func(i int) bool {
+			return i >= 0 && i < len(parent.Args) && parent.Args[i] == lit
+		}
+		if _, ok := regexpsyntax.PatternFuncs[fn.FullName()]; ok && isArg(0) {
+			e.span(lit.Pos(), lit.End(), tokRegexp)
+			return true
+		}
+		switch fn.FullName() {
+		case "time.Parse", "time.ParseInLocation", "(time.Time).Format":
+			if isArg(0) {
+				toks = layoutTokens(value)
+			}
+		case "(time.Time).AppendFormat":
+			if isArg(1) {
+				toks = layoutTokens(value)
+			}
+		default:
+			if isArg(printfFormatIndex(fn)) {
+				ops, _ := fmtstr.Parse(value)
+				for _, op := range ops {
+					toks = append(toks, embeddedToken{op.Start, op.End(), tokMacro})
+				}
+			}
+		}
+	}
+	if len(toks) == 0 {
+		return false
+	}
+	offsets := literalOffsets(lit.Value)
+	if len(offsets) != len(value)+1 {
+		return false
+	}
+	pos := lit.Pos()
+	for _, tok := range toks {
+		start := lit.Pos() + token.Pos(offsets[tok.start])
+		end := lit.Pos() + token.Pos(offsets[tok.end])
+		e.span(pos, start, tokString)
+		e.span(start, end, tok.typ)
+		pos = end
+	}
+	e.span(pos, lit.End(), tokString)
+	return true
+}
+
+// span emits a token of the given type for each line of the source
+// between start and end, excluding newlines.
+func (e *encoded) span(start, end token.Pos, typ tokenType) {
+	tf := e.pgf.Tok
+	for start < end {
+		stop := end
+		if line := safetoken.Line(tf, start); line < tf.LineCount() {
+			if next := tf.LineStart(line + 1); next <= end {
+				stop = next - 1 // exclude the newline
+			}
+		}
+		if stop > start {
+			e.token(start, int(stop-start), typ, nil)
+		}
+		if stop == end {
+			break
+		}
+		start = stop + 1
+	}
+}
+
+// literalOffsets returns, for each byte of the value of the string
+// literal lit, and for the end of the value, the offset within lit of the
+// source text from which it was decoded. It returns nil if lit is
+// malformed.
+func literalOffsets(lit string) []int {
+	if len(lit) < 2 {
+		return nil
+	}
+	var offsets []int
+	if lit[0] == '`' {
+		for i := 1; i < len(lit)-1; i++ {
+			if lit[i] != '\r' { // carriage returns are discarded
+				offsets = append(offsets, i)
+			}
+		}
+		return append(offsets, len(lit)-1)
+	}
+	for s := lit[1 : len(lit)-1]; len(s) > 0; {
+		offset := len(lit) - 1 - len(s)
+		value, multibyte, tail, err := strconv.UnquoteChar(s, lit[0])
+		if err != nil {
+			return nil
+		}
+		n := 1
+		if value >= utf8.RuneSelf && multibyte {
+			n = utf8.RuneLen(value)
+		}
+		for ; n > 0; n-- {
+			offsets = append(offsets, offset)
+		}
+		s = tail
+	}
+	return append(offsets, len(lit)-1)
+}
+
+// printfFormatIndex returns the index of the format parameter of fn if
+// it is printf-like: that is, if its name ends in "f" and its final
+// parameters are a string and a ...interface{}. Otherwise it returns -1.
+func printfFormatIndex(fn *types.Func) int {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || !sig.Variadic() || !strings.HasSuffix(fn.Name(), "f") {
+		return -1
+	}
+	n := sig.Params().Len()
+	if n < 2 || !types.Identical(sig.Params().At(n-2).Type(), types.Typ[types.String]) {
+		return -1
+	}
+	if s, ok := sig.Params().At(n - 1).Type().(*types.Slice); !ok || !isEmptyInterface(s.Elem()) {
+		return -1
+	}
+	return n - 2
+}
+
+func isEmptyInterface(T types.Type) bool {
+	iface, ok := T.Underlying().(*types.Interface)
+	return ok && iface.Empty()
+}
+
+// structTagTokens returns the keys of the conventional struct tag tag,
+// as in `json:"name,omitempty" xml:"name"`. It stops at the first
+// syntax error.
+func structTagTokens(tag string) []embeddedToken {
+	// This follows reflect.StructTag.Lookup.
+	var toks []embeddedToken
+	for i := 0; i < len(tag); {
+		// Skip leading space.
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		if i == len(tag) {
+			break
+		}
+		// Scan to colon. A space, a quote or a control character is a
+		// syntax error.
+		start := i
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == start || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		toks = append(toks, embeddedToken{start, i, tokProperty})
+		// Scan quoted string to find value.
+		i += 2
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		i++
+	}
+	return toks
+}
+
+// layoutTokens returns the elements of the time layout, such as "Jan"
+// or "2006".
+func layoutTokens(layout string) []embeddedToken {
+	var toks []embeddedToken
+	for i := 0; i < len(layout); {
+		if n := layoutElementLen(layout[i:]); n > 0 {
+			toks = append(toks, embeddedToken{i, i + n, tokMacro})
+			i += n
+		} else {
+			i++
+		}
+	}
+	return toks
+}
+
+// layoutElementLen returns the length of the element of a time layout at
+// the start of s, or 0 if there is none. It follows nextStdChunk in the
+// time package.
+func layoutElementLen(s string) int {
+	switch s[0] {
+	case 'J': // January, Jan
+		if strings.HasPrefix(s, "January") {
+			return 7
+		}
+		if strings.HasPrefix(s, "Jan") && !startsWithLowerCase(s[3:]) {
+			return 3
+		}
+	case 'M': // Monday, Mon, MST
+		if strings.HasPrefix(s, "Monday") {
+			return 6
+		}
+		if strings.HasPrefix(s, "Mon") && !startsWithLowerCase(s[3:]) {
+			return 3
+		}
+		if strings.HasPrefix(s, "MST") {
+			return 3
+		}
+	case '0': // 01, 02, 03, 04, 05, 06, 002
+		if len(s) >= 2 && '1' <= s[1] && s[1] <= '6' {
+			return 2
+		}
+		if strings.HasPrefix(s, "002") {
+			return 3
+		}
+	case '1': // 15, 1
+		if strings.HasPrefix(s, "15") {
+			return 2
+		}
+		return 1
+	case '2': // 2006, 2
+		if strings.HasPrefix(s, "2006") {
+			return 4
+		}
+		return 1
+	case '_': // _2, __2; _2006 is a literal _ followed by 2006
+		if strings.HasPrefix(s, "_2") && !strings.HasPrefix(s, "_2006") {
+			return 2
+		}
+		if strings.HasPrefix(s, "__2") {
+			return 3
+		}
+	case '3', '4', '5':
+		return 1
+	case 'P': // PM
+		if strings.HasPrefix(s, "PM") {
+			return 2
+		}
+	case 'p': // pm
+		if strings.HasPrefix(s, "pm") {
+			return 2
+		}
+	case '-', 'Z': // -070000, -07:00:00, -0700, -07:00, -07, and likewise Z07
+		for _, zone := range []string{"070000", "07:00:00", "0700", "07:00", "07"} {
+			if strings.HasPrefix(s[1:], zone) {
+				return 1 + len(zone)
+			}
+		}
+	case '.', ',': // .000, .999, ,000 or ,999: fractional seconds
+		if len(s) >= 2 && (s[1] == '0' || s[1] == '9') {
+			j := 1
+			for j < len(s) && s[j] == s[1] {
+				j++
+			}
+			// The digits must end here.
+			if j == len(s) || s[j] < '0' || '9' < s[j] {
+				return j
+			}
+		}
+	}
+	return 0
+}
+
+func startsWithLowerCase(s string) bool {
+	return len(s) > 0 && 'a' <= s[0] && s[0] <= 'z'
+}
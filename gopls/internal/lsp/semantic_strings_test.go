@@ -0,0 +1,98 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// tokenText returns the text of each of the tokens of s.
+func tokenText(s string, toks []embeddedToken) string {
+	var parts []string
+	for _, tok := range toks {
+		parts = append(parts, s[tok.start:tok.end])
+	}
+	return strings.Join(parts, "|")
+}
+
+func TestLayoutTokens(t *testing.T) {
+	tests := []struct {
+		layout, want string
+	}{
+		{"2006-01-02", "2006|01|02"},
+		{"15:04:05.000 MST", "15|04|05|.000|MST"},
+		{"Mon Jan _2 3:04PM", "Mon|Jan|_2|3|04|PM"},
+		{"Monday, January 2", "Monday|January|2"},
+		{"Jane at 002", "002"},
+		{"_2006 -07:00 Z0700", "2006|-07:00|Z0700"},
+		{"05.999999999 .0001", "05|.999999999|01"},
+		{"no elements", ""},
+	}
+	for _, test := range tests {
+		if got := tokenText(test.layout, layoutTokens(test.layout)); got != test.want {
+			t.Errorf("layoutTokens(%q) = %q, want %q", test.layout, got, test.want)
+		}
+	}
+}
+
+func TestStructTagTokens(t *testing.T) {
+	tests := []struct {
+		tag, want string
+	}{
+		{`json:"name,omitempty"`, "json"},
+		{`json:"a" xml:"b\"c"  yaml:"d"`, "json|xml|yaml"},
+		{`json:"a" bad tag:"b"`, "json"},
+		{`json:"unterminated`, "json"},
+		{`not a tag`, ""},
+	}
+	for _, test := range tests {
+		if got := tokenText(test.tag, structTagTokens(test.tag)); got != test.want {
+			t.Errorf("structTagTokens(%q) = %q, want %q", test.tag, got, test.want)
+		}
+	}
+}
+
+func TestLiteralOffsets(t *testing.T) {
+	for _, lit := range []string{
+		`""`,
+		`"abc"`,
+		`"a\tbéc\xffd\377é"`,
+		"`raw\r\nstring`",
+	} {
+		value, err := strconv.Unquote(lit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets := literalOffsets(lit)
+		if len(offsets) != len(value)+1 {
+			t.Errorf("literalOffsets(%s) has %d offsets, want %d", lit, len(offsets), len(value)+1)
+			continue
+		}
+		// Each byte of the value must be decoded from the source text at its
+		// offset, up to the offset of the next one.
+		for i := 0; i < len(value); {
+			j := i + 1
+			for j < len(value) && offsets[j] == offsets[i] {
+				j++
+			}
+			src := lit[offsets[i]:offsets[j]]
+			if lit[0] == '`' {
+				src = strings.ReplaceAll(src, "\r", "")
+			} else {
+				src, err = strconv.Unquote(`"` + src + `"`)
+				if err != nil {
+					t.Errorf("literalOffsets(%s): bad escape at %d: %v", lit, offsets[i], err)
+					break
+				}
+			}
+			if src != value[i:j] {
+				t.Errorf("literalOffsets(%s): source %q at %d decodes to %q", lit, src, offsets[i], value[i:j])
+			}
+			i = j
+		}
+	}
+}
@@ -35,7 +35,7 @@ func NewServer(session *cache.Session, client protocol.ClientCloser, options *se
 	// stub declarations in unimplemented.go.
 	return &server{
 		diagnostics:           map[protocol.DocumentURI]*fileReports{},
-		gcOptimizationDetails: make(map[source.PackageID]struct{}),
+		gcOptimizationDetails: make(map[source.PackageID]*source.PackageGCDetails),
 		watchedGlobPatterns:   nil, // empty
 		changedFiles:          make(map[protocol.DocumentURI]struct{}),
 		session:               session,
@@ -102,10 +102,17 @@ type server struct {
 	diagnostics   map[protocol.DocumentURI]*fileReports
 
 	// gcOptimizationDetails describes the packages for which we want
-	// optimization details to be included in the diagnostics. The key is the
-	// ID of the package.
+	// optimization details to be reported, and holds the details last
+	// computed for each of them, or nil if they have not been computed. The
+	// key is the ID of the package.
 	gcOptimizationDetailsMu sync.Mutex
-	gcOptimizationDetails   map[source.PackageID]struct{}
+	gcOptimizationDetails   map[source.PackageID]*source.PackageGCDetails
+
+	// coverage holds the test coverage of files, as loaded by the
+	// coverage command. The map field may be reassigned but the map is
+	// immutable.
+	coverageMu sync.Mutex
+	coverage   map[protocol.DocumentURI]*source.FileCoverage
 
 	// diagnosticsSema limits the concurrency of diagnostics runs, which can be
 	// expensive.
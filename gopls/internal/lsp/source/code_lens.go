@@ -6,16 +6,21 @@ package source
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/lsp/command"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/internal/event"
 )
 
 type LensFunc func(context.Context, Snapshot, file.Handle) ([]protocol.CodeLens, error)
@@ -27,6 +32,7 @@ func LensFuncs() map[command.Command]LensFunc {
 		command.Test:          runTestCodeLens,
 		command.RegenerateCgo: regenerateCgoLens,
 		command.GCDetails:     toggleDetailsCodeLens,
+		command.References:    referencesCodeLens,
 	}
 }
 
@@ -56,6 +62,15 @@ func runTestCodeLens(ctx context.Context, snapshot Snapshot, fh file.Handle) ([]
 		codeLens = append(codeLens, protocol.CodeLens{Range: rng, Command: &cmd})
 	}
 
+	for _, fn := range fns.Subtests {
+		cmd, err := command.NewTestCommand("run subtest", puri, []string{fn.Name}, nil)
+		if err != nil {
+			return nil, err
+		}
+		rng := protocol.Range{Start: fn.Rng.Start, End: fn.Rng.Start}
+		codeLens = append(codeLens, protocol.CodeLens{Range: rng, Command: &cmd})
+	}
+
 	for _, fn := range fns.Benchmarks {
 		cmd, err := command.NewTestCommand("run benchmark", puri, nil, []string{fn.Name})
 		if err != nil {
@@ -96,6 +111,7 @@ type TestFn struct {
 type TestFns struct {
 	Tests      []TestFn
 	Benchmarks []TestFn
+	Subtests   []TestFn // named "TestXxx/name", ranging over the test case
 }
 
 func TestsAndBenchmarks(pkg Package, pgf *ParsedGoFile) (TestFns, error) {
@@ -118,6 +134,12 @@ func TestsAndBenchmarks(pkg Package, pgf *ParsedGoFile) (TestFns, error) {
 
 		if matchTestFunc(fn, pkg, testRe, "T") {
 			out.Tests = append(out.Tests, TestFn{fn.Name.Name, rng})
+
+			subtests, err := tableDrivenSubtests(pkg, pgf, fn)
+			if err != nil {
+				return out, err
+			}
+			out.Subtests = append(out.Subtests, subtests...)
 		}
 
 		if matchTestFunc(fn, pkg, benchmarkRe, "B") {
@@ -166,6 +188,176 @@ func matchTestFunc(fn *ast.FuncDecl, pkg Package, nameRe *regexp.Regexp, paramID
 	return namedObj.Id() == paramID
 }
 
+// tableDrivenSubtests returns the subtests of the test function fn that
+// are driven by a table of test cases, in the form:
+//
+//	for _, tc := range tests {
+//		t.Run(tc.name, func(t *testing.T) { ... })
+//	}
+//
+// where tests is a slice, array or map literal, or a local variable
+// initialized by one, and the subtest name is a constant field of each case
+// or a constant map key. Cases whose names are not constant are skipped.
+func tableDrivenSubtests(pkg Package, pgf *ParsedGoFile, fn *ast.FuncDecl) ([]TestFn, error) {
+	info := pkg.GetTypesInfo()
+	params := fn.Type.Params.List
+	if fn.Body == nil || len(params) != 1 || len(params[0].Names) != 1 {
+		return nil, nil
+	}
+	t := info.Defs[params[0].Names[0]]
+	if t == nil {
+		return nil, nil
+	}
+
+	// Record the literal initializers of local variables.
+	inits := make(map[types.Object]*ast.CompositeLit)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE && len(n.Lhs) == len(n.Rhs) {
+				for i, lhs := range n.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok {
+						if lit, ok := n.Rhs[i].(*ast.CompositeLit); ok {
+							inits[info.Defs[id]] = lit
+						}
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i, id := range n.Names {
+					if lit, ok := n.Values[i].(*ast.CompositeLit); ok {
+						inits[info.Defs[id]] = lit
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	var subtests []TestFn
+	var err error
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		loop, ok := n.(*ast.RangeStmt)
+		if !ok || err != nil {
+			return err == nil
+		}
+		name := subtestName(info, t, loop)
+		if name == nil {
+			return true
+		}
+		var table *ast.CompositeLit
+		switch x := astutil.Unparen(loop.X).(type) {
+		case *ast.CompositeLit:
+			table = x
+		case *ast.Ident:
+			table = inits[info.Uses[x]]
+		}
+		if table == nil {
+			return true
+		}
+		for _, elt := range table.Elts {
+			caseName, ok := subtestCaseName(info, loop, name, elt)
+			if !ok {
+				continue
+			}
+			var rng protocol.Range
+			rng, err = pgf.NodeRange(elt)
+			if err != nil {
+				return false
+			}
+			subtests = append(subtests, TestFn{fn.Name.Name + "/" + caseName, rng})
+		}
+		return true
+	})
+	return subtests, err
+}
+
+// subtestName returns the name argument of a call t.Run(name, f) among
+// the statements of the loop body, if it is the loop key or a field of
+// the loop value.
+func subtestName(info *types.Info, t types.Object, loop *ast.RangeStmt) ast.Expr {
+	for _, stmt := range loop.Body.List {
+		expr, ok := stmt.(*ast.ExprStmt)
+		if !ok {
+			continue
+		}
+		call, ok := expr.X.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Run" {
+			continue
+		}
+		if recv, ok := sel.X.(*ast.Ident); !ok || info.Uses[recv] != t {
+			continue
+		}
+		switch arg := call.Args[0].(type) {
+		case *ast.Ident:
+			if loop.Key != nil && sameVar(info, arg, loop.Key) {
+				return arg
+			}
+		case *ast.SelectorExpr:
+			if id, ok := arg.X.(*ast.Ident); ok && loop.Value != nil && sameVar(info, id, loop.Value) {
+				return arg
+			}
+		}
+	}
+	return nil
+}
+
+// sameVar reports whether the identifier id refers to the variable
+// declared by the loop variable expression v.
+func sameVar(info *types.Info, id *ast.Ident, v ast.Expr) bool {
+	decl, ok := v.(*ast.Ident)
+	return ok && info.Defs[decl] != nil && info.Uses[id] == info.Defs[decl]
+}
+
+// subtestCaseName returns the constant name of the subtest for the test
+// case elt of the table ranged over by loop, where name is the name
+// argument of the subtest, as returned by subtestName.
+func subtestCaseName(info *types.Info, loop *ast.RangeStmt, name ast.Expr, elt ast.Expr) (string, bool) {
+	var key, value ast.Expr = nil, elt
+	if kv, ok := elt.(*ast.KeyValueExpr); ok {
+		key, value = kv.Key, kv.Value
+	}
+
+	var nameExpr ast.Expr
+	switch name := name.(type) {
+	case *ast.Ident: // the map key
+		nameExpr = key
+	case *ast.SelectorExpr: // a field of the value
+		if u, ok := value.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			value = u.X
+		}
+		lit, ok := value.(*ast.CompositeLit)
+		if !ok {
+			return "", false
+		}
+		st, ok := info.TypeOf(lit).Underlying().(*types.Struct)
+		if !ok {
+			return "", false
+		}
+		for i, e := range lit.Elts {
+			if kv, ok := e.(*ast.KeyValueExpr); ok {
+				if id, ok := kv.Key.(*ast.Ident); ok && id.Name == name.Sel.Name {
+					nameExpr = kv.Value
+				}
+			} else if i < st.NumFields() && st.Field(i).Name() == name.Sel.Name {
+				nameExpr = e
+			}
+		}
+	}
+	if nameExpr == nil {
+		return "", false
+	}
+	if tv, ok := info.Types[nameExpr]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return constant.StringVal(tv.Value), true
+	}
+	return "", false
+}
+
 func goGenerateCodeLens(ctx context.Context, snapshot Snapshot, fh file.Handle) ([]protocol.CodeLens, error) {
 	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
 	if err != nil {
@@ -246,3 +438,102 @@ func toggleDetailsCodeLens(ctx context.Context, snapshot Snapshot, fh file.Handl
 	}
 	return []protocol.CodeLens{{Range: rng, Command: &cmd}}, nil
 }
+
+// referencesCodeLens returns a lens above each top-level function, type
+// and method declared in the file. Counting references requires a search
+// of the workspace, so the lenses are returned unresolved, and their
+// commands are computed on demand by ResolveReferencesCodeLens.
+func referencesCodeLens(ctx context.Context, snapshot Snapshot, fh file.Handle) ([]protocol.CodeLens, error) {
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, err
+	}
+	var codeLens []protocol.CodeLens
+	add := func(id *ast.Ident, implementations bool) error {
+		if id.Name == "_" {
+			return nil
+		}
+		rng, err := pgf.NodeRange(id)
+		if err != nil {
+			return err
+		}
+		codeLens = append(codeLens, protocol.CodeLens{
+			Range: rng,
+			Data: command.ReferencesArgs{
+				Location:        protocol.Location{URI: fh.URI(), Range: rng},
+				Implementations: implementations,
+			},
+		})
+		return nil
+	}
+	for _, decl := range pgf.File.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if err := add(decl.Name, decl.Recv != nil); err != nil {
+				return nil, err
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					if err := add(spec.Name, true); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return codeLens, nil
+}
+
+// DecodeReferencesCodeLens decodes the data of an unresolved lens
+// produced by the references lens function, which the client returns
+// as arbitrary JSON.
+func DecodeReferencesCodeLens(lens *protocol.CodeLens) (command.ReferencesArgs, error) {
+	var args command.ReferencesArgs
+	data, err := json.Marshal(lens.Data)
+	if err != nil {
+		return args, err
+	}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return args, fmt.Errorf("decoding code lens data: %v", err)
+	}
+	if args.Location.URI == "" {
+		return args, fmt.Errorf("code lens has no location")
+	}
+	return args, nil
+}
+
+// ResolveReferencesCodeLens returns the command of a references lens,
+// whose title reports the number of references to the symbol declared
+// at the lens location and, for a type or method, the number of its
+// implementations. If the implementations cannot be found, the title
+// reports only the references.
+func ResolveReferencesCodeLens(ctx context.Context, snapshot Snapshot, fh file.Handle, args command.ReferencesArgs) (*protocol.Command, error) {
+	pos := args.Location.Range.Start
+	refs, err := References(ctx, snapshot, fh, pos, false)
+	if err != nil {
+		return nil, err
+	}
+	title := pluralize(len(refs), "reference")
+	if args.Implementations {
+		if impls, err := Implementation(ctx, snapshot, fh, pos); err != nil {
+			event.Error(ctx, "counting implementations for references lens", err)
+		} else {
+			title += " | " + pluralize(len(impls), "implementation")
+		}
+	}
+	cmd, err := command.NewReferencesCommand(title, args)
+	if err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+// pluralize returns a count of the given noun, such as "1 reference"
+// or "2 references".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -25,6 +25,8 @@ import (
 	"time"
 	"unicode"
 
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/tools/go/ast/astutil"
 	goplsastutil "golang.org/x/tools/gopls/internal/astutil"
@@ -69,6 +71,11 @@ type CompletionItem struct {
 	// insert an unqualified type).
 	AdditionalTextEdits []protocol.TextEdit
 
+	// Command is an optional command that is executed after inserting this
+	// completion, such as to add a requirement for the module of an
+	// unimported package to the go.mod file.
+	Command *protocol.Command
+
 	// Depth is how many levels were searched to find this completion.
 	// For example when completing "foo<>", "fooBar" is depth 0, and
 	// "fooBar.Baz" is depth 1.
@@ -230,6 +237,16 @@ type completer struct {
 	// for deep completions.
 	methodSetCache map[methodSetKey]*types.MethodSet
 
+	// cachedModules holds, by import path, the module versions in the
+	// module cache that provide the unimported packages of modules not
+	// required by the current module. See cachedModule.
+	cachedModules map[string]module.Version
+
+	// modFile is the go.mod file of the current module, if any, parsed
+	// lazily by cachedModule.
+	modFile     *modfile.File
+	modFileDone bool
+
 	// mapper converts the positions in the file from which the completion originated.
 	mapper *protocol.Mapper
 
@@ -11,8 +11,13 @@ import (
 	"go/ast"
 	"go/doc"
 	"go/types"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/gopls/internal/lsp/command"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/gopls/internal/lsp/safetoken"
 	"golang.org/x/tools/gopls/internal/lsp/snippet"
@@ -55,6 +60,7 @@ func (c *completer) item(ctx context.Context, cand candidate) (CompletionItem, e
 		kind          = protocol.TextCompletion
 		snip          snippet.Builder
 		protocolEdits []protocol.TextEdit
+		cmd           *protocol.Command
 	)
 	if obj.Type() == nil {
 		detail = ""
@@ -171,11 +177,28 @@ Suffixes:
 		}
 
 		protocolEdits = append(protocolEdits, addlEdits...)
+		mod, ok := c.cachedModule(ctx, cand.imp.importPath)
+		if ok {
+			addCmd, err := command.NewAddCachedDependencyCommand("Add dependency from the module cache", command.CachedDependencyArgs{
+				URI:     c.fh.URI(),
+				Module:  mod.Path,
+				Version: mod.Version,
+				Package: cand.imp.importPath,
+			})
+			if err != nil {
+				return CompletionItem{}, err
+			}
+			cmd = &addCmd
+		}
 		if kind != protocol.ModuleCompletion {
 			if detail != "" {
 				detail += " "
 			}
-			detail += fmt.Sprintf("(from %q)", cand.imp.importPath)
+			if ok {
+				detail += fmt.Sprintf("(from %q in %s)", cand.imp.importPath, mod)
+			} else {
+				detail += fmt.Sprintf("(from %q)", cand.imp.importPath)
+			}
 		}
 	}
 
@@ -229,6 +252,7 @@ Suffixes:
 		Label:               label,
 		InsertText:          insert,
 		AdditionalTextEdits: protocolEdits,
+		Command:             cmd,
 		Detail:              detail,
 		Kind:                kind,
 		Score:               cand.score,
@@ -293,6 +317,75 @@ func (c *completer) importEdits(imp *importInfo) ([]protocol.TextEdit, error) {
 	})
 }
 
+// cachedModule returns the newest version in the module cache, compatible
+// with the go directive of the current module, of a module that provides
+// the package with the given import path, if the package is not provided
+// by the standard library, the current module or one of its requirements.
+// Selecting a completion that imports such a package also adds a
+// requirement for the module version.
+func (c *completer) cachedModule(ctx context.Context, importPath string) (module.Version, bool) {
+	if mod, ok := c.cachedModules[importPath]; ok {
+		return mod, mod.Path != ""
+	}
+	var mod module.Version
+	if f := c.currentModFile(ctx); f != nil && f.Module != nil && !providedByModFile(f, importPath) {
+		goVersion := ""
+		if f.Go != nil {
+			goVersion = f.Go.Version
+		}
+		mod, _ = source.FindCachedModule(c.snapshot.GOMODCACHE(), importPath, goVersion)
+	}
+	if c.cachedModules == nil {
+		c.cachedModules = make(map[string]module.Version)
+	}
+	c.cachedModules[importPath] = mod
+	return mod, mod.Path != ""
+}
+
+// currentModFile returns the go.mod file of the module containing the file
+// being completed, or nil if there is none or the module is vendored, as a
+// requirement added from the module cache would not be vendored.
+func (c *completer) currentModFile(ctx context.Context) *modfile.File {
+	if !c.modFileDone {
+		c.modFileDone = true
+		modURI := c.snapshot.GoModForFile(c.fh.URI())
+		if modURI != "" && !fileExists(filepath.Join(filepath.Dir(modURI.Path()), "vendor", "modules.txt")) {
+			if fh, err := c.snapshot.ReadFile(ctx, modURI); err == nil {
+				if pm, err := c.snapshot.ParseMod(ctx, fh); err == nil {
+					c.modFile = pm.File
+				}
+			}
+		}
+	}
+	return c.modFile
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// providedByModFile reports whether the package with the given import path
+// is in the standard library, or in the module or a requirement of the
+// go.mod file f.
+func providedByModFile(f *modfile.File, importPath string) bool {
+	if first, _, _ := strings.Cut(importPath, "/"); !strings.Contains(first, ".") {
+		return true // standard library
+	}
+	within := func(modPath string) bool {
+		return importPath == modPath || strings.HasPrefix(importPath, modPath+"/")
+	}
+	if within(f.Module.Mod.Path) {
+		return true
+	}
+	for _, req := range f.Require {
+		if within(req.Mod.Path) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *completer) formatBuiltin(ctx context.Context, cand candidate) (CompletionItem, error) {
 	obj := cand.obj
 	item := CompletionItem{
@@ -8,9 +8,9 @@ import (
 	"go/ast"
 	"go/constant"
 	"go/types"
-	"strconv"
 	"strings"
-	"unicode/utf8"
+
+	"golang.org/x/tools/internal/fmtstr"
 )
 
 // printfArgKind returns the expected objKind when completing a
@@ -51,22 +51,12 @@ func printfArgKind(info *types.Info, call *ast.CallExpr, argIdx int) objKind {
 // formatOperandKind returns the objKind corresponding to format's
 // operandIdx'th operand.
 func formatOperandKind(format string, operandIdx int) objKind {
-	var (
-		prevOperandIdx int
-		kind           = kindAny
-	)
-	for {
-		i := strings.Index(format, "%")
-		if i == -1 {
-			break
-		}
-
-		var operands []formatOperand
-		format, operands = parsePrintfVerb(format[i+1:], prevOperandIdx)
-
+	kind := kindAny
+	ops, _ := fmtstr.Parse(format)
+	for _, op := range ops {
 		// Check if any this verb's operands correspond to our target
 		// operandIdx.
-		for _, v := range operands {
+		for _, v := range formatOperands(op) {
 			if v.idx == operandIdx {
 				if kind == kindAny {
 					kind = v.kind
@@ -76,8 +66,6 @@ func formatOperandKind(format string, operandIdx int) objKind {
 					kind &= v.kind
 				}
 			}
-
-			prevOperandIdx = v.idx
 		}
 	}
 	return kind
@@ -90,83 +78,46 @@ type formatOperand struct {
 	kind objKind
 }
 
-// parsePrintfVerb parses the leading printf verb in f. The opening
-// "%" must already be trimmed from f. prevIdx is the previous
-// operand's index, or zero if this is the first verb. The format
-// string is returned with the leading verb removed. Multiple operands
-// can be returned in the case of dynamic widths such as "%*.*f".
-func parsePrintfVerb(f string, prevIdx int) (string, []formatOperand) {
-	var verbs []formatOperand
-
-	addVerb := func(k objKind) {
-		verbs = append(verbs, formatOperand{
-			idx:  prevIdx + 1,
-			kind: k,
-		})
-		prevIdx++
-	}
-
-	for len(f) > 0 {
-		// Trim first rune off of f so we are guaranteed to make progress.
-		r, l := utf8.DecodeRuneInString(f)
-		f = f[l:]
-
-		// We care about three things:
-		// 1. The verb, which maps directly to object kind.
-		// 2. Explicit operand indices like "%[2]s".
-		// 3. Dynamic widths using "*".
-		switch r {
-		case '%':
-			return f, nil
-		case '*':
-			addVerb(kindInt)
-			continue
-		case '[':
-			// Parse operand index as in "%[2]s".
-			i := strings.Index(f, "]")
-			if i == -1 {
-				return f, nil
-			}
-
-			idx, err := strconv.Atoi(f[:i])
-			f = f[i+1:]
-			if err != nil {
-				return f, nil
-			}
-
-			prevIdx = idx - 1
-			continue
-		case 'v', 'T':
-			addVerb(kindAny)
-		case 't':
-			addVerb(kindBool)
-		case 'c', 'd', 'o', 'O', 'U':
-			addVerb(kindInt)
-		case 'e', 'E', 'f', 'F', 'g', 'G':
-			addVerb(kindFloat | kindComplex)
-		case 'b':
-			addVerb(kindInt | kindFloat | kindComplex | kindBytes)
-		case 'q', 's':
-			addVerb(kindString | kindBytes | kindStringer | kindError)
-		case 'x', 'X':
-			// Omit kindStringer and kindError though technically allowed.
-			addVerb(kindString | kindBytes | kindInt | kindFloat | kindComplex)
-		case 'p':
-			addVerb(kindPtr | kindSlice)
-		case 'w':
-			addVerb(kindError)
-		case '+', '-', '#', ' ', '.', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			// Flag or numeric width/precision value.
-			continue
-		default:
-			// Assume unrecognized rune is a custom fmt.Formatter verb.
-			addVerb(kindAny)
-		}
-
-		if len(verbs) > 0 {
-			break
+// formatOperands returns the operands of the printf directive op.
+// Multiple operands are returned in the case of dynamic widths such as
+// "%*.*f".
+func formatOperands(op *fmtstr.Operation) []formatOperand {
+	var operands []formatOperand
+	for _, size := range []fmtstr.Size{op.Width, op.Prec} {
+		if size.Kind == fmtstr.Star {
+			operands = append(operands, formatOperand{idx: size.ArgIndex + 1, kind: kindInt})
 		}
 	}
+	if op.Verb.ArgIndex >= 0 {
+		operands = append(operands, formatOperand{idx: op.Verb.ArgIndex + 1, kind: verbKind(op.Verb.Verb)})
+	}
+	return operands
+}
 
-	return f, verbs
+// verbKind returns the kinds of objects that the printf verb accepts.
+func verbKind(verb rune) objKind {
+	switch verb {
+	case 'v', 'T':
+		return kindAny
+	case 't':
+		return kindBool
+	case 'c', 'd', 'o', 'O', 'U':
+		return kindInt
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		return kindFloat | kindComplex
+	case 'b':
+		return kindInt | kindFloat | kindComplex | kindBytes
+	case 'q', 's':
+		return kindString | kindBytes | kindStringer | kindError
+	case 'x', 'X':
+		// Omit kindStringer and kindError though technically allowed.
+		return kindString | kindBytes | kindInt | kindFloat | kindComplex
+	case 'p':
+		return kindPtr | kindSlice
+	case 'w':
+		return kindError
+	default:
+		// Assume unrecognized rune is a custom fmt.Formatter verb.
+		return kindAny
+	}
 }
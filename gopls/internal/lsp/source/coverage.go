@@ -0,0 +1,188 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/cover"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/safetoken"
+)
+
+// FileCoverage holds the test coverage of a Go file, as recorded in a
+// coverage profile produced by `go test -coverprofile`.
+//
+// Profiles record positions in the file content at the time the tests
+// were run, so the coverage applies only while the file content has the
+// recorded hash.
+type FileCoverage struct {
+	Hash   file.Hash       // digest of the file content described by Blocks
+	Blocks []CoverageBlock // in order of position
+}
+
+// A CoverageBlock is a block of statements and the number of times it was
+// executed.
+type CoverageBlock struct {
+	Range   protocol.Range
+	NumStmt int
+	Count   int
+}
+
+// LoadCoverage maps the blocks of the given coverage profiles to files of
+// the workspace. Profiles of files that do not belong to a workspace
+// package are ignored. Blocks recorded by more than one profile, as when
+// several test binaries cover the same package, have their counts summed.
+func LoadCoverage(ctx context.Context, snapshot Snapshot, profiles []*cover.Profile) (map[protocol.DocumentURI]*FileCoverage, error) {
+	workspace, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Profiles name files by import path, except for packages outside
+	// a module or GOPATH, whose files are named by absolute path.
+	files := make(map[string]protocol.DocumentURI)
+	for _, m := range workspace {
+		for _, uri := range m.GoFiles {
+			files[path.Join(string(m.PkgPath), filepath.Base(uri.Path()))] = uri
+		}
+	}
+
+	type blockKey struct{ startLine, startCol, endLine, endCol int }
+	merged := make(map[protocol.DocumentURI]map[blockKey]cover.ProfileBlock)
+	for _, p := range profiles {
+		uri, ok := files[p.FileName]
+		if !ok && filepath.IsAbs(p.FileName) {
+			uri, ok = protocol.URIFromPath(p.FileName), true
+		}
+		if !ok {
+			continue
+		}
+		blocks := merged[uri]
+		if blocks == nil {
+			blocks = make(map[blockKey]cover.ProfileBlock)
+			merged[uri] = blocks
+		}
+		for _, b := range p.Blocks {
+			key := blockKey{b.StartLine, b.StartCol, b.EndLine, b.EndCol}
+			if prev, ok := blocks[key]; ok {
+				b.Count += prev.Count
+			}
+			blocks[key] = b
+		}
+	}
+
+	result := make(map[protocol.DocumentURI]*FileCoverage)
+	for uri, blocks := range merged {
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fh.Content()
+		if err != nil {
+			continue // e.g. the file was deleted since the tests ran
+		}
+		m := protocol.NewMapper(uri, content)
+		fc := &FileCoverage{Hash: fh.Identity().Hash}
+		for _, b := range blocks {
+			start, err := m.LineCol8Position(b.StartLine, b.StartCol)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coverage block in %s: %v", uri.Path(), err)
+			}
+			end, err := m.LineCol8Position(b.EndLine, b.EndCol)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coverage block in %s: %v", uri.Path(), err)
+			}
+			fc.Blocks = append(fc.Blocks, CoverageBlock{
+				Range:   protocol.Range{Start: start, End: end},
+				NumStmt: b.NumStmt,
+				Count:   b.Count,
+			})
+		}
+		sort.Slice(fc.Blocks, func(i, j int) bool {
+			return protocol.CompareRange(fc.Blocks[i].Range, fc.Blocks[j].Range) < 0
+		})
+		result[uri] = fc
+	}
+	return result, nil
+}
+
+// CoverageDiagnostics returns a diagnostic for each block of the file
+// coverage: blocks that were never executed are reported with severity
+// Information, and executed blocks with severity Hint.
+func CoverageDiagnostics(uri protocol.DocumentURI, fc *FileCoverage) []*Diagnostic {
+	var diags []*Diagnostic
+	for _, b := range fc.Blocks {
+		if b.NumStmt == 0 {
+			continue
+		}
+		diag := &Diagnostic{
+			URI:    uri,
+			Range:  b.Range,
+			Source: CoverageInfo,
+		}
+		if b.Count == 0 {
+			diag.Severity = protocol.SeverityInformation
+			diag.Message = "not covered by tests"
+		} else {
+			diag.Severity = protocol.SeverityHint
+			diag.Message = "covered by tests"
+		}
+		diags = append(diags, diag)
+	}
+	return diags
+}
+
+// FuncCoverageHover returns a description of the coverage of the function
+// declared at the given position, such as "Test coverage: 75.0% of
+// statements", or "" if the position is not the name of a function
+// declaration whose blocks are included in the file coverage.
+func FuncCoverageHover(ctx context.Context, snapshot Snapshot, fh file.Handle, pp protocol.Position, fc *FileCoverage) (string, error) {
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return "", err
+	}
+	pos, err := pgf.PositionPos(pp)
+	if err != nil {
+		return "", err
+	}
+	var decl *ast.FuncDecl
+	for _, d := range pgf.File.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Name.Pos() <= pos && pos <= fn.Name.End() {
+			decl = fn
+			break
+		}
+	}
+	if decl == nil || decl.Body == nil {
+		return "", nil
+	}
+	start, end, err := safetoken.Offsets(pgf.Tok, decl.Pos(), decl.End())
+	if err != nil {
+		return "", err
+	}
+
+	var covered, total int
+	for _, b := range fc.Blocks {
+		bstart, bend, err := pgf.Mapper.RangeOffsets(b.Range)
+		if err != nil {
+			return "", err
+		}
+		if start <= bstart && bend <= end {
+			total += b.NumStmt
+			if b.Count > 0 {
+				covered += b.NumStmt
+			}
+		}
+	}
+	if total == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Test coverage: %.1f%% of statements (%d/%d)", 100*float64(covered)/float64(total), covered, total), nil
+}
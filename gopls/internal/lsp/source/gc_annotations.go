@@ -13,27 +13,72 @@ import (
 	"path/filepath"
 	"strings"
 
+	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/internal/gocommand"
 )
 
-func GCOptimizationDetails(ctx context.Context, snapshot Snapshot, m *Metadata) (map[protocol.DocumentURI][]*Diagnostic, error) {
+// PackageGCDetails holds the optimization decisions reported by the
+// compiler for the files of a package.
+type PackageGCDetails struct {
+	Files map[protocol.DocumentURI]*FileGCDetails
+}
+
+// FileGCDetails holds the optimization decisions reported by the
+// compiler for a file.
+//
+// The compiler reports positions in the file content on disk at the time
+// the package was compiled, so the details apply only while the file
+// content has the recorded hash.
+type FileGCDetails struct {
+	Hash    file.Hash   // digest of the file content described by Details
+	Details []*GCDetail // in order of position
+}
+
+// A GCDetail is an optimization decision of the compiler, such as the
+// escape of a variable to the heap or the inlining of a call.
+type GCDetail struct {
+	Kind    settings.Annotation
+	Code    string // the compiler's code for the decision, such as "escapes"
+	Message string
+	Range   protocol.Range
+
+	// Related explains the decision. For escapes, it holds the steps of
+	// the flow of the value to the heap, as reported by -m=2.
+	Related []protocol.DiagnosticRelatedInformation
+}
+
+// GCOptimizationDetails builds the package m, and returns the
+// optimization decisions reported by the compiler for each of its
+// files.
+func GCOptimizationDetails(ctx context.Context, snapshot Snapshot, m *Metadata) (*PackageGCDetails, error) {
 	if len(m.CompiledGoFiles) == 0 {
 		return nil, nil
 	}
 	pkgDir := filepath.Dir(m.CompiledGoFiles[0].Path())
-	outDir := filepath.Join(os.TempDir(), fmt.Sprintf("gopls-%d.details", os.Getpid()))
-
-	if err := os.MkdirAll(outDir, 0700); err != nil {
+	outDir, err := os.MkdirTemp("", fmt.Sprintf("gopls-%d.details-", os.Getpid()))
+	if err != nil {
 		return nil, err
 	}
+	defer os.RemoveAll(outDir)
 	tmpFile, err := os.CreateTemp(os.TempDir(), "gopls-x")
 	if err != nil {
 		return nil, err
 	}
+	tmpFile.Close()
 	defer os.Remove(tmpFile.Name())
 
+	// Record the content of the files that the compiler will read.
+	pd := &PackageGCDetails{Files: make(map[protocol.DocumentURI]*FileGCDetails)}
+	for _, uri := range gcDetailsFiles(m) {
+		content, err := os.ReadFile(uri.Path())
+		if err != nil {
+			return nil, err
+		}
+		pd.Files[uri] = &FileGCDetails{Hash: file.HashOf(content)}
+	}
+
 	outDirURI := protocol.URIFromPath(outDir)
 	// GC details doesn't handle Windows URIs in the form of "file:///C:/...",
 	// so rewrite them to "file://C:/...". See golang/go#41614.
@@ -57,39 +102,69 @@ func GCOptimizationDetails(ctx context.Context, snapshot Snapshot, m *Metadata)
 	if err != nil {
 		return nil, err
 	}
-	reports := make(map[protocol.DocumentURI][]*Diagnostic)
-	opts := snapshot.Options()
 	var parseError error
 	for _, fn := range files {
-		uri, diagnostics, err := parseDetailsFile(fn, opts)
+		uri, details, err := parseDetailsFile(fn)
 		if err != nil {
 			// expect errors for all the files, save 1
 			parseError = err
 		}
-		fh := snapshot.FindFile(uri)
-		if fh == nil {
-			continue
+		// https://github.com/golang/go/issues/42198
+		// sometimes the details generated for files
+		// outside the package can never be taken back.
+		if fd, ok := pd.Files[uri]; ok {
+			fd.Details = details
 		}
-		if pkgDir != filepath.Dir(fh.URI().Path()) {
-			// https://github.com/golang/go/issues/42198
-			// sometimes the detail diagnostics generated for files
-			// outside the package can never be taken back.
-			continue
+	}
+	return pd, parseError
+}
+
+// gcDetailsFiles returns the files of package m for which the compiler
+// reports optimization details.
+func gcDetailsFiles(m *Metadata) []protocol.DocumentURI {
+	var uris []protocol.DocumentURI
+	pkgDir := filepath.Dir(m.CompiledGoFiles[0].Path())
+	for _, uri := range m.CompiledGoFiles {
+		if filepath.Dir(uri.Path()) == pkgDir {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// NeedsGCDetails reports whether the optimization details of package m
+// must be (re)computed: that is, whether pd is nil, or describes content
+// other than the current content of one of the files of m. Since the
+// compiler reads files from disk, it reports false while any file of m
+// has unsaved changes.
+func NeedsGCDetails(ctx context.Context, snapshot Snapshot, m *Metadata, pd *PackageGCDetails) bool {
+	if len(m.CompiledGoFiles) == 0 {
+		return false
+	}
+	stale := pd == nil
+	for _, uri := range gcDetailsFiles(m) {
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil || !fh.SameContentsOnDisk() {
+			return false
+		}
+		if pd != nil {
+			if fd := pd.Files[uri]; fd == nil || fd.Hash != fh.Identity().Hash {
+				stale = true
+			}
 		}
-		reports[fh.URI()] = diagnostics
 	}
-	return reports, parseError
+	return stale
 }
 
-func parseDetailsFile(filename string, options *settings.Options) (protocol.DocumentURI, []*Diagnostic, error) {
+func parseDetailsFile(filename string) (protocol.DocumentURI, []*GCDetail, error) {
 	buf, err := os.ReadFile(filename)
 	if err != nil {
 		return "", nil, err
 	}
 	var (
-		uri         protocol.DocumentURI
-		i           int
-		diagnostics []*Diagnostic
+		uri     protocol.DocumentURI
+		i       int
+		details []*GCDetail
 	)
 	type metadata struct {
 		File string `json:"file,omitempty"`
@@ -112,12 +187,12 @@ func parseDetailsFile(filename string, options *settings.Options) (protocol.Docu
 		if err := dec.Decode(d); err != nil {
 			return "", nil, err
 		}
-		d.Tags = []protocol.DiagnosticTag{} // must be an actual slice
-		msg := d.Code.(string)
-		if msg != "" {
-			msg = fmt.Sprintf("%s(%s)", msg, d.Message)
+		if d.Source != "go compiler" {
+			continue
 		}
-		if !showDiagnostic(msg, d.Source, options) {
+		code, _ := d.Code.(string)
+		kind, ok := gcDetailKind(code)
+		if !ok {
 			continue
 		}
 		var related []protocol.DiagnosticRelatedInformation
@@ -135,44 +210,151 @@ func parseDetailsFile(filename string, options *settings.Options) (protocol.Docu
 				Message: ri.Message,
 			})
 		}
-		diagnostic := &Diagnostic{
-			URI:      uri,
-			Range:    zeroIndexedRange(d.Range),
-			Message:  msg,
-			Severity: d.Severity,
-			Source:   OptimizationDetailsError, // d.Source is always "go compiler" as of 1.16, use our own
-			Tags:     d.Tags,
-			Related:  related,
-		}
-		diagnostics = append(diagnostics, diagnostic)
+		details = append(details, &GCDetail{
+			Kind:    kind,
+			Code:    code,
+			Message: d.Message,
+			Range:   zeroIndexedRange(d.Range),
+			Related: related,
+		})
 		i++
 	}
-	return uri, diagnostics, nil
+	return uri, details, nil
 }
 
-// showDiagnostic reports whether a given diagnostic should be shown to the end
-// user, given the current options.
-func showDiagnostic(msg, source string, o *settings.Options) bool {
-	if source != "go compiler" {
-		return false
+// gcDetailKind returns the kind of annotation of a compiler decision with
+// the given code, or false if the decision is not of interest.
+func gcDetailKind(code string) (settings.Annotation, bool) {
+	switch {
+	case strings.HasPrefix(code, "canInline") ||
+		strings.HasPrefix(code, "cannotInline") ||
+		strings.HasPrefix(code, "inlineCall"):
+		return settings.Inline, true
+	case strings.HasPrefix(code, "escape") || code == "leak":
+		return settings.Escape, true
+	case strings.HasPrefix(code, "nilcheck"):
+		return settings.Nil, true
+	case strings.HasPrefix(code, "isInBounds") ||
+		strings.HasPrefix(code, "isSliceInBounds"):
+		return settings.Bounds, true
 	}
-	if o.Annotations == nil {
-		return true
+	return "", false
+}
+
+// showGCDetail reports whether a given decision should be shown to the
+// end user, given the current options.
+func showGCDetail(d *GCDetail, o *settings.Options) bool {
+	return o.Annotations == nil || o.Annotations[d.Kind]
+}
+
+// String returns the compiler's description of the decision.
+func (d *GCDetail) String() string {
+	if d.Message == "" {
+		return d.Code
 	}
-	switch {
-	case strings.HasPrefix(msg, "canInline") ||
-		strings.HasPrefix(msg, "cannotInline") ||
-		strings.HasPrefix(msg, "inlineCall"):
-		return o.Annotations[settings.Inline]
-	case strings.HasPrefix(msg, "escape") || msg == "leak":
-		return o.Annotations[settings.Escape]
-	case strings.HasPrefix(msg, "nilcheck"):
-		return o.Annotations[settings.Nil]
-	case strings.HasPrefix(msg, "isInBounds") ||
-		strings.HasPrefix(msg, "isSliceInBounds"):
-		return o.Annotations[settings.Bounds]
-	}
-	return false
+	return fmt.Sprintf("%s(%s)", d.Code, d.Message)
+}
+
+// label returns a short description of the decision, for use in an inlay
+// hint.
+func (d *GCDetail) label() string {
+	switch d.Code {
+	case "canInlineFunction":
+		return "inlinable"
+	case "cannotInlineFunction":
+		return "not inlinable"
+	case "inlineCall":
+		return "inlined"
+	case "isInBounds", "isSliceInBounds":
+		return "bounds check"
+	case "nilcheck":
+		return "nil check"
+	}
+	if d.Message != "" {
+		return d.Message
+	}
+	return d.Code
+}
+
+// explanation returns the description of the decision followed by the
+// steps that explain it, one per line.
+func (d *GCDetail) explanation() string {
+	var b strings.Builder
+	b.WriteString(d.String())
+	for _, ri := range d.Related {
+		fmt.Fprintf(&b, "\n%s (%s:%d:%d)",
+			strings.TrimPrefix(ri.Message, "escflow:"),
+			filepath.Base(ri.Location.URI.Path()),
+			ri.Location.Range.Start.Line+1,
+			ri.Location.Range.Start.Character+1)
+	}
+	return b.String()
+}
+
+// GCDetailsDiagnostics returns the optimization details of a file as
+// diagnostics, for clients that do not support inlay hints.
+func GCDetailsDiagnostics(uri protocol.DocumentURI, fd *FileGCDetails, options *settings.Options) []*Diagnostic {
+	var diagnostics []*Diagnostic
+	for _, d := range fd.Details {
+		if !showGCDetail(d, options) {
+			continue
+		}
+		diagnostics = append(diagnostics, &Diagnostic{
+			URI:      uri,
+			Range:    d.Range,
+			Message:  d.String(),
+			Severity: protocol.SeverityInformation,
+			Source:   OptimizationDetailsError,   // the compiler's source is always "go compiler" as of 1.16, use our own
+			Tags:     []protocol.DiagnosticTag{}, // must be an actual slice
+			Related:  d.Related,
+		})
+	}
+	return diagnostics
+}
+
+// GCDetailsInlayHints returns inlay hints for the optimization details
+// of a file within the given range, or the entire file if the range is
+// empty. The tooltip of each hint explains the decision.
+func GCDetailsInlayHints(fd *FileGCDetails, rng protocol.Range, options *settings.Options) []protocol.InlayHint {
+	whole := protocol.ComparePosition(rng.Start, rng.End) >= 0
+	var hints []protocol.InlayHint
+	for _, d := range fd.Details {
+		if !showGCDetail(d, options) {
+			continue
+		}
+		pos := d.Range.Start
+		if !whole && (protocol.ComparePosition(pos, rng.Start) < 0 || protocol.ComparePosition(pos, rng.End) > 0) {
+			continue
+		}
+		label := buildLabel(d.label())
+		label[0].Tooltip = &protocol.OrPTooltipPLabel{Value: d.explanation()}
+		hints = append(hints, protocol.InlayHint{
+			Position:     pos,
+			Label:        label,
+			PaddingRight: true,
+		})
+	}
+	return hints
+}
+
+// GCDetailsHover returns the explanation of the optimization details of
+// a file that are reported at a position within the given range, such
+// as the range of a hovered identifier.
+func GCDetailsHover(fd *FileGCDetails, rng protocol.Range, options *settings.Options) string {
+	var explanations []string
+	for _, d := range fd.Details {
+		if showGCDetail(d, options) && protocol.Intersect(protocol.Range{Start: d.Range.Start, End: d.Range.Start}, rng) {
+			explanations = append(explanations, d.explanation())
+		}
+	}
+	if len(explanations) == 0 {
+		return ""
+	}
+	text := strings.Join(explanations, "\n")
+	if options.PreferredContentFormat == protocol.Markdown {
+		text = "```\n" + text + "\n```"
+	}
+	return text
 }
 
 // The range produced by the compiler is 1-indexed, so subtract range by 1.
@@ -60,6 +60,10 @@ type HoverJSON struct {
 	// LinkAnchor is the pkg.go.dev link anchor for the given symbol.
 	// For example, the "Node" part of "pkg.go.dev/go/ast#Node".
 	LinkAnchor string `json:"linkAnchor"`
+
+	// Layout describes the memory layout of a struct type, if the
+	// structLayoutInHover option is set.
+	Layout string `json:"layout,omitempty"`
 }
 
 // Hover implements the "textDocument/hover" RPC for Go files.
@@ -220,6 +224,7 @@ func hover(ctx context.Context, snapshot Snapshot, fh file.Handle, pp protocol.P
 	// of identifiers, and we should revisit the formatting of method set.
 	_, isTypeName := obj.(*types.TypeName)
 	_, isTypeParam := obj.Type().(*typeparams.TypeParam)
+	var layout string
 	if isTypeName && !isTypeParam {
 		spec, ok := spec.(*ast.TypeSpec)
 		if !ok {
@@ -254,6 +259,10 @@ func hover(ctx context.Context, snapshot Snapshot, fh file.Handle, pp protocol.P
 			}
 		}
 		signature = b.String()
+
+		if snapshot.Options().StructLayoutInHover {
+			layout = structLayout(pkg.Metadata().TypesSizes, snapshot.GOARCH(), obj.Type(), qf)
+		}
 	}
 
 	// Compute link data (on pkg.go.dev or other documentation host).
@@ -361,6 +370,7 @@ func hover(ctx context.Context, snapshot Snapshot, fh file.Handle, pp protocol.P
 		Signature:         signature,
 		LinkPath:          linkPath,
 		LinkAnchor:        anchor,
+		Layout:            layout,
 	}, nil
 }
 
@@ -889,6 +899,9 @@ func formatHover(h *HoverJSON, options *settings.Options) (string, error) {
 
 func formatSignature(h *HoverJSON, options *settings.Options) string {
 	signature := h.Signature
+	if h.Layout != "" {
+		signature += "\n\n" + h.Layout
+	}
 	if signature != "" && options.PreferredContentFormat == protocol.Markdown {
 		signature = fmt.Sprintf("```go\n%s\n```", signature)
 	}
@@ -39,6 +39,11 @@ const (
 	CompositeLiteralTypes      = "compositeLiteralTypes"
 	CompositeLiteralFieldNames = "compositeLiteralFields"
 	FunctionTypeParameters     = "functionTypeParameters"
+	InterfaceConversions       = "interfaceConversions"
+	ImplicitAddressOf          = "implicitAddressOf"
+	CapturedLoopVariables      = "capturedLoopVariables"
+	MethodValueTypeParameters  = "methodValueTypeParameters"
+	DeferArguments             = "deferArguments"
 )
 
 var AllInlayHints = map[string]*Hint{
@@ -77,6 +82,31 @@ var AllInlayHints = map[string]*Hint{
 		Doc:  "Enable/disable inlay hints for implicit type parameters on generic functions:\n```go\n\tmyFoo/*[int, string]*/(1, \"hello\")\n```",
 		Run:  funcTypeParams,
 	},
+	InterfaceConversions: {
+		Name: InterfaceConversions,
+		Doc:  "Enable/disable inlay hints for implicit conversions of call arguments to non-empty interface types:\n```go\n\tio.Copy(/*io.Writer(*/f/*)*/, /*io.Reader(*/r/*)*/)\n```",
+		Run:  interfaceConversions,
+	},
+	ImplicitAddressOf: {
+		Name: ImplicitAddressOf,
+		Doc:  "Enable/disable inlay hints for the implicit address-of operation on the receiver of methods with pointer receivers:\n```go\n\t/*(&*/buf/*)*/.WriteString(\"hello\")\n```",
+		Run:  implicitAddressOf,
+	},
+	CapturedLoopVariables: {
+		Name: CapturedLoopVariables,
+		Doc:  "Enable/disable inlay hints for loop variables captured by function literals, such as closures started as goroutines:\n```go\n\tfor _, v := range values {\n\t\tgo func() {\n\t\t\tuse(v/* captured loop var*/)\n\t\t}()\n\t}\n```",
+		Run:  capturedLoopVariables,
+	},
+	MethodValueTypeParameters: {
+		Name: MethodValueTypeParameters,
+		Doc:  "Enable/disable inlay hints for the inferred type arguments of method values of generic types, and of generic functions used as values:\n```go\n\tpush := stack.Push/*[int]*/\n\tvar f func(int) string = format/*[int]*/\n```",
+		Run:  methodValueTypeParams,
+	},
+	DeferArguments: {
+		Name: DeferArguments,
+		Doc:  "Enable/disable inlay hints for the arguments of deferred calls, which are evaluated at the defer statement rather than when the call runs:\n```go\n\tdefer log.Printf(\"took %v\", time.Since(start)/* evaluated at defer*/)\n```",
+		Run:  deferArguments,
+	},
 }
 
 func InlayHint(ctx context.Context, snapshot Snapshot, fh file.Handle, pRng protocol.Range) ([]protocol.InlayHint, error) {
@@ -209,6 +239,274 @@ func funcTypeParams(node ast.Node, m *protocol.Mapper, tf *token.File, info *typ
 	}}
 }
 
+func interfaceConversions(node ast.Node, m *protocol.Mapper, tf *token.File, info *types.Info, q *types.Qualifier) []protocol.InlayHint {
+	callExpr, ok := node.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	// Skip conversions and calls to built-ins.
+	if tv, ok := info.Types[callExpr.Fun]; !ok || tv.IsType() || tv.IsBuiltin() {
+		return nil
+	}
+	signature, ok := info.TypeOf(callExpr.Fun).(*types.Signature)
+	if !ok {
+		return nil
+	}
+
+	var hints []protocol.InlayHint
+	params := signature.Params()
+	for i, v := range callExpr.Args {
+		var paramType types.Type
+		switch {
+		case signature.Variadic() && i >= params.Len()-1:
+			if callExpr.Ellipsis.IsValid() {
+				continue // the slice is passed as is
+			}
+			paramType = params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+		case i < params.Len():
+			paramType = params.At(i).Type()
+		default:
+			// e.g. f(g()) where g returns multiple values
+			continue
+		}
+		iface, ok := paramType.Underlying().(*types.Interface)
+		if !ok || iface.Empty() {
+			continue
+		}
+		argType := info.TypeOf(v)
+		if argType == nil || types.IsInterface(argType) {
+			continue
+		}
+		if b, ok := argType.(*types.Basic); ok && b.Kind() == types.UntypedNil {
+			continue
+		}
+		start, err := m.PosPosition(tf, v.Pos())
+		if err != nil {
+			continue
+		}
+		end, err := m.PosPosition(tf, v.End())
+		if err != nil {
+			continue
+		}
+		hints = append(hints, protocol.InlayHint{
+			Position: start,
+			Label:    buildLabel(types.TypeString(paramType, *q) + "("),
+			Kind:     protocol.Type,
+		}, protocol.InlayHint{
+			Position: end,
+			Label:    buildLabel(")"),
+			Kind:     protocol.Type,
+		})
+	}
+	return hints
+}
+
+func implicitAddressOf(node ast.Node, m *protocol.Mapper, tf *token.File, info *types.Info, _ *types.Qualifier) []protocol.InlayHint {
+	sel, ok := node.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	selection, ok := info.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal || selection.Indirect() {
+		return nil
+	}
+	// A method with a pointer receiver selected from an addressable value
+	// (including values reached through embedded fields) implicitly takes
+	// the address of the value.
+	recv := selection.Obj().(*types.Func).Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	if _, ok := recv.Type().(*types.Pointer); !ok {
+		return nil
+	}
+	if _, ok := info.TypeOf(sel.X).Underlying().(*types.Pointer); ok {
+		return nil
+	}
+	start, err := m.PosPosition(tf, sel.X.Pos())
+	if err != nil {
+		return nil
+	}
+	end, err := m.PosPosition(tf, sel.X.End())
+	if err != nil {
+		return nil
+	}
+	return []protocol.InlayHint{{
+		Position: start,
+		Label:    buildLabel("(&"),
+	}, {
+		Position: end,
+		Label:    buildLabel(")"),
+	}}
+}
+
+func capturedLoopVariables(node ast.Node, m *protocol.Mapper, tf *token.File, info *types.Info, _ *types.Qualifier) []protocol.InlayHint {
+	// Collect the variables declared by the loop.
+	loopVars := make(map[types.Object]bool)
+	addVar := func(e ast.Expr) {
+		if id, ok := e.(*ast.Ident); ok {
+			if obj := info.Defs[id]; obj != nil {
+				loopVars[obj] = true
+			}
+		}
+	}
+	var body *ast.BlockStmt
+	switch loop := node.(type) {
+	case *ast.RangeStmt:
+		if loop.Tok == token.DEFINE {
+			addVar(loop.Key)
+			addVar(loop.Value)
+		}
+		body = loop.Body
+	case *ast.ForStmt:
+		if init, ok := loop.Init.(*ast.AssignStmt); ok && init.Tok == token.DEFINE {
+			for _, lhs := range init.Lhs {
+				addVar(lhs)
+			}
+		}
+		body = loop.Body
+	}
+	if len(loopVars) == 0 || body == nil {
+		return nil
+	}
+
+	// Report the first reference to each loop variable within each
+	// function literal of the body. Nested function literals are
+	// visited as part of the outermost one.
+	var hints []protocol.InlayHint
+	ast.Inspect(body, func(n ast.Node) bool {
+		lit, ok := n.(*ast.FuncLit)
+		if !ok {
+			return true
+		}
+		seen := make(map[types.Object]bool)
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := info.Uses[id]
+			if !loopVars[obj] || seen[obj] {
+				return true
+			}
+			seen[obj] = true
+			end, err := m.PosPosition(tf, id.End())
+			if err != nil {
+				return true
+			}
+			hints = append(hints, protocol.InlayHint{
+				Position:    end,
+				Label:       buildLabel("captured loop var"),
+				PaddingLeft: true,
+			})
+			return true
+		})
+		return false
+	})
+	return hints
+}
+
+func methodValueTypeParams(node ast.Node, m *protocol.Mapper, tf *token.File, info *types.Info, q *types.Qualifier) []protocol.InlayHint {
+	// Only values are of interest: the type arguments of a called
+	// function are reported by funcTypeParams, and those of a called
+	// method are evident from its receiver. So visit the children of
+	// node, skipping the function of a call, explicitly instantiated
+	// generic names, and the selected name of a selector, which is
+	// reported (or skipped) along with the selector by its parent.
+	var skip ast.Expr
+	switch n := node.(type) {
+	case *ast.CallExpr:
+		skip = n.Fun
+	case *ast.SelectorExpr:
+		skip = n.Sel
+	default:
+		if x, _, _, _ := typeparams.UnpackIndexExpr(node); x != nil {
+			skip = x
+		}
+	}
+	var hints []protocol.InlayHint
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == node {
+			return true
+		}
+		if n == nil || n == skip {
+			return false
+		}
+		var (
+			id       *ast.Ident
+			typeArgs *typeparams.TypeList
+		)
+		switch e := n.(type) {
+		case *ast.Ident:
+			id, typeArgs = e, typeparams.GetInstances(info)[e].TypeArgs
+		case *ast.SelectorExpr:
+			id = e.Sel
+			if selection, ok := info.Selections[e]; ok {
+				if selection.Kind() == types.MethodVal {
+					if named, ok := Deref(selection.Recv()).(*types.Named); ok {
+						typeArgs = typeparams.NamedTypeArgs(named)
+					}
+				}
+			} else {
+				// A qualified identifier, e.g. pkg.Func.
+				typeArgs = typeparams.GetInstances(info)[e.Sel].TypeArgs
+			}
+		}
+		if typeArgs.Len() == 0 {
+			return false
+		}
+		end, err := m.PosPosition(tf, id.End())
+		if err != nil {
+			return false
+		}
+		var args []string
+		for i := 0; i < typeArgs.Len(); i++ {
+			args = append(args, types.TypeString(typeArgs.At(i), *q))
+		}
+		hints = append(hints, protocol.InlayHint{
+			Position: end,
+			Label:    buildLabel("[" + strings.Join(args, ", ") + "]"),
+			Kind:     protocol.Type,
+		})
+		return false
+	})
+	return hints
+}
+
+func deferArguments(node ast.Node, m *protocol.Mapper, tf *token.File, info *types.Info, _ *types.Qualifier) []protocol.InlayHint {
+	stmt, ok := node.(*ast.DeferStmt)
+	if !ok {
+		return nil
+	}
+	// The function value and the arguments of a deferred call are
+	// evaluated at the defer statement. Report those whose evaluation
+	// may differ from that at the time of the call: calls, and
+	// expressions that are not constants or function literals.
+	exprs := stmt.Call.Args
+	if call, ok := stmt.Call.Fun.(*ast.CallExpr); ok {
+		exprs = append([]ast.Expr{call}, exprs...)
+	}
+	var hints []protocol.InlayHint
+	for _, e := range exprs {
+		if tv, ok := info.Types[e]; !ok || tv.Value != nil || tv.IsNil() {
+			continue
+		}
+		if _, ok := e.(*ast.FuncLit); ok {
+			continue
+		}
+		end, err := m.PosPosition(tf, e.End())
+		if err != nil {
+			continue
+		}
+		hints = append(hints, protocol.InlayHint{
+			Position:    end,
+			Label:       buildLabel("evaluated at defer"),
+			PaddingLeft: true,
+		})
+	}
+	return hints
+}
+
 func assignVariableTypes(node ast.Node, m *protocol.Mapper, tf *token.File, info *types.Info, q *types.Qualifier) []protocol.InlayHint {
 	stmt, ok := node.(*ast.AssignStmt)
 	if !ok || stmt.Tok != token.DEFINE {
@@ -0,0 +1,159 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/internal/versions"
+)
+
+// This file implements queries of the module cache that need no go
+// command, such as for the completion of packages of modules that are not
+// yet required.
+//
+// The module cache holds, for each downloaded version of a module, the
+// files cache/download/<module>/@v/<version>.{mod,zip,ziphash}, and the
+// extracted module in <module>@<version>, with module paths and versions
+// escaped as by module.EscapePath and module.EscapeVersion.
+
+// FindCachedModule returns the newest version in the module cache
+// gomodcache of a module that provides the package with the given import
+// path, and whose go directive, if any, is no newer than goVersion (such
+// as "1.21"). Release versions are preferred over pre-release versions.
+// It reports false if there is no such module version.
+//
+// If several modules could provide the package, the one with the longest
+// path is chosen, as the go command would.
+func FindCachedModule(gomodcache, importPath, goVersion string) (module.Version, bool) {
+	if gomodcache == "" {
+		return module.Version{}, false
+	}
+	for modPath := importPath; modPath != "."; modPath = path.Dir(modPath) {
+		if err := module.CheckPath(modPath); err != nil {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, modPath), "/")
+		for _, v := range cachedVersions(gomodcache, modPath) {
+			mod := module.Version{Path: modPath, Version: v}
+			if providesPackage(gomodcache, mod, rel) && goCompatible(gomodcache, mod, goVersion) {
+				return mod, true
+			}
+		}
+	}
+	return module.Version{}, false
+}
+
+// cachedVersions returns the versions of the module with the given path
+// whose zip files are in the module cache, newest first, and release
+// versions before pre-release versions.
+func cachedVersions(gomodcache, modPath string) []string {
+	dir, err := downloadDir(gomodcache, modPath)
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var vs []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".ziphash") {
+			continue
+		}
+		v, err := module.UnescapeVersion(strings.TrimSuffix(name, ".ziphash"))
+		if err != nil || !semver.IsValid(v) {
+			continue
+		}
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool {
+		if pi, pj := semver.Prerelease(vs[i]) != "", semver.Prerelease(vs[j]) != ""; pi != pj {
+			return pj
+		}
+		return semver.Compare(vs[i], vs[j]) > 0
+	})
+	return vs
+}
+
+// downloadDir returns the directory of the module cache that holds the
+// downloaded files of the module with the given path.
+func downloadDir(gomodcache, modPath string) (string, error) {
+	esc, err := module.EscapePath(modPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gomodcache, "cache", "download", filepath.FromSlash(esc), "@v"), nil
+}
+
+// downloadFile returns the name of the file with the given suffix (such as
+// ".mod") among the downloaded files of a module version.
+func downloadFile(gomodcache string, mod module.Version, suffix string) (string, error) {
+	dir, err := downloadDir(gomodcache, mod.Path)
+	if err != nil {
+		return "", err
+	}
+	v, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, v+suffix), nil
+}
+
+// providesPackage reports whether the extracted module version in the
+// module cache has a package in its subdirectory rel: that is, a directory
+// containing a non-test Go file.
+func providesPackage(gomodcache string, mod module.Version, rel string) bool {
+	esc, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return false
+	}
+	v, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return false
+	}
+	entries, err := os.ReadDir(filepath.Join(gomodcache, filepath.FromSlash(esc)+"@"+v, filepath.FromSlash(rel)))
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			return true
+		}
+	}
+	return false
+}
+
+// goCompatible reports whether the go directive of the go.mod file of a
+// module version in the module cache, if any, is no newer than goVersion.
+func goCompatible(gomodcache string, mod module.Version, goVersion string) bool {
+	if goVersion == "" {
+		return true
+	}
+	filename, err := downloadFile(gomodcache, mod, ".mod")
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+	f, err := modfile.ParseLax(filename, data, nil)
+	if err != nil {
+		return false
+	}
+	if f.Go == nil {
+		return true
+	}
+	return versions.Compare("go"+f.Go.Version, "go"+goVersion) <= 0
+}
@@ -100,6 +100,11 @@ type Snapshot interface {
 	// workspace package. Otherwise, it returns symbols from all loaded packages.
 	Symbols(ctx context.Context, workspaceOnly bool) (map[protocol.DocumentURI][]Symbol, error)
 
+	// DependencySymbols returns the symbols of all packages of the modules
+	// in the module cache that provide loaded packages, including packages
+	// that are not loaded.
+	DependencySymbols(ctx context.Context) ([]*PackageSymbols, error)
+
 	// -- package metadata --
 
 	// ReverseDependencies returns a new mapping whose entries are
@@ -180,6 +185,16 @@ type Snapshot interface {
 	// by the GOPRIVATE environment variable.
 	IsGoPrivatePath(path string) bool
 
+	// GOARCH returns the target architecture of the view.
+	GOARCH() string
+
+	// GOMODCACHE returns the module cache directory of the view.
+	GOMODCACHE() string
+
+	// GoModForFile returns the URI of the go.mod file for the given URI, or
+	// "" if there is none.
+	GoModForFile(uri protocol.DocumentURI) protocol.DocumentURI
+
 	// Folder returns the folder with which this view was created.
 	Folder() protocol.DocumentURI
 
@@ -205,6 +220,7 @@ func NarrowestMetadataForFile(ctx context.Context, snapshot Snapshot, uri protoc
 
 type XrefIndex interface {
 	Lookup(targets map[PackagePath]map[objectpath.Path]struct{}) (locs []protocol.Location)
+	Referenced(targets map[PackagePath]map[objectpath.Path]struct{}) map[PackagePath]map[objectpath.Path]struct{}
 }
 
 // NarrowestPackageForFile is a convenience function that selects the narrowest
@@ -689,6 +705,8 @@ const (
 	TemplateError            DiagnosticSource = "template"
 	WorkFileError            DiagnosticSource = "go.work file"
 	ConsistencyInfo          DiagnosticSource = "consistency"
+	UnusedDeclaration        DiagnosticSource = "unused"
+	CoverageInfo             DiagnosticSource = "coverage"
 )
 
 func AnalyzerErrorKind(name string) DiagnosticSource {
@@ -0,0 +1,275 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/bug"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/safetoken"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/structlayout"
+)
+
+// This file implements the display of the memory layout of struct types
+// in hover, and the code action that reorders the fields of a struct to
+// minimize its size, using the same ordering as the fieldalignment
+// analyzer.
+
+// structLayout returns a description of the memory layout of the struct
+// type T for the given sizes: its size and alignment, and the offset,
+// size and trailing padding of each of its fields. It returns "" if the
+// layout of T cannot be computed, for example because it is generic or
+// ill-typed.
+func structLayout(sizes types.Sizes, goarch string, T types.Type, qf types.Qualifier) string {
+	if named, ok := T.(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return "" // the layout depends on the type arguments
+	}
+	str, ok := T.Underlying().(*types.Struct)
+	if !ok || !hasLayout(str) {
+		return ""
+	}
+	size, align := sizes.Sizeof(str), sizes.Alignof(str)
+	if size < 0 {
+		return "" // too large
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// size=%d, align=%d (GOARCH=%s)", size, align, goarch)
+	ls := structlayout.SizesFor(sizes)
+	if optimal, _ := structlayout.OptimalOrder(str, ls); ls.Sizeof(optimal) < ls.Sizeof(str) {
+		fmt.Fprintf(&b, "\n// size=%d with fields reordered", ls.Sizeof(optimal))
+	}
+	if str.NumFields() == 0 {
+		return b.String()
+	}
+	b.WriteString("\n")
+
+	fields := make([]*types.Var, str.NumFields())
+	for i := range fields {
+		fields[i] = str.Field(i)
+	}
+	offsets := sizes.Offsetsof(fields)
+	tw := tabwriter.NewWriter(&b, 0, 4, 1, ' ', 0)
+	for i, f := range fields {
+		fsize := sizes.Sizeof(f.Type())
+		end := size
+		if i+1 < len(fields) {
+			end = offsets[i+1]
+		}
+		decl := f.Name() + "\t" + types.TypeString(f.Type(), qf)
+		if f.Embedded() {
+			decl = types.TypeString(f.Type(), qf) + "\t"
+		}
+		fmt.Fprintf(tw, "\n%s\t// offset=%d, size=%d", decl, offsets[i], fsize)
+		if padding := end - offsets[i] - fsize; padding > 0 {
+			fmt.Fprintf(tw, ", padding=%d", padding)
+		}
+	}
+	tw.Flush()
+	return b.String()
+}
+
+// hasLayout reports whether the layout of type T can be computed: that
+// is, whether it is well-typed and not parameterized.
+func hasLayout(T types.Type) bool {
+	if _, ok := T.(*types.TypeParam); ok {
+		return false
+	}
+	switch t := T.Underlying().(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Array:
+		return hasLayout(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !hasLayout(t.Field(i).Type()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ReorderStructFields returns a code action that reorders the fields of
+// the innermost struct type enclosing rng so as to minimize its size, or
+// failing that the number of its bytes that may hold pointers. It
+// reports false if there is no such struct type, or if its fields are
+// already in an optimal order.
+//
+// Unlike the suggested fix of the fieldalignment analyzer, the action
+// preserves the comments of the fields, and is not offered if the struct
+// contains comments that are not attached to a field.
+func ReorderStructFields(pkg Package, pgf *ParsedGoFile, fh file.Handle, rng protocol.Range) (protocol.CodeAction, bool) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		bug.Reportf("(file=%v).RangePos(%v) failed: %v", pgf.URI, rng, err)
+		return protocol.CodeAction{}, false
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	var node *ast.StructType
+	for _, n := range path {
+		if st, ok := n.(*ast.StructType); ok {
+			node = st
+			break
+		}
+	}
+	if node == nil || node.Fields == nil || len(node.Fields.List) < 2 {
+		return protocol.CodeAction{}, false
+	}
+	str, ok := pkg.GetTypesInfo().TypeOf(node).(*types.Struct)
+	if !ok || !hasLayout(str) {
+		return protocol.CodeAction{}, false
+	}
+
+	sizes := structlayout.SizesFor(pkg.Metadata().TypesSizes)
+	optimal, indexes := structlayout.OptimalOrder(str, sizes)
+	var title string
+	if size, optsize := sizes.Sizeof(str), sizes.Sizeof(optimal); size != optsize {
+		title = fmt.Sprintf("Reorder fields to reduce struct size from %d to %d bytes", size, optsize)
+	} else if ptrs, optptrs := sizes.Ptrdata(str), sizes.Ptrdata(optimal); ptrs != optptrs {
+		title = fmt.Sprintf("Reorder fields to reduce pointer bytes from %d to %d", ptrs, optptrs)
+	} else {
+		return protocol.CodeAction{}, false // already optimal
+	}
+
+	fieldText, ok := structFieldText(pgf, node)
+	if !ok || len(fieldText) != len(indexes) {
+		return protocol.CodeAction{}, false
+	}
+	var newText bytes.Buffer
+	newText.WriteString("struct {\n")
+	for _, index := range indexes {
+		newText.WriteString(fieldText[index])
+		newText.WriteString("\n")
+	}
+	newText.WriteString("}")
+
+	// Format the whole file so that the fields are indented correctly,
+	// but only keep the edits within the struct.
+	startOffset, endOffset, err := safetoken.Offsets(pgf.Tok, node.Pos(), node.End())
+	if err != nil {
+		bug.Reportf("failed to get struct offsets: %v", err)
+		return protocol.CodeAction{}, false
+	}
+	var src bytes.Buffer
+	src.Write(pgf.Src[:startOffset])
+	src.Write(newText.Bytes())
+	src.Write(pgf.Src[endOffset:])
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return protocol.CodeAction{}, false
+	}
+	var edits []diff.Edit
+	for _, edit := range diff.Bytes(pgf.Src, formatted) {
+		if edit.End >= startOffset && edit.Start <= endOffset {
+			edits = append(edits, edit)
+		}
+	}
+	pedits, err := protocol.EditsFromDiffEdits(pgf.Mapper, edits)
+	if err != nil {
+		bug.Reportf("failed to convert diff.Edit to protocol.TextEdit: %v", err)
+		return protocol.CodeAction{}, false
+	}
+	return protocol.CodeAction{
+		Title: title,
+		Kind:  protocol.RefactorRewrite,
+		Edit: &protocol.WorkspaceEdit{
+			DocumentChanges: []protocol.DocumentChanges{
+				{
+					TextDocumentEdit: &protocol.TextDocumentEdit{
+						TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+							Version:                fh.Version(),
+							TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: fh.URI()},
+						},
+						Edits: pedits,
+					},
+				},
+			},
+		},
+	}, true
+}
+
+// structFieldText returns the source text of each field of the struct
+// type node, in the order of the fields of its type, including their doc
+// and line comments. Fields declared with several names are split into
+// one field per name. It reports false if the struct holds comments that
+// are not attached to a field.
+func structFieldText(pgf *ParsedGoFile, node *ast.StructType) ([]string, bool) {
+	text := func(n ast.Node) (string, bool) {
+		start, end, err := safetoken.Offsets(pgf.Tok, n.Pos(), n.End())
+		if err != nil {
+			return "", false
+		}
+		return string(pgf.Src[start:end]), true
+	}
+
+	// Comments attached to fields, and those within field types, are
+	// preserved.
+	attached := make(map[*ast.CommentGroup]bool)
+	var fields []string
+	for _, f := range node.Fields.List {
+		var doc, comment string
+		if f.Doc != nil {
+			attached[f.Doc] = true
+			t, ok := text(f.Doc)
+			if !ok {
+				return nil, false
+			}
+			doc = t + "\n"
+		}
+		if f.Comment != nil {
+			attached[f.Comment] = true
+			t, ok := text(f.Comment)
+			if !ok {
+				return nil, false
+			}
+			comment = " " + t
+		}
+		for _, cg := range pgf.File.Comments {
+			if f.Pos() <= cg.Pos() && cg.End() <= f.End() {
+				attached[cg] = true
+			}
+		}
+
+		if len(f.Names) <= 1 {
+			t, ok := text(f)
+			if !ok {
+				return nil, false
+			}
+			fields = append(fields, doc+t+comment)
+			continue
+		}
+		typ, ok := text(f.Type)
+		if !ok {
+			return nil, false
+		}
+		if f.Tag != nil {
+			typ += " " + f.Tag.Value
+		}
+		for i, name := range f.Names {
+			if i == 0 {
+				fields = append(fields, doc+name.Name+" "+typ+comment)
+			} else {
+				fields = append(fields, name.Name+" "+typ)
+			}
+		}
+	}
+	for _, cg := range pgf.File.Comments {
+		if node.Fields.Opening < cg.Pos() && cg.End() < node.Fields.Closing && !attached[cg] {
+			return nil, false
+		}
+	}
+	return fields, true
+}
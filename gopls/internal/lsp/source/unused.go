@@ -0,0 +1,563 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+// This file implements the unusedDeclarations diagnostics.
+//
+// A package-level declaration or struct field is unused if nothing in
+// the workspace refers to it, other than its own declaration.
+// References within the declaring package are found by inspecting the
+// type checker's Uses map. References to exported declarations from
+// other packages are found in the cross-reference (xrefs) indexes of
+// the declaring package's reverse dependencies within the workspace.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/types/objectpath"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/safetoken"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/internal/event"
+)
+
+// UnusedDeclarations reports unused package-level declarations and
+// struct fields in the specified packages, according to the
+// unusedDeclarations setting. Each diagnostic offers a quick fix to
+// delete the declaration, where this can be done safely.
+func UnusedDeclarations(ctx context.Context, snapshot Snapshot, pkgIDs map[PackageID]unit) (map[protocol.DocumentURI][]*Diagnostic, error) {
+	opts := snapshot.Options()
+	mode := opts.UnusedDeclarations
+	if mode == "" || mode == settings.UnusedDeclarationsOff || len(pkgIDs) == 0 {
+		return nil, nil
+	}
+
+	ctx, done := event.Start(ctx, "source.UnusedDeclarations")
+	defer done()
+
+	// Check only the widest variant of each package, which
+	// includes the in-package test files, if any.
+	widest := make(map[PackagePath]*Metadata)
+	for id := range pkgIDs {
+		m := snapshot.Metadata(id)
+		if m == nil || m.IsIntermediateTestVariant() {
+			continue
+		}
+		if w := widest[m.PkgPath]; w == nil || len(m.CompiledGoFiles) > len(w.CompiledGoFiles) {
+			widest[m.PkgPath] = m
+		}
+	}
+	var ids []PackageID
+	for _, m := range widest {
+		ids = append(ids, m.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	pkgs, err := snapshot.TypeCheck(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make(map[protocol.DocumentURI][]*Diagnostic)
+	for _, pkg := range pkgs {
+		// Within a package containing errors, references may be
+		// missing, and the declarations under edit.
+		if len(pkg.GetParseErrors()) > 0 || len(pkg.GetTypeErrors()) > 0 {
+			continue
+		}
+		diags, err := unusedInPackage(ctx, snapshot, pkg, mode, opts.UnusedDeclarationsAllowlist)
+		if err != nil {
+			return nil, err
+		}
+		for _, diag := range diags {
+			reports[diag.URI] = append(reports[diag.URI], diag)
+		}
+	}
+	return reports, nil
+}
+
+// An unusedCandidate is a declaration that may be reported as unused.
+type unusedCandidate struct {
+	obj   types.Object
+	kind  string // "function", "type", "constant", or "field"
+	pgf   *ParsedGoFile
+	ident *ast.Ident
+
+	// For a field, parent is the declaration of its struct type.
+	// Fields are not reported when their type is unused too.
+	parent *unusedCandidate
+
+	// Uses of obj within [declStart, declEnd) do not count,
+	// such as recursive calls or self-referential types.
+	declStart, declEnd token.Pos
+
+	// delete is the edit that deletes the declaration,
+	// or nil if it cannot be deleted safely.
+	delete *protocol.TextEdit
+}
+
+// unusedInPackage returns a diagnostic for each unused declaration of
+// the type-checked package.
+func unusedInPackage(ctx context.Context, snapshot Snapshot, pkg Package, mode settings.UnusedDeclarationsMode, allowlist []string) ([]*Diagnostic, error) {
+	var (
+		tpkg       = pkg.GetTypes()
+		info       = pkg.GetTypesInfo()
+		candidates = make(map[token.Pos]*unusedCandidate) // keyed by declaring position
+		used       = make(map[token.Pos]bool)
+	)
+
+	allowed := func(name string) bool {
+		qualified := tpkg.Path() + "." + name
+		for _, pattern := range allowlist {
+			if ok, _ := path.Match(pattern, qualified); ok {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(c *unusedCandidate, name string) {
+		if c.ident.Name == "_" || c.obj == nil {
+			return
+		}
+		if mode != settings.UnusedDeclarationsAll && c.obj.Exported() {
+			return
+		}
+		if allowed(name) {
+			return
+		}
+		candidates[c.obj.Pos()] = c
+	}
+
+	// Symbols named by a //go:linkname directive may be referenced
+	// from other packages without a visible reference.
+	linknamed := make(map[string]bool)
+	for _, pgf := range pkg.CompiledGoFiles() {
+		for _, cg := range pgf.File.Comments {
+			for _, c := range cg.List {
+				if strings.HasPrefix(c.Text, "//go:linkname ") {
+					if fields := strings.Fields(c.Text); len(fields) >= 2 {
+						linknamed[fields[1]] = true
+					}
+				}
+			}
+		}
+	}
+
+	for _, pgf := range pkg.CompiledGoFiles() {
+		if pgf.Fixed() || IsGenerated(ctx, snapshot, pgf.URI) {
+			continue // uses are still counted below
+		}
+		isTestFile := strings.HasSuffix(pgf.URI.Path(), "_test.go")
+
+		for _, decl := range pgf.File.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				name := decl.Name.Name
+				if decl.Recv != nil || // methods may be called through interfaces
+					decl.Body == nil || // implemented in assembly
+					name == "init" ||
+					name == "main" && tpkg.Name() == "main" ||
+					isTestFile && isTestFuncName(name) ||
+					hasExportDirective(decl.Doc) ||
+					linknamed[name] {
+					continue
+				}
+				start := decl.Pos()
+				if decl.Doc != nil {
+					start = decl.Doc.Pos()
+				}
+				add(&unusedCandidate{
+					obj:       info.Defs[decl.Name],
+					kind:      "function",
+					pgf:       pgf,
+					ident:     decl.Name,
+					declStart: decl.Pos(),
+					declEnd:   decl.End(),
+					delete:    deleteLines(pgf, start, decl.End(), true),
+				}, name)
+
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						typ := &unusedCandidate{
+							obj:       info.Defs[spec.Name],
+							kind:      "type",
+							pgf:       pgf,
+							ident:     spec.Name,
+							declStart: spec.Pos(),
+							declEnd:   spec.End(),
+							delete:    deleteSpec(pgf, decl, spec, spec.Doc),
+						}
+						add(typ, spec.Name.Name)
+
+						if st, ok := spec.Type.(*ast.StructType); ok {
+							addFields(pgf, info, typ, st, add)
+						}
+
+					case *ast.ValueSpec:
+						if decl.Tok != token.CONST {
+							continue // variable initializers may have effects
+						}
+						var del *protocol.TextEdit
+						if len(spec.Names) == 1 && (len(decl.Specs) == 1 || !isImplicitConstGroup(decl)) {
+							del = deleteSpec(pgf, decl, spec, spec.Doc)
+						}
+						for _, id := range spec.Names {
+							add(&unusedCandidate{
+								obj:       info.Defs[id],
+								kind:      "constant",
+								pgf:       pgf,
+								ident:     id,
+								declStart: spec.Pos(),
+								declEnd:   spec.End(),
+								delete:    del,
+							}, id.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Count references within the package. Comparing declaring
+	// positions rather than objects accounts for the fields of
+	// instantiated generic types.
+	for id, obj := range info.Uses {
+		if obj.Pkg() != tpkg {
+			continue
+		}
+		if c, ok := candidates[obj.Pos()]; ok && !(c.declStart <= id.Pos() && id.Pos() < c.declEnd) {
+			used[obj.Pos()] = true
+		}
+	}
+
+	// An unkeyed composite literal refers to every field of its struct.
+	for _, pgf := range pkg.CompiledGoFiles() {
+		ast.Inspect(pgf.File, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok || len(lit.Elts) == 0 {
+				return true
+			}
+			if _, keyed := lit.Elts[0].(*ast.KeyValueExpr); keyed {
+				return true
+			}
+			if tv, ok := info.Types[lit]; ok {
+				if st, ok := tv.Type.Underlying().(*types.Struct); ok {
+					for i := 0; i < st.NumFields(); i++ {
+						used[st.Field(i).Pos()] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	var unused []*unusedCandidate
+	for pos, c := range candidates {
+		if !used[pos] {
+			unused = append(unused, c)
+		}
+	}
+
+	// Exported declarations may be referenced by other packages.
+	if mode == settings.UnusedDeclarationsAll {
+		var err error
+		unused, err = removeExternallyUsed(ctx, snapshot, pkg, unused)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].ident.Pos() < unused[j].ident.Pos()
+	})
+	isUnused := make(map[*unusedCandidate]bool)
+	for _, c := range unused {
+		isUnused[c] = true
+	}
+	var diags []*Diagnostic
+	for _, c := range unused {
+		if c.parent != nil && isUnused[c.parent] {
+			continue
+		}
+		rng, err := c.pgf.NodeRange(c.ident)
+		if err != nil {
+			return nil, err
+		}
+		diag := &Diagnostic{
+			URI:      c.pgf.URI,
+			Range:    rng,
+			Severity: protocol.SeverityInformation,
+			Source:   UnusedDeclaration,
+			Message:  fmt.Sprintf("%s %s is unused", c.kind, c.ident.Name),
+			Tags:     []protocol.DiagnosticTag{protocol.Unnecessary},
+		}
+		if c.delete != nil {
+			diag.SuggestedFixes = []SuggestedFix{{
+				Title:      fmt.Sprintf("Delete unused %s %s", c.kind, c.ident.Name),
+				Edits:      map[protocol.DocumentURI][]protocol.TextEdit{c.pgf.URI: {*c.delete}},
+				ActionKind: protocol.QuickFix,
+			}}
+		}
+		diags = append(diags, diag)
+	}
+	return diags, nil
+}
+
+// addFields adds a candidate for each eligible field of the struct type
+// declared by the named type spec.
+//
+// Embedded fields are promoted, and tagged fields are typically accessed
+// through reflection, so neither is eligible.
+func addFields(pgf *ParsedGoFile, info *types.Info, parent *unusedCandidate, st *ast.StructType, add func(*unusedCandidate, string)) {
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 || field.Tag != nil {
+			continue
+		}
+		for i, id := range field.Names {
+			var del *protocol.TextEdit
+			if len(field.Names) == 1 {
+				start := field.Pos()
+				if field.Doc != nil {
+					start = field.Doc.Pos()
+				}
+				del = deleteLines(pgf, start, field.End(), false)
+			} else if i+1 < len(field.Names) {
+				del = deleteRange(pgf, id.Pos(), field.Names[i+1].Pos()) // "f, "
+			} else {
+				del = deleteRange(pgf, field.Names[i-1].End(), id.End()) // ", f"
+			}
+			add(&unusedCandidate{
+				obj:       info.Defs[id],
+				kind:      "field",
+				pgf:       pgf,
+				ident:     id,
+				declStart: field.Pos(),
+				declEnd:   field.End(),
+				delete:    del,
+				parent:    parent,
+			}, parent.ident.Name+"."+id.Name)
+		}
+	}
+}
+
+// removeExternallyUsed returns the subset of the unused candidates of pkg
+// that are not referenced by any other workspace package.
+func removeExternallyUsed(ctx context.Context, snapshot Snapshot, pkg Package, unused []*unusedCandidate) ([]*unusedCandidate, error) {
+	pkgPath := pkg.Metadata().PkgPath
+	targets := map[PackagePath]map[objectpath.Path]struct{}{pkgPath: {}}
+	paths := make(map[*unusedCandidate]objectpath.Path)
+	var result []*unusedCandidate
+	for _, c := range unused {
+		if !c.obj.Exported() {
+			result = append(result, c)
+			continue
+		}
+		p, err := objectpath.For(c.obj)
+		if err != nil {
+			continue // not addressable from other packages; assume used
+		}
+		paths[c] = p
+		targets[pkgPath][p] = struct{}{}
+	}
+	if len(paths) == 0 {
+		return result, nil
+	}
+
+	// Find the workspace packages that depend on any variant of pkg.
+	workspace, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inWorkspace := make(map[PackageID]bool)
+	for _, m := range workspace {
+		inWorkspace[m.ID] = true
+	}
+	variants, err := snapshot.MetadataForFile(ctx, pkg.Metadata().CompiledGoFiles[0])
+	if err != nil {
+		return nil, err
+	}
+	rdeps := make(map[PackageID]bool)
+	for _, v := range variants {
+		if v.PkgPath != pkgPath {
+			continue
+		}
+		deps, err := snapshot.ReverseDependencies(ctx, v.ID, true)
+		if err != nil {
+			return nil, err
+		}
+		for id, m := range deps {
+			if m.PkgPath != pkgPath && inWorkspace[id] {
+				rdeps[id] = true
+			}
+		}
+	}
+	var ids []PackageID
+	for id := range rdeps {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	referenced := make(map[objectpath.Path]bool)
+	if len(ids) > 0 {
+		indexes, err := snapshot.References(ctx, ids...)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indexes {
+			for p := range index.Referenced(targets)[pkgPath] {
+				referenced[p] = true
+			}
+		}
+	}
+	for c, p := range paths {
+		if !referenced[p] {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// deleteSpec returns the edit that deletes spec from decl, or the entire
+// declaration if spec is its only spec.
+func deleteSpec(pgf *ParsedGoFile, decl *ast.GenDecl, spec ast.Spec, doc *ast.CommentGroup) *protocol.TextEdit {
+	if len(decl.Specs) == 1 {
+		start := decl.Pos()
+		if decl.Doc != nil {
+			start = decl.Doc.Pos()
+		}
+		return deleteLines(pgf, start, decl.End(), true)
+	}
+	start := spec.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	return deleteLines(pgf, start, spec.End(), false)
+}
+
+// deleteLines returns the edit that deletes the lines spanned by the
+// source range [start, end), including any trailing line comment.
+// It returns nil if the range does not span entire lines.
+//
+// If deleting the lines would leave two consecutive blank lines,
+// and joinBlank is set, one of them is deleted too.
+func deleteLines(pgf *ParsedGoFile, start, end token.Pos, joinBlank bool) *protocol.TextEdit {
+	startOffset, endOffset, err := safetoken.Offsets(pgf.Tok, start, end)
+	if err != nil {
+		return nil
+	}
+	src := pgf.Src
+	for startOffset > 0 && (src[startOffset-1] == ' ' || src[startOffset-1] == '\t') {
+		startOffset--
+	}
+	if startOffset > 0 && src[startOffset-1] != '\n' {
+		return nil
+	}
+	for endOffset < len(src) && (src[endOffset] == ' ' || src[endOffset] == '\t') {
+		endOffset++
+	}
+	if strings.HasPrefix(string(src[endOffset:]), "//") {
+		for endOffset < len(src) && src[endOffset] != '\n' {
+			endOffset++
+		}
+	}
+	if endOffset < len(src) {
+		if src[endOffset] != '\n' {
+			return nil
+		}
+		endOffset++
+	}
+	if joinBlank && endOffset < len(src) && src[endOffset] == '\n' &&
+		(startOffset == 0 || startOffset >= 2 && src[startOffset-2] == '\n') {
+		endOffset++
+	}
+	rng, err := pgf.Mapper.OffsetRange(startOffset, endOffset)
+	if err != nil {
+		return nil
+	}
+	return &protocol.TextEdit{Range: rng}
+}
+
+// deleteRange returns the edit that deletes the source range [start, end).
+func deleteRange(pgf *ParsedGoFile, start, end token.Pos) *protocol.TextEdit {
+	rng, err := pgf.PosRange(start, end)
+	if err != nil {
+		return nil
+	}
+	return &protocol.TextEdit{Range: rng}
+}
+
+// isImplicitConstGroup reports whether the constant declaration uses iota
+// or omits the values of some specs, so that the meaning of its specs
+// depends on their position within the group.
+func isImplicitConstGroup(decl *ast.GenDecl) bool {
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		if len(spec.Values) == 0 {
+			return true
+		}
+		for _, v := range spec.Values {
+			usesIota := false
+			ast.Inspect(v, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && id.Name == "iota" {
+					usesIota = true
+				}
+				return !usesIota
+			})
+			if usesIota {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTestFuncName reports whether name is the name of a function that is
+// called by the go test driver.
+func isTestFuncName(name string) bool {
+	if name == "TestMain" {
+		return true
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		if rest == "" || prefix == "Example" {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		if !unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExportDirective reports whether doc contains a cgo //export directive.
+func hasExportDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, "//export ") {
+			return true
+		}
+	}
+	return false
+}
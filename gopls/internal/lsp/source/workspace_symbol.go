@@ -7,6 +7,7 @@ package source
 import (
 	"context"
 	"fmt"
+	"go/token"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -30,6 +31,14 @@ type Symbol struct {
 	Range protocol.Range
 }
 
+// A PackageSymbols holds the symbols of the files of a package that need
+// not be loaded, such as a package in the module cache.
+type PackageSymbols struct {
+	PkgPath PackagePath
+	Name    PackageName
+	Files   map[protocol.DocumentURI][]Symbol
+}
+
 // maxSymbols defines the maximum number of symbol results that should ever be
 // sent in response to a client.
 const maxSymbols = 100
@@ -256,6 +265,205 @@ func matchExact(exact string) matcherFunc {
 	}
 }
 
+// matchAll is the matcherFunc used for a query that consists only of
+// filters. It matches the empty string at the end of the symbol, so that
+// symbolizers treat the match as being within the unqualified name.
+func matchAll(chunks []string) (int, float64) {
+	n := 0
+	for _, chunk := range chunks {
+		n += len(chunk)
+	}
+	return n, 1
+}
+
+// A symbolFilter restricts the symbols matched by a workspace symbol query
+// according to properties other than their names. Within each category of
+// filter, a symbol need match only one of the filters.
+type symbolFilter struct {
+	kinds      []string         // "type", "func", "method", "field", "var", "const"
+	pkgs       []*regexp.Regexp // package path patterns
+	qualifiers []string         // names of enclosing types or packages
+	exported   bool             // match only exported symbols
+	unexported bool             // match only unexported symbols
+}
+
+// parseFilters extracts the filters from the fields of a workspace symbol
+// query, and returns them along with the rest of the query. The filters
+// are:
+//
+//	type:, func:, method:,     match only symbols of that kind; any text
+//	field:, var:, const:       following the colon is a query field
+//	pkg:pattern                match only symbols of packages whose path
+//	                           matches pattern (see matchPackagePattern)
+//	Name.                      match only the fields and methods of type
+//	                           Name, or the members of package Name
+//	is:exported, is:unexported match only (un)exported symbols
+//
+// If query contains no filters, it is returned unchanged.
+func parseFilters(query string) (symbolFilter, string) {
+	var (
+		filter symbolFilter
+		rest   []string
+		found  bool
+	)
+	for _, field := range strings.Fields(query) {
+		if k, v, ok := strings.Cut(field, ":"); ok {
+			switch k {
+			case "type", "func", "method", "field", "var", "const":
+				filter.kinds = append(filter.kinds, k)
+				found = true
+				if v != "" {
+					rest = append(rest, v)
+				}
+				continue
+			case "pkg":
+				if v != "" {
+					filter.pkgs = append(filter.pkgs, packagePatternRegexp(v))
+					found = true
+					continue
+				}
+			case "is":
+				switch v {
+				case "exported":
+					filter.exported = true
+					found = true
+					continue
+				case "unexported":
+					filter.unexported = true
+					found = true
+					continue
+				}
+			}
+		}
+		if qual := strings.TrimSuffix(field, "."); qual != field && token.IsIdentifier(qual) {
+			filter.qualifiers = append(filter.qualifiers, qual)
+			found = true
+			continue
+		}
+		rest = append(rest, field)
+	}
+	if !found {
+		return symbolFilter{}, query
+	}
+	return filter, strings.Join(rest, " ")
+}
+
+// packagePatternRegexp returns a regular expression for the package path
+// pattern of a pkg: filter. As with the go command, "..." matches any
+// string, and a trailing "/..." also matches the empty string. A pattern
+// without "..." matches the packages whose path is the pattern or ends in
+// "/" followed by the pattern, so that "http" matches "net/http".
+func packagePatternRegexp(pattern string) *regexp.Regexp {
+	if !strings.Contains(pattern, "...") {
+		return regexp.MustCompile(`^(.*/)?` + regexp.QuoteMeta(pattern) + `$`)
+	}
+	var re strings.Builder
+	re.WriteString("^")
+	trailing := strings.HasSuffix(pattern, "/...")
+	if trailing {
+		pattern = strings.TrimSuffix(pattern, "/...")
+	}
+	for i, part := range strings.Split(pattern, "...") {
+		if i > 0 {
+			re.WriteString(".*")
+		}
+		re.WriteString(regexp.QuoteMeta(part))
+	}
+	if trailing {
+		re.WriteString("(/.*)?")
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String())
+}
+
+// isZero reports whether f matches every symbol.
+func (f *symbolFilter) isZero() bool {
+	return len(f.kinds) == 0 && len(f.pkgs) == 0 && len(f.qualifiers) == 0 && !f.exported && !f.unexported
+}
+
+// matches reports whether the symbol sym of package md satisfies f.
+func (f *symbolFilter) matches(sym Symbol, md *Metadata) bool {
+	if len(f.kinds) > 0 {
+		ok := false
+		for _, kind := range f.kinds {
+			if matchKind(kind, sym) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(f.pkgs) > 0 {
+		ok := false
+		for _, re := range f.pkgs {
+			if re.MatchString(string(md.PkgPath)) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(f.qualifiers) > 0 {
+		ok := false
+		for _, qual := range f.qualifiers {
+			if string(md.Name) == qual || strings.HasPrefix(sym.Name, qual+".") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.exported || f.unexported {
+		exported := isExportedSymbol(sym.Name)
+		if f.exported && !exported || f.unexported && exported {
+			return false
+		}
+	}
+	return true
+}
+
+// matchKind reports whether sym is of the kind named by a kind filter.
+// Types, functions, variables and constants are only those declared at
+// package level.
+func matchKind(kind string, sym Symbol) bool {
+	member := strings.Contains(sym.Name, ".")
+	switch kind {
+	case "type":
+		switch sym.Kind {
+		case protocol.Class, protocol.Struct, protocol.Interface:
+			return !member
+		}
+	case "func":
+		return sym.Kind == protocol.Function && !member
+	case "method":
+		return sym.Kind == protocol.Method
+	case "field":
+		return sym.Kind == protocol.Field
+	case "var":
+		return sym.Kind == protocol.Variable && !member
+	case "const":
+		return sym.Kind == protocol.Constant && !member
+	}
+	return false
+}
+
+// isExportedSymbol reports whether each of the dot-separated components of
+// a symbol name is exported.
+func isExportedSymbol(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !token.IsExported(part) {
+			return false
+		}
+	}
+	return true
+}
+
 // smartCase returns a matcherFunc that is case-sensitive if q contains any
 // upper-case characters, and case-insensitive otherwise.
 func smartCase(q string, m matcherFunc) matcherFunc {
@@ -296,7 +504,12 @@ func (c comboMatcher) match(chunks []string) (int, float64) {
 //     of zero indicates no match.
 //   - A symbolizer determines how we extract the symbol for an object. This
 //     enables the 'symbolStyle' configuration option.
+//
+// Before matching, the filters of the query (see parseFilters) are
+// extracted from it. Symbols that do not satisfy the filters are skipped.
 func collectSymbols(ctx context.Context, snapshots []Snapshot, matcherType settings.SymbolMatcher, symbolizer symbolizer, query string) ([]protocol.SymbolInformation, error) {
+	filter, query := parseFilters(query)
+
 	// Extract symbols from all files.
 	var work []symbolFile
 	var roots []string
@@ -312,10 +525,8 @@ func collectSymbols(ctx context.Context, snapshots []Snapshot, matcherType setti
 		filterer := NewFilterer(filters)
 		folder := filepath.ToSlash(folderURI.Path())
 
-		workspaceOnly := true
-		if snapshot.Options().SymbolScope == settings.AllSymbolScope {
-			workspaceOnly = false
-		}
+		scope := snapshot.Options().SymbolScope
+		workspaceOnly := scope != settings.AllSymbolScope && scope != settings.DependencySymbolScope
 		symbols, err := snapshot.Symbols(ctx, workspaceOnly)
 		if err != nil {
 			return nil, err
@@ -339,6 +550,29 @@ func collectSymbols(ctx context.Context, snapshots []Snapshot, matcherType setti
 			seen[uri] = true
 			work = append(work, symbolFile{uri, meta, syms})
 		}
+
+		if scope == settings.DependencySymbolScope {
+			pkgs, err := snapshot.DependencySymbols(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, pkg := range pkgs {
+				// Files of loaded packages have already been seen, so
+				// only the package path and name are needed for matching.
+				meta := &Metadata{
+					ID:      PackageID(pkg.PkgPath),
+					PkgPath: pkg.PkgPath,
+					Name:    pkg.Name,
+				}
+				for uri, syms := range pkg.Files {
+					if seen[uri] || filterer.Disallow(strings.TrimPrefix(filepath.ToSlash(uri.Path()), folder)) {
+						continue
+					}
+					seen[uri] = true
+					work = append(work, symbolFile{uri, meta, syms})
+				}
+			}
+		}
 	}
 
 	// Match symbols in parallel.
@@ -348,11 +582,14 @@ func collectSymbols(ctx context.Context, snapshots []Snapshot, matcherType setti
 	results := make(chan *symbolStore)
 	for i := 0; i < nmatchers; i++ {
 		go func(i int) {
-			matcher := buildMatcher(matcherType, query)
+			matcher := matcherFunc(matchAll)
+			if query != "" || filter.isZero() {
+				matcher = buildMatcher(matcherType, query)
+			}
 			store := new(symbolStore)
 			// Assign files to workers in round-robin fashion.
 			for j := i; j < len(work); j += nmatchers {
-				matchFile(store, symbolizer, matcher, roots, work[j])
+				matchFile(store, symbolizer, matcher, &filter, roots, work[j])
 			}
 			results <- store
 		}(i)
@@ -448,9 +685,13 @@ type symbolFile struct {
 }
 
 // matchFile scans a symbol file and adds matching symbols to the store.
-func matchFile(store *symbolStore, symbolizer symbolizer, matcher matcherFunc, roots []string, i symbolFile) {
+func matchFile(store *symbolStore, symbolizer symbolizer, matcher matcherFunc, filter *symbolFilter, roots []string, i symbolFile) {
 	space := make([]string, 0, 3)
+	filtered := !filter.isZero()
 	for _, sym := range i.syms {
+		if filtered && !filter.matches(sym, i.md) {
+			continue
+		}
 		symbolParts, score := symbolizer(space, sym.Name, i.md, matcher)
 
 		// Check if the score is too low before applying any downranking.
@@ -6,6 +6,8 @@ package source
 
 import (
 	"testing"
+
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
 )
 
 func TestParseQuery(t *testing.T) {
@@ -45,6 +47,52 @@ func TestParseQuery(t *testing.T) {
 	}
 }
 
+func TestParseFilters(t *testing.T) {
+	pkg := &Metadata{PkgPath: "example.com/net/http", Name: "http"}
+	tests := []struct {
+		query    string
+		sym      Symbol
+		wantRest string
+		want     bool
+	}{
+		{"Serve", Symbol{Name: "Server", Kind: protocol.Struct}, "Serve", true},
+		{"type:Serve", Symbol{Name: "Server", Kind: protocol.Struct}, "Serve", true},
+		{"type:", Symbol{Name: "ListenAndServe", Kind: protocol.Function}, "", false},
+		{"func: type: Serve", Symbol{Name: "ListenAndServe", Kind: protocol.Function}, "Serve", true},
+		{"func:", Symbol{Name: "Server.Close", Kind: protocol.Method}, "", false},
+		{"method:Close", Symbol{Name: "Server.Close", Kind: protocol.Method}, "Close", true},
+		{"field:", Symbol{Name: "Server.Addr", Kind: protocol.Field}, "", true},
+		{"var:", Symbol{Name: "ErrClosed", Kind: protocol.Variable}, "", true},
+		{"const:", Symbol{Name: "ErrClosed", Kind: protocol.Variable}, "", false},
+		{"Server. Cl", Symbol{Name: "Server.Close", Kind: protocol.Method}, "Cl", true},
+		{"Server.", Symbol{Name: "Client.Close", Kind: protocol.Method}, "", false},
+		{"http.", Symbol{Name: "Client.Close", Kind: protocol.Method}, "", true},
+		{"Server.Close", Symbol{Name: "Server.Close", Kind: protocol.Method}, "Server.Close", true},
+		{"pkg:http", Symbol{Name: "Server", Kind: protocol.Struct}, "", true},
+		{"pkg:net/http", Symbol{Name: "Server", Kind: protocol.Struct}, "", true},
+		{"pkg:ttp", Symbol{Name: "Server", Kind: protocol.Struct}, "", false},
+		{"pkg:example.com/net/...", Symbol{Name: "Server", Kind: protocol.Struct}, "", true},
+		{"pkg:example.com/net/http/...", Symbol{Name: "Server", Kind: protocol.Struct}, "", true},
+		{"pkg:.../h...", Symbol{Name: "Server", Kind: protocol.Struct}, "", true},
+		{"pkg:example.com/x/...", Symbol{Name: "Server", Kind: protocol.Struct}, "", false},
+		{"pkg:json pkg:http", Symbol{Name: "Server", Kind: protocol.Struct}, "", true},
+		{"is:exported", Symbol{Name: "Server.Addr", Kind: protocol.Field}, "", true},
+		{"is:exported", Symbol{Name: "Server.mu", Kind: protocol.Field}, "", false},
+		{"is:unexported", Symbol{Name: "Server.mu", Kind: protocol.Field}, "", true},
+		{"is:other", Symbol{Name: "Server", Kind: protocol.Struct}, "is:other", true},
+	}
+
+	for _, test := range tests {
+		filter, rest := parseFilters(test.query)
+		if rest != test.wantRest {
+			t.Errorf("parseFilters(%q) returned query %q, want %q", test.query, rest, test.wantRest)
+		}
+		if got := filter.matches(test.sym, pkg); got != test.want {
+			t.Errorf("parseFilters(%q) match for %s: %t, want %t", test.query, test.sym.Name, got, test.want)
+		}
+	}
+}
+
 func TestFiltererDisallow(t *testing.T) {
 	tests := []struct {
 		filters  []string
@@ -160,6 +160,32 @@ func Lookup(m *source.Metadata, data []byte, targets map[source.PackagePath]map[
 	return locs
 }
 
+// Referenced searches a serialized index produced by an indexPackage
+// operation, and returns the subset of the target set to which it
+// contains at least one reference. It is cheaper than Lookup when the
+// locations of the references are not needed.
+func Referenced(data []byte, targets map[source.PackagePath]map[objectpath.Path]struct{}) map[source.PackagePath]map[objectpath.Path]struct{} {
+	var packages []*gobPackage
+	packageCodec.Decode(data, &packages)
+	var found map[source.PackagePath]map[objectpath.Path]struct{}
+	for _, gp := range packages {
+		if objectSet, ok := targets[gp.PkgPath]; ok {
+			for _, gobObj := range gp.Objects {
+				if _, ok := objectSet[gobObj.Path]; ok && len(gobObj.Refs) > 0 {
+					if found == nil {
+						found = make(map[source.PackagePath]map[objectpath.Path]struct{})
+					}
+					if found[gp.PkgPath] == nil {
+						found[gp.PkgPath] = make(map[objectpath.Path]struct{})
+					}
+					found[gp.PkgPath][gobObj.Path] = struct{}{}
+				}
+			}
+		}
+	}
+	return found
+}
+
 // -- serialized representation --
 
 // The cross-reference index records the location of all references
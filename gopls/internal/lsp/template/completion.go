@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"go/scanner"
 	"go/token"
+	"go/types"
 	"strings"
 
 	"golang.org/x/tools/gopls/internal/file"
@@ -24,6 +25,7 @@ type completer struct {
 	offset int // offset of the start of the Token
 	ctx    protocol.CompletionContext
 	syms   map[string]symbol
+	info   *typeInfo // nil unless the template has a type directive
 }
 
 func Completion(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pos protocol.Position, context protocol.CompletionContext) (*protocol.CompletionList, error) {
@@ -52,10 +54,31 @@ func Completion(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, p
 		offset: start + len(Left),
 		ctx:    context,
 		syms:   syms,
+		info:   typeCheck(ctx, snapshot, parseWithout(p, start)),
 	}
 	return c.complete()
 }
 
+// parseWithout returns p, or if it has a parse error, the parse of its
+// contents with the token starting at start blanked out, as the token
+// being completed is often incomplete.
+func parseWithout(p *Parsed, start int) *Parsed {
+	if p.ParseErr == nil {
+		return p
+	}
+	i := p.tokenAt(start)
+	if i < 0 {
+		return p
+	}
+	buf := append([]byte(nil), p.buf...)
+	for j := p.tokens[i].Start; j < p.tokens[i].End; j++ {
+		if buf[j] != '\n' {
+			buf[j] = ' '
+		}
+	}
+	return parseBuffer(buf)
+}
+
 func filterSyms(syms map[string]symbol, ns []symbol) {
 	for _, xsym := range ns {
 		switch xsym.kind {
@@ -143,6 +166,10 @@ func (c *completer) complete() (*protocol.CompletionList, error) {
 		return ans, nil
 	}
 	if pattern[0] == '.' {
+		if items, ok := c.typedMembers(start); ok {
+			ans.Items = items
+			return ans, nil
+		}
 		for _, s := range c.syms {
 			if s.kind == protocol.Method && weakMatch("."+s.name, pattern) > 0 {
 				ans.Items = append(ans.Items, protocol.CompletionItem{
@@ -190,6 +217,65 @@ func (c *completer) complete() (*protocol.CompletionList, error) {
 	return ans, nil
 }
 
+// typedMembers returns the fields and methods that complete the chain of
+// selections ending at offset, such as .Author.Na or $.Title, if the type
+// of its start is known.
+func (c *completer) typedMembers(offset int) ([]protocol.CompletionItem, bool) {
+	if c.info == nil {
+		return nil, false
+	}
+	i := offset
+	for i > c.offset && isChainByte(c.p.buf[i-1]) {
+		i--
+	}
+	names := strings.Split(string(c.p.buf[i:offset]), ".")
+	if len(names) < 2 {
+		return nil, false
+	}
+	sc := c.info.scopeAt(i)
+	if sc == nil {
+		return nil, false
+	}
+	var t types.Type
+	switch names[0] {
+	case "":
+		t = sc.dot
+	case "$":
+		t = sc.root
+	}
+	for _, name := range names[1 : len(names)-1] {
+		if t == nil {
+			break
+		}
+		_, t, _ = lookupField(t, name)
+	}
+	if t == nil {
+		return nil, false
+	}
+	prefix := strings.ToLower(names[len(names)-1])
+	items := []protocol.CompletionItem{}
+	for _, obj := range members(t) {
+		if !strings.HasPrefix(strings.ToLower(obj.Name()), prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{
+			Label:  obj.Name(),
+			Kind:   protocol.FieldCompletion,
+			Detail: typeString(obj.Type()),
+		}
+		if _, ok := obj.(*types.Func); ok {
+			item.Kind = protocol.MethodCompletion
+			item.Detail = strings.TrimPrefix(typeString(obj.Type()), "func")
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+func isChainByte(b byte) bool {
+	return b == '.' || b == '$' || b == '_' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
 // someday think about comments, strings, backslashes, etc
 // this would repeat some of the template parsing, but because the user is typing
 // there may be no parse tree here.
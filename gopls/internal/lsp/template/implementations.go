@@ -7,6 +7,7 @@ package template
 import (
 	"context"
 	"fmt"
+	"go/types"
 	"regexp"
 	"strconv"
 	"time"
@@ -23,7 +24,9 @@ var errRe = regexp.MustCompile(`template.*:(\d+): (.*)`)
 // Diagnose returns parse errors. There is only one.
 // The errors are not always helpful. For instance { {end}}
 // will likely point to the end of the file.
-func Diagnose(f file.Handle) []*source.Diagnostic {
+// If the file parses, it returns the type errors of its templates
+// that have a type directive.
+func Diagnose(ctx context.Context, snapshot source.Snapshot, f file.Handle) []*source.Diagnostic {
 	// no need for skipTemplate check, as Diagnose is called on the
 	// snapshot's template files
 	buf, err := f.Content()
@@ -36,7 +39,7 @@ func Diagnose(f file.Handle) []*source.Diagnostic {
 	}
 	p := parseBuffer(buf)
 	if p.ParseErr == nil {
-		return nil
+		return typeDiagnostics(ctx, snapshot, f, p)
 	}
 	unknownError := func(msg string) []*source.Diagnostic {
 		s := fmt.Sprintf("malformed template error %q: %s", p.ParseErr.Error(), msg)
@@ -70,15 +73,47 @@ func Diagnose(f file.Handle) []*source.Diagnostic {
 	return []*source.Diagnostic{&d}
 }
 
+// typeDiagnostics returns the errors found by type checking the templates
+// of p.
+func typeDiagnostics(ctx context.Context, snapshot source.Snapshot, f file.Handle, p *Parsed) []*source.Diagnostic {
+	info := typeCheck(ctx, snapshot, p)
+	if info == nil {
+		return nil
+	}
+	var diags []*source.Diagnostic
+	for _, e := range info.errs {
+		diags = append(diags, &source.Diagnostic{
+			URI:      f.URI(),
+			Range:    p.Range(e.start, e.length),
+			Severity: protocol.SeverityError,
+			Source:   source.TemplateError,
+			Message:  e.msg,
+		})
+	}
+	return diags
+}
+
 // Definition finds the definitions of the symbol at loc. It
 // does not understand scoping (if any) in templates. This code is
 // for definitions, type definitions, and implementations.
-// Results only for variables and templates.
-func Definition(snapshot *cache.Snapshot, fh file.Handle, loc protocol.Position) ([]protocol.Location, error) {
-	x, _, err := symAtPosition(fh, loc)
+// Results are for variables, templates, and the fields and methods
+// of templates with a type directive, which are declared in Go source.
+func Definition(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, loc protocol.Position) ([]protocol.Location, error) {
+	x, p, err := symAtPosition(fh, loc)
 	if err != nil {
 		return nil, err
 	}
+	if x.kind == protocol.Method {
+		if info := typeCheck(ctx, snapshot, p); info != nil {
+			if r, ok := info.refs[x.start]; ok {
+				loc, err := objLocation(ctx, snapshot, r)
+				if err != nil {
+					return nil, err
+				}
+				return []protocol.Location{loc}, nil
+			}
+		}
+	}
 	sym := x.name
 	ans := []protocol.Location{}
 	// PJW: this is probably a pattern to abstract
@@ -88,6 +123,10 @@ func Definition(snapshot *cache.Snapshot, fh file.Handle, loc protocol.Position)
 			if !s.vardef || s.name != sym {
 				continue
 			}
+			// {{template "x"}} refers to a template, not a variable.
+			if x.kind == protocol.Package && s.kind != protocol.Namespace {
+				continue
+			}
 			ans = append(ans, protocol.Location{URI: k, Range: p.Range(s.start, s.length)})
 		}
 	}
@@ -109,6 +148,12 @@ func Hover(ctx context.Context, snapshot source.Snapshot, fh file.Handle, positi
 		ans.Contents.Value = fmt.Sprintf("constant %s", sym.name)
 	case protocol.Method: // field or method
 		ans.Contents.Value = fmt.Sprintf("%s: field or method", sym.name)
+		if info := typeCheck(ctx, snapshot, p); info != nil {
+			if r, ok := info.refs[sym.start]; ok {
+				qf := func(p *types.Package) string { return p.Name() }
+				ans.Contents.Value = fmt.Sprintf("```go\n%s\n```", types.ObjectString(r.obj, qf))
+			}
+		}
 	case protocol.Package: // template use, template def (PJW: do we want two?)
 		ans.Contents.Value = fmt.Sprintf("template %s\n(add definition)", sym.name)
 	case protocol.Namespace:
@@ -0,0 +1,535 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package template
+
+// This file contains the type checking of templates whose data type is
+// declared by a type directive, a comment of the form
+//
+//	{{/* gopls:type example.com/pkg.Page */}}
+//
+// The directive gives the type of dot in the template that contains it:
+// either a template defined by {{define}} or {{block}}, or the top-level
+// template of the file. A pointer type may be written as *pkg.Page.
+// With it, gopls checks field and method chains such as .Author.Name,
+// completes fields and methods, and finds their declarations in Go source.
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/safetoken"
+	"golang.org/x/tools/gopls/internal/lsp/source"
+)
+
+var typeDirectiveRe = regexp.MustCompile(`^{{-?\s*/\*\s*gopls:type\s+(\*?[^\s*]+)\s*\*/\s*-?}}$`)
+
+// typeInfo holds the results of type checking a template file.
+type typeInfo struct {
+	scopes []dotScope  // in increasing order of start
+	refs   map[int]ref // field or method denoted by the symbol at each offset
+	errs   []typeError // in no particular order
+}
+
+// A dotScope is a region of a template file in which dot has a given type.
+// A nil type means the type is unknown.
+type dotScope struct {
+	start, end int
+	dot, root  types.Type // the types of . and $
+}
+
+// A ref records the field or method denoted by a symbol, with the file
+// set needed to find its declaration.
+type ref struct {
+	obj  types.Object
+	fset *token.FileSet
+}
+
+type typeError struct {
+	start, length int
+	msg           string
+}
+
+// scopeAt returns the innermost scope containing offset, or nil.
+func (info *typeInfo) scopeAt(offset int) *dotScope {
+	var s *dotScope
+	for i := range info.scopes {
+		sc := &info.scopes[i]
+		if sc.start <= offset && offset <= sc.end && (s == nil || sc.start >= s.start) {
+			s = sc
+		}
+	}
+	return s
+}
+
+// typeCheck type checks the templates of p that have a type directive.
+// It returns nil if there are none.
+func typeCheck(ctx context.Context, snapshot source.Snapshot, p *Parsed) *typeInfo {
+	if p.ParseErr != nil {
+		return nil
+	}
+	info := &typeInfo{refs: make(map[int]ref)}
+	regions := p.templateRegions()
+
+	// Resolve the directives, by the name of the template containing them.
+	type directive struct {
+		typ  types.Type
+		fset *token.FileSet
+	}
+	directives := make(map[string]directive)
+	for _, tok := range p.tokens {
+		m := typeDirectiveRe.FindSubmatchIndex(p.buf[tok.Start:tok.End])
+		if m == nil {
+			continue
+		}
+		start, end := tok.Start+m[2], tok.Start+m[3]
+		// The directive belongs to the innermost template containing it,
+		// which is the one that starts last.
+		name, inner := "", -1
+		for n, r := range regions {
+			if r.start <= tok.Start && tok.End <= r.end && r.start > inner {
+				name, inner = n, r.start
+			}
+		}
+		if _, ok := directives[name]; ok {
+			info.errorf(start, end-start, "duplicate gopls:type directive")
+			continue
+		}
+		typ, fset, err := lookupType(ctx, snapshot, string(p.buf[start:end]))
+		if err != nil {
+			info.errorf(start, end-start, "%v", err)
+			typ = nil // still record the directive, so later ones are reported
+		}
+		directives[name] = directive{typ, fset}
+	}
+	if len(directives) == 0 {
+		return nil
+	}
+
+	for _, t := range p.named {
+		if t.Tree == nil || t.Root == nil {
+			continue
+		}
+		d := directives[t.Name()]
+		start, end := 0, len(p.buf)
+		if t.Name() != "" {
+			r, ok := regions[t.Name()]
+			if !ok {
+				continue
+			}
+			start, end = r.start, r.end
+		}
+		info.scopes = append(info.scopes, dotScope{start: start, end: end, dot: d.typ, root: d.typ})
+		if d.typ == nil {
+			continue
+		}
+		c := &checker{p: p, info: info, fset: d.fset, root: d.typ, vars: make(map[string]types.Type)}
+		c.walk(t.Root, d.typ)
+	}
+	sort.SliceStable(info.scopes, func(i, j int) bool {
+		return info.scopes[i].start < info.scopes[j].start
+	})
+	return info
+}
+
+func (info *typeInfo) errorf(start, length int, format string, args ...interface{}) {
+	info.errs = append(info.errs, typeError{start: start, length: length, msg: fmt.Sprintf(format, args...)})
+}
+
+// lookupType returns the type denoted by name, a package path followed by
+// a dot and a type name, optionally preceded by a star.
+func lookupType(ctx context.Context, snapshot source.Snapshot, name string) (types.Type, *token.FileSet, error) {
+	ptr := strings.HasPrefix(name, "*")
+	qualified := strings.TrimPrefix(name, "*")
+	dot := strings.LastIndexByte(qualified, '.')
+	if dot < 0 || dot < strings.LastIndexByte(qualified, '/') {
+		return nil, nil, fmt.Errorf("invalid type %q: want a package path and type name, such as example.com/pkg.Page", name)
+	}
+	pkgPath, typeName := source.PackagePath(qualified[:dot]), qualified[dot+1:]
+
+	metas, err := snapshot.AllMetadata(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	source.RemoveIntermediateTestVariants(&metas)
+	var meta *source.Metadata
+	for _, m := range metas {
+		if m.PkgPath == pkgPath && (meta == nil || meta.ForTest != "") {
+			meta = m
+		}
+	}
+	if meta == nil {
+		return nil, nil, fmt.Errorf("cannot find package %q", pkgPath)
+	}
+	pkgs, err := snapshot.TypeCheck(ctx, meta.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkg := pkgs[0]
+	obj, ok := pkg.GetTypes().Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil, nil, fmt.Errorf("package %q has no type %s", pkgPath, typeName)
+	}
+	typ := obj.Type()
+	if ptr {
+		typ = types.NewPointer(typ)
+	}
+	return typ, pkg.FileSet(), nil
+}
+
+// A region is the extent of the body of a {{define}} or {{block}}.
+type region struct {
+	start, end int
+}
+
+// templateRegions returns the body of each template defined in p by name.
+func (p *Parsed) templateRegions() map[string]region {
+	regions := make(map[string]region)
+	for i, tok := range p.tokens {
+		words := p.actionWords(tok)
+		if len(words) < 2 || words[0] != "define" && words[0] != "block" {
+			continue
+		}
+		name, err := strconv.Unquote(words[1])
+		if err != nil {
+			continue
+		}
+		end := len(p.buf)
+		if j := p.matchEnd(i, false); j >= 0 {
+			end = p.tokens[j].Start
+		}
+		regions[name] = region{start: tok.End, end: end}
+	}
+	return regions
+}
+
+// actionWords returns the space-separated words of the action of tok,
+// without delimiters or trim markers.
+func (p *Parsed) actionWords(tok Token) []string {
+	s := string(p.buf[tok.Start+len(Left) : tok.End-len(Right)])
+	s = strings.TrimPrefix(s, "-")
+	s = strings.TrimSuffix(s, "-")
+	return strings.Fields(s)
+}
+
+// matchEnd returns the index of the {{end}} token matching the control
+// action in p.tokens[i], or of its first {{else}} if orElse is set. It
+// returns -1 if there is none.
+func (p *Parsed) matchEnd(i int, orElse bool) int {
+	depth := 0
+	for j := i + 1; j < len(p.tokens); j++ {
+		words := p.actionWords(p.tokens[j])
+		if len(words) == 0 {
+			continue
+		}
+		switch words[0] {
+		case "if", "with", "range", "block", "define":
+			depth++
+		case "else":
+			if depth == 0 && orElse {
+				return j
+			}
+		case "end":
+			if depth == 0 {
+				return j
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// tokenAt returns the index of the token containing offset, or -1.
+func (p *Parsed) tokenAt(offset int) int {
+	for i, tok := range p.tokens {
+		if tok.Start <= offset && offset < tok.End {
+			return i
+		}
+	}
+	return -1
+}
+
+// A checker computes the types of the pipelines of a template, whose
+// data has type root.
+type checker struct {
+	p    *Parsed
+	info *typeInfo
+	fset *token.FileSet
+	root types.Type
+	vars map[string]types.Type // types of $variables (ignoring scopes)
+}
+
+func (c *checker) walk(n parse.Node, dot types.Type) {
+	switch x := n.(type) {
+	case *parse.ListNode:
+		if x == nil {
+			return
+		}
+		for _, nd := range x.Nodes {
+			c.walk(nd, dot)
+		}
+	case *parse.ActionNode:
+		c.declare(x.Pipe, c.pipe(x.Pipe, dot))
+	case *parse.IfNode:
+		c.declare(x.Pipe, c.pipe(x.Pipe, dot))
+		c.walk(x.List, dot)
+		c.walk(x.ElseList, dot)
+	case *parse.WithNode:
+		t := c.pipe(x.Pipe, dot)
+		c.declare(x.Pipe, t)
+		c.scope(x.Pos, t)
+		c.walk(x.List, t)
+		c.walk(x.ElseList, dot)
+	case *parse.RangeNode:
+		key, elem := rangeTypes(c.pipe(x.Pipe, dot))
+		if decl := x.Pipe.Decl; len(decl) == 1 {
+			c.vars[decl[0].Ident[0]] = elem
+		} else if len(decl) == 2 {
+			c.vars[decl[0].Ident[0]] = key
+			c.vars[decl[1].Ident[0]] = elem
+		}
+		c.scope(x.Pos, elem)
+		c.walk(x.List, elem)
+		c.walk(x.ElseList, dot)
+	case *parse.TemplateNode:
+		c.pipe(x.Pipe, dot)
+	}
+}
+
+// scope records that dot has type t in the body of the control action
+// containing pos.
+func (c *checker) scope(pos parse.Pos, t types.Type) {
+	i := c.p.tokenAt(int(pos))
+	if i < 0 {
+		return
+	}
+	end := len(c.p.buf)
+	if j := c.p.matchEnd(i, true); j >= 0 {
+		end = c.p.tokens[j].Start
+	}
+	c.info.scopes = append(c.info.scopes, dotScope{start: c.p.tokens[i].End, end: end, dot: t, root: c.root})
+}
+
+func (c *checker) declare(pipe *parse.PipeNode, t types.Type) {
+	if pipe == nil {
+		return
+	}
+	for _, v := range pipe.Decl {
+		c.vars[v.Ident[0]] = t
+	}
+}
+
+// pipe returns the type of the pipeline, or nil if it is unknown.
+func (c *checker) pipe(pipe *parse.PipeNode, dot types.Type) types.Type {
+	if pipe == nil {
+		return nil
+	}
+	var t types.Type
+	for _, cmd := range pipe.Cmds {
+		t = nil
+		for i, arg := range cmd.Args {
+			if at := c.expr(arg, dot); i == 0 {
+				t = at
+			}
+		}
+	}
+	return t
+}
+
+func (c *checker) expr(n parse.Node, dot types.Type) types.Type {
+	switch x := n.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return c.chain(dot, x.Ident, x, 0)
+	case *parse.VariableNode:
+		t := c.root
+		if x.Ident[0] != "$" {
+			t = c.vars[x.Ident[0]]
+		}
+		return c.chain(t, x.Ident, x, 1)
+	case *parse.ChainNode:
+		return c.chain(c.expr(x.Node, dot), x.Field, x, 0)
+	case *parse.PipeNode:
+		return c.pipe(x, dot)
+	case *parse.StringNode:
+		return types.Typ[types.String]
+	case *parse.BoolNode:
+		return types.Typ[types.Bool]
+	}
+	return nil
+}
+
+// chain returns the type of the field and method selections
+// idents[skip:] from a value of type t, recording the objects they denote
+// and reporting those that do not exist.
+func (c *checker) chain(t types.Type, idents []string, n parse.Node, skip int) types.Type {
+	syms := c.p.fields(idents, n)
+	for i := skip; i < len(idents); i++ {
+		if t == nil {
+			return nil
+		}
+		obj, next, err := lookupField(t, idents[i])
+		if i >= len(syms) { // can't locate the identifier
+			t = next
+			continue
+		}
+		sym := syms[i]
+		if err != "" {
+			c.info.errorf(sym.start, sym.length, "%s", err)
+			return nil
+		}
+		if obj != nil {
+			c.info.refs[sym.start] = ref{obj: obj, fset: c.fset}
+		}
+		t = next
+	}
+	return t
+}
+
+// lookupField returns the field or method name of a value of type t, and
+// the type of its selection (for a method, the type of its first result).
+// If there is no such field or method, it returns an error message like
+// the one reported when executing the template, unless the selection
+// can only be checked at run time.
+func lookupField(t types.Type, name string) (types.Object, types.Type, string) {
+	var pkg *types.Package
+	if named, ok := source.Deref(t).(*types.Named); ok {
+		pkg = named.Obj().Pkg()
+	}
+	switch obj := lookupFieldOrMethod(t, pkg, name).(type) {
+	case *types.Var:
+		if !obj.Exported() {
+			return obj, nil, fmt.Sprintf("%s is an unexported field of struct type %s", name, typeString(t))
+		}
+		return obj, obj.Type(), ""
+	case *types.Func:
+		if obj.Exported() {
+			var result types.Type
+			if res := obj.Type().(*types.Signature).Results(); res.Len() > 0 {
+				result = res.At(0).Type()
+			}
+			return obj, result, ""
+		}
+	}
+	switch u := source.Deref(t).Underlying().(type) {
+	case *types.Map:
+		if key, ok := u.Key().Underlying().(*types.Basic); ok && key.Info()&types.IsString != 0 {
+			return nil, u.Elem(), ""
+		}
+		return nil, nil, ""
+	case *types.Interface:
+		return nil, nil, "" // the dynamic type may have it
+	}
+	if _, ok := t.(*types.TypeParam); ok {
+		return nil, nil, ""
+	}
+	return nil, nil, fmt.Sprintf("can't evaluate field %s in type %s", name, typeString(t))
+}
+
+// lookupFieldOrMethod is like types.LookupFieldOrMethod for an
+// addressable value, as templates take the address of values to find
+// methods with pointer receivers.
+func lookupFieldOrMethod(t types.Type, pkg *types.Package, name string) types.Object {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, pkg, name)
+	return obj
+}
+
+// rangeTypes returns the types of the key and element of a range over a
+// value of type t, or nil if they are unknown.
+func rangeTypes(t types.Type) (key, elem types.Type) {
+	if t == nil {
+		return nil, nil
+	}
+	switch u := source.Deref(t).Underlying().(type) {
+	case *types.Slice:
+		return types.Typ[types.Int], u.Elem()
+	case *types.Array:
+		return types.Typ[types.Int], u.Elem()
+	case *types.Map:
+		return u.Key(), u.Elem()
+	case *types.Chan:
+		return u.Elem(), u.Elem()
+	}
+	return nil, nil
+}
+
+// members returns the exported fields and methods of a value of type t,
+// sorted by name.
+func members(t types.Type) []types.Object {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if token.IsExported(name) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	// Fields, including promoted ones.
+	var visit func(t types.Type, depth int)
+	visit = func(t types.Type, depth int) {
+		st, ok := source.Deref(t).Underlying().(*types.Struct)
+		if !ok || depth > 10 {
+			return
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			f := st.Field(i)
+			add(f.Name())
+			if f.Embedded() {
+				visit(f.Type(), depth+1)
+			}
+		}
+	}
+	visit(t, 0)
+	// Methods.
+	mt := t
+	if _, ok := t.Underlying().(*types.Interface); !ok {
+		if _, ok := t.(*types.Pointer); !ok {
+			mt = types.NewPointer(t)
+		}
+	}
+	mset := types.NewMethodSet(mt)
+	for i := 0; i < mset.Len(); i++ {
+		add(mset.At(i).Obj().Name())
+	}
+
+	sort.Strings(names)
+	var objs []types.Object
+	for _, name := range names {
+		// The lookup discards shadowed and ambiguous selections.
+		if obj := lookupFieldOrMethod(t, nil, name); obj != nil {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// typeString formats a type as in the errors reported when executing
+// templates, qualified by package name.
+func typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string { return p.Name() })
+}
+
+// objLocation returns the location of the declaration of the object of r.
+func objLocation(ctx context.Context, snapshot source.Snapshot, r ref) (protocol.Location, error) {
+	posn := safetoken.StartPosition(r.fset, r.obj.Pos())
+	fh, err := snapshot.ReadFile(ctx, protocol.URIFromPath(posn.Filename))
+	if err != nil {
+		return protocol.Location{}, err
+	}
+	content, err := fh.Content()
+	if err != nil {
+		return protocol.Location{}, err
+	}
+	m := protocol.NewMapper(fh.URI(), content)
+	return m.OffsetLocation(posn.Offset, posn.Offset+len(r.obj.Name()))
+}
@@ -106,10 +106,6 @@ func (s *server) ResolveCodeAction(context.Context, *protocol.CodeAction) (*prot
 	return nil, notImplemented("ResolveCodeAction")
 }
 
-func (s *server) ResolveCodeLens(context.Context, *protocol.CodeLens) (*protocol.CodeLens, error) {
-	return nil, notImplemented("ResolveCodeLens")
-}
-
 func (s *server) ResolveCompletionItem(context.Context, *protocol.CompletionItem) (*protocol.CompletionItem, error) {
 	return nil, notImplemented("ResolveCompletionItem")
 }
@@ -146,10 +142,6 @@ func (s *server) WillDeleteFiles(context.Context, *protocol.DeleteFilesParams) (
 	return nil, notImplemented("WillDeleteFiles")
 }
 
-func (s *server) WillRenameFiles(context.Context, *protocol.RenameFilesParams) (*protocol.WorkspaceEdit, error) {
-	return nil, notImplemented("WillRenameFiles")
-}
-
 func (s *server) WillSave(context.Context, *protocol.WillSaveTextDocumentParams) error {
 	return notImplemented("WillSave")
 }
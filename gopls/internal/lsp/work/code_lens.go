@@ -0,0 +1,90 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package work
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/cache"
+	"golang.org/x/tools/gopls/internal/lsp/command"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/source"
+)
+
+// LensFuncs returns the supported lensFuncs for go.work files.
+func LensFuncs() map[command.Command]source.LensFunc {
+	return map[command.Command]source.LensFunc{
+		command.GoWorkUse: useLenses,
+	}
+}
+
+// useLenses returns a code lens to remove each module used by the go.work
+// file, and one to add each module found beneath its directory that it
+// does not use.
+func useLenses(ctx context.Context, snapshot source.Snapshot, fh file.Handle) ([]protocol.CodeLens, error) {
+	// The go work commands run in a view, which only the cache snapshot
+	// knows about.
+	s, ok := snapshot.(*cache.Snapshot)
+	if !ok || fh.URI() != s.WorkFile() {
+		return nil, nil
+	}
+	pw, err := s.ParseWork(ctx, fh)
+	if err != nil || pw.File == nil {
+		return nil, err
+	}
+	viewID := s.View().ID()
+	workdir := filepath.Dir(fh.URI().Path())
+
+	var lenses []protocol.CodeLens
+	used := make(map[protocol.DocumentURI]bool)
+	for _, use := range pw.File.Use {
+		used[modFileURI(pw, use)] = true
+		rng, err := pw.Mapper.OffsetRange(use.Syntax.Start.Byte, use.Syntax.End.Byte)
+		if err != nil {
+			return nil, err
+		}
+		cmd, err := command.NewGoWorkUseCommand("Remove module", command.GoWorkUseArgs{
+			ViewID: viewID,
+			Dir:    use.Path,
+			Drop:   true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		lenses = append(lenses, protocol.CodeLens{Range: rng, Command: &cmd})
+	}
+
+	// Put the lenses to add modules on the go directive, or at the start
+	// of the file.
+	var rng protocol.Range
+	if pw.File.Go != nil {
+		rng, err = pw.Mapper.OffsetRange(pw.File.Go.Syntax.Start.Byte, pw.File.Go.Syntax.End.Byte)
+		if err != nil {
+			return nil, err
+		}
+	}
+	modFiles, err := s.FindModules(protocol.URIFromPath(workdir))
+	if err != nil {
+		return nil, err
+	}
+	for _, modURI := range modFiles {
+		if used[modURI] {
+			continue
+		}
+		dir := relUsePath(workdir, filepath.Dir(modURI.Path()))
+		cmd, err := command.NewGoWorkUseCommand(fmt.Sprintf("Add module %s", dir), command.GoWorkUseArgs{
+			ViewID: viewID,
+			Dir:    dir,
+		})
+		if err != nil {
+			return nil, err
+		}
+		lenses = append(lenses, protocol.CodeLens{Range: rng, Command: &cmd})
+	}
+	return lenses, nil
+}
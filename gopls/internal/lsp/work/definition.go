@@ -0,0 +1,52 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package work
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/cache"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/internal/event"
+)
+
+// Definition returns the location of the go.mod file of the module
+// denoted by the use directive at the given position, if any.
+func Definition(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, position protocol.Position) ([]protocol.Location, error) {
+	ctx, done := event.Start(ctx, "work.Definition")
+	defer done()
+
+	pw, err := snapshot.ParseWork(ctx, fh)
+	if err != nil {
+		return nil, fmt.Errorf("getting go.work file handle: %w", err)
+	}
+	offset, err := pw.Mapper.PositionOffset(position)
+	if err != nil {
+		return nil, fmt.Errorf("computing cursor offset: %w", err)
+	}
+	use, _, _ := usePath(pw, offset)
+	if use == nil {
+		return nil, nil
+	}
+
+	modfh, err := snapshot.ReadFile(ctx, modFileURI(pw, use))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := modfh.Content(); err != nil {
+		return nil, nil // no go.mod file: reported by DiagnosticsForWork
+	}
+	// Jump to the module directive, if the go.mod file can be parsed.
+	loc := protocol.Location{URI: modfh.URI()}
+	if pm, err := snapshot.ParseMod(ctx, modfh); err == nil && pm.File.Module != nil {
+		syntax := pm.File.Module.Syntax
+		if rng, err := pm.Mapper.OffsetRange(syntax.Start.Byte, syntax.End.Byte); err == nil {
+			loc.Range = rng
+		}
+	}
+	return []protocol.Location{loc}, nil
+}
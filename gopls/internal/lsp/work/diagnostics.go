@@ -78,16 +78,128 @@ func DiagnosticsForWork(ctx context.Context, snapshot source.Snapshot, fh file.H
 			})
 		}
 	}
-	return diagnostics, nil
+	replaceDiags, err := replaceDiagnostics(ctx, snapshot, pw)
+	if err != nil {
+		return nil, err
+	}
+	return append(diagnostics, replaceDiags...), nil
 }
 
-func modFileURI(pw *source.ParsedWorkFile, use *modfile.Use) protocol.DocumentURI {
+// replaceDiagnostics reports replace directives of the go.work file that
+// override different replacements in the go.mod files of its modules,
+// and replacements in those go.mod files that conflict with each other,
+// which the go command rejects unless go.work overrides them.
+func replaceDiagnostics(ctx context.Context, snapshot source.Snapshot, pw *source.ParsedWorkFile) ([]*source.Diagnostic, error) {
 	workdir := filepath.Dir(pw.URI.Path())
 
-	modroot := filepath.FromSlash(use.Path)
-	if !filepath.IsAbs(modroot) {
-		modroot = filepath.Join(workdir, modroot)
+	// A replacement applies to a specific version of a module, or to all
+	// of its versions if the version is empty.
+	type replacement struct {
+		use     *modfile.Use
+		replace *modfile.Replace
+		target  string // the New module, with directories made absolute
+	}
+	target := func(dir string, r *modfile.Replace) string {
+		if r.New.Version == "" {
+			return resolveDir(dir, r.New.Path)
+		}
+		return r.New.String()
+	}
+	overrides := func(w, m *modfile.Replace) bool {
+		return w.Old.Path == m.Old.Path && (w.Old.Version == "" || w.Old.Version == m.Old.Version)
+	}
+
+	// Collect the replacements of the modules, grouped by the module
+	// version they replace.
+	var members []replacement
+	byOld := make(map[string][]replacement)
+	for _, use := range pw.File.Use {
+		modfh, err := snapshot.ReadFile(ctx, modFileURI(pw, use))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := modfh.Content(); err != nil {
+			continue // reported above
+		}
+		pm, err := snapshot.ParseMod(ctx, modfh)
+		if err != nil || pm.File == nil {
+			continue // reported by go.mod diagnostics
+		}
+		for _, r := range pm.File.Replace {
+			m := replacement{use, r, target(useDir(pw, use), r)}
+			members = append(members, m)
+			byOld[r.Old.String()] = append(byOld[r.Old.String()], m)
+		}
+	}
+
+	var diagnostics []*source.Diagnostic
+	addDiagnostic := func(syntax *modfile.Line, severity protocol.DiagnosticSeverity, msg string) error {
+		rng, err := pw.Mapper.OffsetRange(syntax.Start.Byte, syntax.End.Byte)
+		if err != nil {
+			return err
+		}
+		diagnostics = append(diagnostics, &source.Diagnostic{
+			URI:      pw.URI,
+			Range:    rng,
+			Severity: severity,
+			Source:   source.WorkFileError,
+			Message:  msg,
+		})
+		return nil
+	}
+
+	// Report go.work replacements that override different ones.
+	for _, w := range pw.File.Replace {
+		wtarget := target(workdir, w)
+		for _, m := range members {
+			if overrides(w, m.replace) && m.target != wtarget {
+				msg := fmt.Sprintf("replacement of %s overrides %s in %s/go.mod", w.Old.Path, m.replace.New, m.use.Path)
+				if err := addDiagnostic(w.Syntax, protocol.SeverityWarning, msg); err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 
-	return protocol.URIFromPath(filepath.Join(modroot, "go.mod"))
+	// Report conflicting replacements that go.work does not override.
+outer:
+	for _, reps := range byOld {
+		for _, w := range pw.File.Replace {
+			if overrides(w, reps[0].replace) {
+				continue outer
+			}
+		}
+		for _, m := range reps[1:] {
+			if m.target != reps[0].target {
+				first := reps[0]
+				msg := fmt.Sprintf("conflicting replacements for %s in %s/go.mod (%s) and %s/go.mod (%s): add a replace directive to go.work to resolve them",
+					first.replace.Old, first.use.Path, first.replace.New, m.use.Path, m.replace.New)
+				if err := addDiagnostic(m.use.Syntax, protocol.SeverityError, msg); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	return diagnostics, nil
+}
+
+func modFileURI(pw *source.ParsedWorkFile, use *modfile.Use) protocol.DocumentURI {
+	return protocol.URIFromPath(filepath.Join(useDir(pw, use), "go.mod"))
+}
+
+// useDir returns the absolute path of the module directory of a use
+// statement.
+func useDir(pw *source.ParsedWorkFile, use *modfile.Use) string {
+	return resolveDir(filepath.Dir(pw.URI.Path()), use.Path)
+}
+
+// resolveDir returns the absolute form of a directory path found in a
+// go.work or go.mod file in the directory base.
+func resolveDir(base, dir string) string {
+	dir = filepath.FromSlash(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+	return dir
 }
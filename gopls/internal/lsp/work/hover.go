@@ -76,19 +76,23 @@ func Hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, positi
 
 func usePath(pw *source.ParsedWorkFile, offset int) (use *modfile.Use, pathStart, pathEnd int) {
 	for _, u := range pw.File.Use {
-		path := []byte(u.Path)
-		s, e := u.Syntax.Start.Byte, u.Syntax.End.Byte
-		i := bytes.Index(pw.Mapper.Content[s:e], path)
-		if i == -1 {
-			// This should not happen.
-			continue
-		}
-		// Shift the start position to the location of the
-		// module directory within the use statement.
-		pathStart, pathEnd = s+i, s+i+len(path)
-		if pathStart <= offset && offset <= pathEnd {
+		pathStart, pathEnd, ok := usePathOffsets(pw, u)
+		if ok && pathStart <= offset && offset <= pathEnd {
 			return u, pathStart, pathEnd
 		}
 	}
 	return nil, 0, 0
 }
+
+// usePathOffsets returns the offsets of the module directory within the
+// use statement.
+func usePathOffsets(pw *source.ParsedWorkFile, u *modfile.Use) (pathStart, pathEnd int, ok bool) {
+	path := []byte(u.Path)
+	s, e := u.Syntax.Start.Byte, u.Syntax.End.Byte
+	i := bytes.Index(pw.Mapper.Content[s:e], path)
+	if i == -1 {
+		// This should not happen.
+		return 0, 0, false
+	}
+	return s + i, s + i + len(path), true
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package work
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/lsp/cache"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/internal/event"
+)
+
+// RenameUses returns the edits to the go.work file of the snapshot that
+// update its use directives after the given renamings of files and
+// directories, such as when a module directory is moved. It returns a
+// nil file handle if there is nothing to update.
+func RenameUses(ctx context.Context, snapshot *cache.Snapshot, renames []protocol.FileRename) (file.Handle, []protocol.TextEdit, error) {
+	uri := snapshot.WorkFile()
+	if uri == "" {
+		return nil, nil, nil
+	}
+	ctx, done := event.Start(ctx, "work.RenameUses")
+	defer done()
+
+	fh, err := snapshot.ReadFile(ctx, uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	pw, err := snapshot.ParseWork(ctx, fh)
+	if err != nil || pw.File == nil {
+		return nil, nil, err
+	}
+	workdir := filepath.Dir(uri.Path())
+
+	var edits []protocol.TextEdit
+	for _, use := range pw.File.Use {
+		dir := useDir(pw, use)
+		for _, r := range renames {
+			oldPath := protocol.DocumentURI(r.OldURI).Path()
+			newPath := protocol.DocumentURI(r.NewURI).Path()
+			rel, err := filepath.Rel(oldPath, dir)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue // not within the renamed directory
+			}
+			newDir := filepath.Join(newPath, rel)
+			newUse := filepath.ToSlash(newDir)
+			if !filepath.IsAbs(filepath.FromSlash(use.Path)) {
+				newUse = relUsePath(workdir, newDir)
+			}
+			start, end, ok := usePathOffsets(pw, use)
+			if !ok {
+				continue
+			}
+			rng, err := pw.Mapper.OffsetRange(start, end)
+			if err != nil {
+				return nil, nil, err
+			}
+			edits = append(edits, protocol.TextEdit{Range: rng, NewText: newUse})
+			break
+		}
+	}
+	if len(edits) == 0 {
+		return nil, nil, nil
+	}
+	return fh, edits, nil
+}
+
+// relUsePath returns the form of the directory dir used in a go.work file
+// in the directory workdir, as written by `go work use`.
+func relUsePath(workdir, dir string) string {
+	rel, err := filepath.Rel(workdir, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	return "./" + filepath.ToSlash(rel)
+}
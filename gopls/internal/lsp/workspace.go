@@ -11,6 +11,7 @@ import (
 
 	"golang.org/x/tools/gopls/internal/lsp/cache"
 	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	"golang.org/x/tools/gopls/internal/lsp/work"
 	"golang.org/x/tools/internal/event"
 )
 
@@ -102,3 +103,33 @@ func (s *server) DidChangeConfiguration(ctx context.Context, _ *protocol.DidChan
 
 	return nil
 }
+
+// WillRenameFiles updates the use directives of go.work files when the
+// directories they refer to are renamed.
+func (s *server) WillRenameFiles(ctx context.Context, params *protocol.RenameFilesParams) (*protocol.WorkspaceEdit, error) {
+	ctx, done := event.Start(ctx, "lsp.Server.willRenameFiles")
+	defer done()
+
+	var changes []protocol.DocumentChanges
+	seen := make(map[protocol.DocumentURI]bool) // views may share a go.work file
+	for _, view := range s.session.Views() {
+		snapshot, release, err := view.Snapshot()
+		if err != nil {
+			continue // view is shut down
+		}
+		fh, edits, err := work.RenameUses(ctx, snapshot, params.Files)
+		release()
+		if err != nil {
+			return nil, err
+		}
+		if fh == nil || seen[fh.URI()] {
+			continue
+		}
+		seen[fh.URI()] = true
+		changes = append(changes, documentChanges(fh, edits)...)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return &protocol.WorkspaceEdit{DocumentChanges: changes}, nil
+}
@@ -346,3 +346,79 @@ func Foo() {
 		)
 	})
 }
+
+func TestReferencesCodeLens(t *testing.T) {
+	const workspace = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+type Shape interface {
+	Area() int
+}
+
+type Square struct{ side int }
+
+func (s Square) Area() int { return s.side * s.side }
+
+func NewSquare(side int) Square { return Square{side} }
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+var _ a.Shape = a.NewSquare(1)
+
+func area() int { return a.NewSquare(2).Area() }
+`
+	WithOptions(
+		Settings{"codelenses": map[string]bool{string(command.References): true}},
+	).Run(t, workspace, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var got []string
+		resolved := make(map[uint32]protocol.CodeLens) // by line
+		for _, lens := range env.CodeLens("a/a.go") {
+			if lens.Command != nil {
+				t.Errorf("got resolved references lens %q, want unresolved", lens.Command.Title)
+				continue
+			}
+			lens = env.ResolveCodeLens(lens)
+			got = append(got, fmt.Sprintf("%d: %s", lens.Range.Start.Line, lens.Command.Title))
+			resolved[lens.Range.Start.Line] = lens
+		}
+		want := []string{
+			"2: 1 reference | 1 implementation",
+			"6: 3 references | 1 implementation",
+			"8: 1 reference | 1 implementation",
+			"10: 2 references",
+		}
+		if diff := compare.Text(fmt.Sprint(want), fmt.Sprint(got)); diff != "" {
+			t.Errorf("unexpected references lenses (-want +got):\n%s", diff)
+		}
+
+		// A lens whose implementations cannot be found (here, because
+		// NewSquare is not a method) reports only the references.
+		lens := protocol.CodeLens{Range: resolved[10].Range}
+		lens.Data = command.ReferencesArgs{
+			Location:        protocol.Location{URI: env.Sandbox.Workdir.URI("a/a.go"), Range: lens.Range},
+			Implementations: true,
+		}
+		if got := env.ResolveCodeLens(lens).Command.Title; got != "2 references" {
+			t.Errorf("references lens without implementations: got title %q, want %q", got, "2 references")
+		}
+
+		// Running the command of a lens with a lone reference shows it.
+		var locs []protocol.Location
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   resolved[2].Command.Command,
+			Arguments: resolved[2].Command.Arguments,
+		}, &locs)
+		if len(locs) != 1 {
+			t.Fatalf("got %d references to Shape, want 1", len(locs))
+		}
+		env.Await(ShownDocument(protocol.URI(env.Sandbox.Workdir.URI("b/b.go"))))
+	})
+}
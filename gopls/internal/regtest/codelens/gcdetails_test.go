@@ -5,6 +5,7 @@
 package codelens
 
 import (
+	"fmt"
 	"runtime"
 	"strings"
 	"testing"
@@ -79,6 +80,104 @@ func main() {
 	})
 }
 
+func TestGCDetails_InlayHints(t *testing.T) {
+	if runtime.GOOS == "android" {
+		t.Skipf("the gc details code lens doesn't work on Android")
+	}
+
+	const mod = `
+-- go.mod --
+module mod.com
+
+go 1.15
+-- main.go --
+package main
+
+var sink *int
+
+func add(a, b int) int { return a + b }
+
+func main() {
+	x := add(1, 2)
+	sink = &x
+}
+`
+	WithOptions(
+		Settings{
+			"codelenses": map[string]bool{
+				"gc_details": true,
+			},
+		},
+		CapabilitiesJSON([]byte(`{"textDocument": {"inlayHint": {}}}`)),
+	).Run(t, mod, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		env.ExecuteCodeLensCommand("main.go", command.GCDetails, nil)
+
+		// hint returns the label and tooltip of the hint at the given
+		// line with the given label prefix, if any.
+		hint := func(line uint32, prefix string) (label, tooltip string) {
+			for _, h := range env.InlayHints("main.go") {
+				if h.Position.Line == line && strings.HasPrefix(h.Label[0].Value, prefix) {
+					if h.Label[0].Tooltip != nil {
+						tooltip = fmt.Sprint(h.Label[0].Tooltip.Value)
+					}
+					return h.Label[0].Value, tooltip
+				}
+			}
+			return "", ""
+		}
+		if label, _ := hint(4, "inlinable"); label == "" {
+			t.Errorf("missing inlining hint for add")
+		}
+		label, tooltip := hint(7, "x escapes")
+		if label == "" {
+			t.Fatalf("missing escape hint for x")
+		}
+		if want := "from sink = &x (assign) (main.go:9:7)"; !strings.Contains(tooltip, want) {
+			t.Errorf("escape hint tooltip %q does not contain %q", tooltip, want)
+		}
+
+		// Hovering over x explains the escape flow.
+		content, _ := env.Hover(env.RegexpSearch("main.go", "(x) :="))
+		if want := "flow: {heap} ← &x"; !strings.Contains(content.Value, want) {
+			t.Errorf("hover does not contain %q:\n%s", want, content.Value)
+		}
+
+		// Details are shown as inlay hints rather than diagnostics.
+		env.AfterChange(NoDiagnostics(ForFile("main.go")))
+
+		// Editing a buffer hides the hints, which apply to the saved file,
+		// and saving it computes them again.
+		env.EditBuffer("main.go", fake.NewEdit(0, 0, 0, 0, "// Package main\n"))
+		if label, _ := hint(7, "x escapes"); label != "" {
+			t.Errorf("got escape hint for edited buffer")
+		}
+		env.SaveBufferWithoutActions("main.go")
+		env.AfterChange()
+		if label, _ := hint(8, "x escapes"); label == "" {
+			t.Errorf("missing escape hint for x after save")
+		}
+
+		// Hints can be filtered by kind.
+		cfg := env.Editor.Config()
+		cfg.Settings = map[string]interface{}{
+			"annotations": map[string]bool{
+				"bounds": true,
+				"escape": false,
+				"inline": true,
+				"nil":    true,
+			},
+		}
+		env.ChangeConfiguration(cfg)
+		if label, _ := hint(8, "x escapes"); label != "" {
+			t.Errorf("got escape hint with escape annotations disabled")
+		}
+		if label, _ := hint(5, "inlinable"); label == "" {
+			t.Errorf("missing inlining hint with escape annotations disabled")
+		}
+	})
+}
+
 // Test for the crasher in golang/go#54199
 func TestGCDetails_NewFile(t *testing.T) {
 	bug.PanicOnBugs = false
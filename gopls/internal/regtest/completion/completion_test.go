@@ -316,9 +316,100 @@ func _() {
 		}
 		env.AcceptCompletion(loc, item)
 
-		// Await the diagnostics to add example.com/blah to the go.mod file.
+		// Accepting the completion of the blah package added the requirement
+		// for its module, which go mod tidy had removed, from the module cache.
+		if got := env.ReadWorkspaceFile("go.mod"); !strings.Contains(got, "require example.com v1.2.3") {
+			t.Errorf("go.mod does not require example.com v1.2.3:\n%s", got)
+		}
+		env.AfterChange(
+			NoDiagnostics(ForFile("main.go")),
+		)
+	})
+}
+
+func TestUnimportedCompletionFromModuleCache(t *testing.T) {
+	// Version v1.1.0 requires a newer Go than the main module, so the
+	// completion must choose v1.0.0, whose own requirement on other.com/c
+	// must be added too.
+	const proxy = `
+-- other.com/b@v1.0.0/go.mod --
+module other.com/b
+
+go 1.18
+
+require other.com/c v1.0.0
+-- other.com/b@v1.0.0/extra/extra.go --
+package extra
+
+import "other.com/c"
+
+func NewServer() int { return c.Zero }
+-- other.com/b@v1.1.0/go.mod --
+module other.com/b
+
+go 1.22
+-- other.com/b@v1.1.0/extra/extra.go --
+package extra
+
+func NewServer() int { return 1 }
+-- other.com/c@v1.0.0/go.mod --
+module other.com/c
+
+go 1.18
+-- other.com/c@v1.0.0/c.go --
+package c
+
+const Zero = 0
+`
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.go --
+package main
+
+var _ = extra.NewServ
+`
+	WithOptions(
+		ProxyFiles(proxy),
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.RunGoCommand("mod", "download", "other.com/b@v1.0.0", "other.com/b@v1.1.0", "other.com/c@v1.0.0")
+
+		env.OpenFile("main.go")
+		loc := env.RegexpSearch("main.go", "NewServ()")
+		completions := env.Completion(loc)
+		var item *protocol.CompletionItem
+		for i := range completions.Items {
+			if completions.Items[i].Label == "NewServer" {
+				item = &completions.Items[i]
+			}
+		}
+		if item == nil {
+			t.Fatalf("no completion of NewServer in %v", completions.Items)
+		}
+		if want := `(from "other.com/b/extra" in other.com/b@v1.0.0)`; !strings.Contains(item.Detail, want) {
+			t.Errorf("completion detail = %q, want it to contain %q", item.Detail, want)
+		}
+		if item.Command == nil {
+			t.Fatalf("completion of NewServer has no command")
+		}
+		env.AcceptCompletion(loc, *item)
+
+		if got := env.ReadWorkspaceFile("go.mod"); !strings.Contains(got, "require other.com/b v1.0.0") {
+			t.Errorf("go.mod does not require other.com/b v1.0.0:\n%s", got)
+		}
+		if got := env.ReadWorkspaceFile("go.mod"); !strings.Contains(got, "other.com/c v1.0.0 // indirect") {
+			t.Errorf("go.mod does not require other.com/c v1.0.0, a requirement of other.com/b:\n%s", got)
+		}
+		if got := env.ReadWorkspaceFile("go.sum"); !strings.Contains(got, "other.com/b v1.0.0 h1:") || !strings.Contains(got, "other.com/b v1.0.0/go.mod h1:") {
+			t.Errorf("go.sum lacks the checksums of other.com/b v1.0.0:\n%s", got)
+		}
+		if got := env.ReadWorkspaceFile("go.sum"); !strings.Contains(got, "other.com/c v1.0.0 h1:") || !strings.Contains(got, "other.com/c v1.0.0/go.mod h1:") {
+			t.Errorf("go.sum lacks the checksums of other.com/c v1.0.0:\n%s", got)
+		}
 		env.AfterChange(
-			Diagnostics(env.AtRegexp("main.go", `"example.com/blah"`)),
+			NoDiagnostics(ForFile("main.go")),
 		)
 	})
 }
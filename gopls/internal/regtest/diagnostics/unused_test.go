@@ -0,0 +1,117 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diagnostics
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	. "golang.org/x/tools/gopls/internal/lsp/regtest"
+)
+
+const unusedFiles = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+// unusedFunc is never called.
+func unusedFunc() {
+	unusedFunc()
+}
+
+func usedFunc() {}
+
+type T struct {
+	used   int
+	unused int
+	Tagged int ` + "`json:\"tagged\"`" + `
+}
+
+const (
+	c1 = 1
+	c2 = 2
+)
+
+func Exported() int {
+	usedFunc()
+	var t T
+	return t.used + c1
+}
+
+func Dead() {}
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+var _ = a.Exported
+`
+
+func TestUnusedDeclarations(t *testing.T) {
+	WithOptions(
+		Settings{"unusedDeclarations": "Unexported"},
+	).Run(t, unusedFiles, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var diags protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "func (unusedFunc)"), WithMessage("function unusedFunc is unused")),
+			Diagnostics(env.AtRegexp("a/a.go", "unused int"), WithMessage("field unused is unused")),
+			Diagnostics(env.AtRegexp("a/a.go", "c2"), WithMessage("constant c2 is unused")),
+			NoDiagnostics(env.AtRegexp("a/a.go", "Tagged")),
+			NoDiagnostics(env.AtRegexp("a/a.go", "Dead")),
+			ReadDiagnostics("a/a.go", &diags),
+		)
+		// The fake editor applies a single edit per buffer version, so
+		// delete the declarations one at a time.
+		for len(diags.Diagnostics) > 0 {
+			env.ApplyQuickFixes("a/a.go", diags.Diagnostics[:1])
+			env.AfterChange(ReadDiagnostics("a/a.go", &diags))
+		}
+		want := `package a
+
+func usedFunc() {}
+
+type T struct {
+	used   int
+	Tagged int ` + "`json:\"tagged\"`" + `
+}
+
+const (
+	c1 = 1
+)
+
+func Exported() int {
+	usedFunc()
+	var t T
+	return t.used + c1
+}
+
+func Dead() {}
+`
+		if got := env.BufferText("a/a.go"); got != want {
+			t.Errorf("after applying fixes, got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+}
+
+func TestUnusedDeclarations_Exported(t *testing.T) {
+	WithOptions(
+		Settings{
+			"unusedDeclarations":          "All",
+			"unusedDeclarationsAllowlist": []string{"mod.com/a.T.*"},
+		},
+	).Run(t, unusedFiles, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "Dead"), WithMessage("function Dead is unused")),
+			Diagnostics(env.AtRegexp("a/a.go", "func (unusedFunc)")),
+			NoDiagnostics(env.AtRegexp("a/a.go", "Exported")),
+			NoDiagnostics(env.AtRegexp("a/a.go", "unused int")),
+		)
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/lsp/command"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	. "golang.org/x/tools/gopls/internal/lsp/regtest"
+)
+
+func TestCoverageProfile(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func Abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+-- cover.out --
+mode: set
+mod.com/a/a.go:3.21,4.11 1 1
+mod.com/a/a.go:4.11,6.3 1 0
+mod.com/a/a.go:7.2,7.10 1 1
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		coverage := func(args command.CoverageArgs) {
+			args.URI = env.Sandbox.Workdir.URI("a/a.go")
+			cmd, err := command.NewCoverageCommand("", args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, nil)
+		}
+
+		coverage(command.CoverageArgs{Profile: env.Sandbox.Workdir.AbsPath("cover.out")})
+		env.Await(
+			Diagnostics(
+				env.AtRegexp("a/a.go", `{\n\t\treturn -x`),
+				WithMessage("not covered by tests"),
+				WithSeverityTags("coverage", protocol.SeverityInformation, nil),
+			),
+			Diagnostics(
+				env.AtRegexp("a/a.go", "return x"),
+				WithSeverityTags("coverage", protocol.SeverityHint, nil),
+			),
+		)
+		content, _ := env.Hover(env.RegexpSearch("a/a.go", "Abs"))
+		if want := "Test coverage: 66.7% of statements (2/3)"; !strings.Contains(content.Value, want) {
+			t.Errorf("hover over Abs = %q, want it to contain %q", content.Value, want)
+		}
+
+		// Coverage is discarded once the file is edited.
+		env.RegexpReplace("a/a.go", "return x", "return +x")
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+		content, _ = env.Hover(env.RegexpSearch("a/a.go", "Abs"))
+		if strings.Contains(content.Value, "Test coverage") {
+			t.Errorf("hover over edited Abs = %q, want no coverage", content.Value)
+		}
+
+		// Reverting the edit restores it, until it is cleared.
+		env.RegexpReplace("a/a.go", `return \+x`, "return x")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "return x"), WithMessage("covered by tests")),
+		)
+		coverage(command.CoverageArgs{Clear: true})
+		env.Await(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+	})
+}
@@ -5,6 +5,7 @@
 package misc
 
 import (
+	"strings"
 	"testing"
 
 	. "golang.org/x/tools/gopls/internal/lsp/regtest"
@@ -134,3 +135,61 @@ func _() {
 		}
 	})
 }
+
+func TestReorderStructFields(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a.go --
+package a
+
+type T struct {
+	// a is a flag.
+	a bool
+	b int32 // b is a count
+	c, d bool
+}
+
+type Optimal struct {
+	b int32
+	a bool
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a.go")
+		if err := env.Editor.RefactorRewrite(env.Ctx, env.RegexpSearch("a.go", "c, d")); err != nil {
+			t.Fatal(err)
+		}
+		want := `package a
+
+type T struct {
+	b int32 // b is a count
+	// a is a flag.
+	a bool
+	c bool
+	d bool
+}
+
+type Optimal struct {
+	b int32
+	a bool
+}
+`
+		if got := env.BufferText("a.go"); got != want {
+			t.Fatalf("reordering fields failed:\n%s", compare.Text(want, got))
+		}
+
+		// No reordering is offered for structs that are already optimal.
+		actions, err := env.Editor.CodeAction(env.Ctx, env.RegexpSearch("a.go", "a bool\n}"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, action := range actions {
+			if strings.HasPrefix(action.Title, "Reorder fields") {
+				t.Errorf("unexpected code action %q", action.Title)
+			}
+		}
+	})
+}
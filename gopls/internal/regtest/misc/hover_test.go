@@ -491,3 +491,50 @@ func TestHoverEmbedDirective(t *testing.T) {
 		}
 	})
 }
+
+func TestHoverStructLayout(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a.go --
+package a
+
+type T struct {
+	a bool
+	b int32
+	c bool
+}
+
+type G[P any] struct {
+	p P
+}
+`
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			WithOptions(
+				Settings{"structLayoutInHover": enabled},
+			).Run(t, files, func(t *testing.T, env *Env) {
+				env.OpenFile("a.go")
+				got, _ := env.Hover(env.RegexpSearch("a.go", "type (T)"))
+				for _, want := range []string{
+					"// size=12, align=4",
+					"// size=8 with fields reordered",
+					"// offset=0, size=1, padding=3",
+					"// offset=4, size=4\n",
+					"// offset=8, size=1, padding=3",
+				} {
+					if has := strings.Contains(got.Value, want); has != enabled {
+						t.Errorf("hover contains %q: %t, want %t; got:\n%s", want, has, enabled, got.Value)
+					}
+				}
+				// The layout of generic types depends on their type arguments.
+				got, _ = env.Hover(env.RegexpSearch("a.go", "type (G)"))
+				if strings.Contains(got.Value, "size=") {
+					t.Errorf("hover over generic type contains layout:\n%s", got.Value)
+				}
+			})
+		})
+	}
+}
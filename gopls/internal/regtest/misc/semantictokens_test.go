@@ -193,3 +193,69 @@ func bar() {}
 		}
 	})
 }
+
+func TestSemanticStringLiterals(t *testing.T) {
+	src := `
+-- go.mod --
+module example.com
+
+go 1.19
+-- main.go --
+package foo
+
+type T struct {
+	F int ` + "`json:\"f,omitempty\" xml:\"f\"`" + `
+}
+
+func logf(format string, args ...interface{}) {}
+
+func _() {
+	logf("%d items in %-8.3s\n", 1, "x")
+}
+`
+	want := []fake.SemanticToken{
+		{Token: "package", TokenType: "keyword"},
+		{Token: "foo", TokenType: "namespace"},
+
+		{Token: "type", TokenType: "keyword"},
+		{Token: "T", TokenType: "type", Mod: "definition"},
+		{Token: "struct", TokenType: "keyword"},
+		{Token: "F", TokenType: "variable", Mod: "definition"},
+		{Token: "int", TokenType: "type", Mod: "defaultLibrary"},
+		{Token: "`", TokenType: "string"},
+		{Token: "json", TokenType: "property"},
+		{Token: `:"f,omitempty" `, TokenType: "string"},
+		{Token: "xml", TokenType: "property"},
+		{Token: `:"f"` + "`", TokenType: "string"},
+
+		{Token: "func", TokenType: "keyword"},
+		{Token: "logf", TokenType: "function", Mod: "definition"},
+		{Token: "format", TokenType: "parameter", Mod: "definition"},
+		{Token: "string", TokenType: "type", Mod: "defaultLibrary"},
+		{Token: "args", TokenType: "parameter", Mod: "definition"},
+		{Token: "...", TokenType: "operator"},
+		{Token: "interface", TokenType: "keyword"},
+
+		{Token: "func", TokenType: "keyword"},
+		{Token: "_", TokenType: "function", Mod: "definition"},
+		{Token: "logf", TokenType: "function"},
+		{Token: `"`, TokenType: "string"},
+		{Token: "%d", TokenType: "macro"},
+		{Token: " items in ", TokenType: "string"},
+		{Token: "%-8.3s", TokenType: "macro"},
+		{Token: `\n"`, TokenType: "string"},
+		{Token: "1", TokenType: "number"},
+		{Token: `"x"`, TokenType: "string"},
+	}
+
+	WithOptions(
+		Modes(Default),
+		Settings{"semanticTokens": true},
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		seen := env.SemanticTokensFull("main.go")
+		if x := cmp.Diff(want, seen); x != "" {
+			t.Errorf("Semantic tokens do not match (-want +got):\n%s", x)
+		}
+	})
+}
@@ -102,6 +102,105 @@ const (
 	})
 }
 
+func TestWorkspaceSymbolFilters(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.17
+-- a/a.go --
+package a
+
+type Server struct {
+	Addr  string
+	debug bool
+}
+
+func (Server) Serve() {}
+
+func Serve() {}
+
+const ServeMode = 0
+-- b/b.go --
+package b
+
+type Serving interface{}
+`
+
+	WithOptions(
+		Settings{"symbolMatcher": string(settings.SymbolFastFuzzy)},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		checkSymbols(env, "type:Serv", "Server", "Serving")
+		checkSymbols(env, "func:Serv", "Serve")
+		checkSymbols(env, "method:Serv", "Server.Serve")
+		checkSymbols(env, "Server. is:exported", "Server.Addr", "Server.Serve")
+		checkSymbols(env, "Server. is:unexported", "Server.debug")
+		checkSymbols(env, "pkg:mod.com/b Serv", "Serving")
+		checkSymbols(env, "pkg:mod.com/... const:", "ServeMode")
+	})
+}
+
+func TestWorkspaceSymbolDependencies(t *testing.T) {
+	const proxy = `
+-- other.com/b@v1.0.0/go.mod --
+module other.com/b
+
+go 1.18
+-- other.com/b@v1.0.0/b/b.go --
+package b
+
+type Loaded struct{}
+-- other.com/b@v1.0.0/extra/extra.go --
+package extra
+
+type UnimportedServer struct {
+	Addr string
+}
+
+func (UnimportedServer) ServeUnimported() {}
+
+func unexportedHelper() {}
+`
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+
+require other.com/b v1.0.0
+-- go.sum --
+other.com/b v1.0.0 h1:0HFdgHsPU9dLuDArOHbgDrexUnDj25mEDV+jLEUqTYQ=
+other.com/b v1.0.0/go.mod h1:mpaz0KLUpfUvRXEFxotjYVf9wJeRloyIDju6Xgi2cnM=
+-- a.go --
+package a
+
+import "other.com/b/b"
+
+var _ b.Loaded
+`
+
+	for _, scope := range []settings.SymbolScope{settings.AllSymbolScope, settings.DependencySymbolScope} {
+		t.Run(string(scope), func(t *testing.T) {
+			WithOptions(
+				ProxyFiles(proxy),
+				Settings{
+					"symbolMatcher": string(settings.SymbolFastFuzzy),
+					"symbolScope":   string(scope),
+				},
+			).Run(t, files, func(t *testing.T, env *Env) {
+				checkSymbols(env, "Loaded", "Loaded")
+				if scope == settings.AllSymbolScope {
+					checkSymbols(env, "UnimportedServer")
+					return
+				}
+				checkSymbols(env, "UnimportedServer", "UnimportedServer", "UnimportedServer.Addr", "UnimportedServer.ServeUnimported")
+				checkSymbols(env, "method:ServeUnimp", "UnimportedServer.ServeUnimported")
+				checkSymbols(env, "pkg:other.com/b/extra is:unexported", "unexportedHelper")
+			})
+		})
+	}
+}
+
 func checkSymbols(env *Env, query string, want ...string) {
 	env.T.Helper()
 	var got []string
@@ -0,0 +1,140 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/lsp/command"
+	"golang.org/x/tools/gopls/internal/lsp/protocol"
+	. "golang.org/x/tools/gopls/internal/lsp/regtest"
+	"golang.org/x/tools/gopls/internal/lsp/tests/compare"
+)
+
+func TestModuleWhy(t *testing.T) {
+	const proxy = `
+-- hasdep.com@v1.2.3/go.mod --
+module hasdep.com
+
+go 1.12
+
+require example.com v1.2.3
+-- hasdep.com@v1.2.3/a/a.go --
+package a
+
+import "example.com/blah"
+
+const Name = blah.Name
+-- example.com@v1.2.3/go.mod --
+module example.com
+
+go 1.12
+-- example.com@v1.2.3/blah/blah.go --
+package blah
+
+const Name = "Blah"
+-- random.com@v1.2.3/go.mod --
+module random.com
+
+go 1.12
+-- random.com@v1.2.3/blah/blah.go --
+package blah
+
+const Name = "Blah"
+`
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+
+require (
+	example.com v1.2.3
+	hasdep.com v1.2.3
+	random.com v1.2.3
+)
+-- go.sum --
+example.com v1.2.3 h1:ihBTGWGjTU3V4ZJ9OmHITkU9WQ4lGdQkMjgyLFk0FaY=
+example.com v1.2.3/go.mod h1:Y2Rc5rVWjWur0h3pd9aEvK5Pof8YKDANh9gHA2Maujo=
+hasdep.com v1.2.3 h1:eRkNDRnOePzizNkHoLaeHhCRrxF3xYYY/gfKhDXJoKw=
+hasdep.com v1.2.3/go.mod h1:ePVZOlez+KZEOejfLPGL2n4i8qiAjrkhQZ4wcImqAes=
+random.com v1.2.3 h1:PzYTykzqqH6+qU0dIgh9iPFbfb4Mm8zNBjWWreRKtx0=
+random.com v1.2.3/go.mod h1:8EGj+8a4Hw1clAp8vbaeHAsKE4sbm536FP7nKyXO+qQ=
+-- main.go --
+package main
+
+import "hasdep.com/a"
+
+func main() {
+	println(a.Name)
+}
+`
+	WithOptions(
+		ProxyFiles(proxy),
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("go.mod")
+
+		cmd, err := command.NewModuleWhyCommand("", command.ModuleWhyArgs{
+			URI:    env.Sandbox.Workdir.URI("go.mod"),
+			Module: "example.com",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result command.ModuleWhyResult
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}, &result)
+		want := command.ModuleWhyResult{
+			ImportChain: []string{"mod.com", "hasdep.com/a", "example.com/blah"},
+			RequiredBy:  []string{"hasdep.com@v1.2.3", "mod.com"},
+		}
+		if !reflect.DeepEqual(result, want) {
+			t.Errorf("ModuleWhy(example.com) = %+v, want %+v", result, want)
+		}
+
+		content, _ := env.Hover(env.RegexpSearch("go.mod", "example.com"))
+		for _, want := range []string{"hasdep.com/a", "Required by:", "`hasdep.com@v1.2.3`"} {
+			if !strings.Contains(content.Value, want) {
+				t.Errorf("hover over example.com = %q, want it to contain %q", content.Value, want)
+			}
+		}
+
+		// Requirements offer fixes based on why they are required,
+		// without waiting for go mod tidy diagnostics.
+		quickFix := func(re, title string) {
+			t.Helper()
+			actions, err := env.Editor.CodeAction(env.Ctx, env.RegexpSearch("go.mod", re), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, action := range actions {
+				if action.Title == title {
+					env.ApplyCodeAction(action)
+					return
+				}
+			}
+			t.Fatalf("no code action %q at %q among %v", title, re, actions)
+		}
+		quickFix("example.com", "Change example.com to indirect")
+		quickFix("random.com", "Remove dependency: random.com")
+		env.AfterChange()
+		const wantMod = `module mod.com
+
+go 1.12
+
+require (
+	example.com v1.2.3 // indirect
+	hasdep.com v1.2.3
+)
+`
+		if got := env.BufferText("go.mod"); got != wantMod {
+			t.Errorf("unexpected go.mod content:\n%s", compare.Text(wantMod, got))
+		}
+	})
+}
@@ -5,6 +5,7 @@
 package template
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -215,6 +216,128 @@ go 1.12
 	})
 }
 
+func TestTypedTemplates(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- page/page.go --
+package page
+
+type Page struct {
+	Title  string
+	Author *Person
+	Tags   []Tag
+	secret string
+}
+
+type Person struct {
+	Name string
+}
+
+func (p *Person) Initials() string { return "" }
+
+type Tag struct {
+	Label string
+}
+-- page.tmpl --
+{{/* gopls:type mod.com/page.Page */}}
+<h1>{{.Title}}</h1>
+<p>{{.Author.Name}} ({{.Author.Initials}})</p>
+{{range .Tags}}<span>{{.Label}} {{.Missing}}</span>{{end}}
+{{.secret}}
+{{template "footer" .Author}}
+-- footer.tmpl --
+{{define "footer"}}{{/* gopls:type mod.com/page.Person */}}{{.Name}} {{.Nmae}}{{end}}
+`
+	WithOptions(
+		Settings{
+			"templateExtensions": []string{"tmpl"},
+		},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("page.tmpl")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("page.tmpl", "Missing"), WithMessage("can't evaluate field Missing in type page.Tag")),
+			Diagnostics(env.AtRegexp("page.tmpl", "secret"), WithMessage("secret is an unexported field of struct type page.Page")),
+			Diagnostics(env.AtRegexp("footer.tmpl", "Nmae"), WithMessage("can't evaluate field Nmae in type page.Person")),
+		)
+
+		content, _ := env.Hover(env.RegexpSearch("page.tmpl", "Name"))
+		if want := "field Name string"; !strings.Contains(content.Value, want) {
+			t.Errorf("hover over Name = %q, want it to contain %q", content.Value, want)
+		}
+
+		loc := env.GoToDefinition(env.RegexpSearch("page.tmpl", "Initials"))
+		if name := env.Sandbox.Workdir.URIToPath(loc.URI); name != "page/page.go" {
+			t.Errorf("definition of Initials is in %s, want page/page.go", name)
+		}
+		if got, want := loc, env.RegexpSearch("page/page.go", "Initials"); got != want {
+			t.Errorf("definition of Initials = %v, want %v", got, want)
+		}
+		loc = env.GoToDefinition(env.RegexpSearch("page.tmpl", `"(footer)"`))
+		if got, want := loc, env.RegexpSearch("footer.tmpl", `"(footer)"`); got != want {
+			t.Errorf("definition of footer = %v, want %v", got, want)
+		}
+
+		completions := env.Completion(env.RegexpSearch("page.tmpl", `{{\.()Label`))
+		var got []string
+		for _, item := range completions.Items {
+			got = append(got, item.Label)
+		}
+		if want := []string{"Label"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("completions inside range = %v, want %v", got, want)
+		}
+
+		// Complete the fields of .Author, in the unfinished action.
+		env.RegexpReplace("page.tmpl", "{{.secret}}", "{{.Author.}}")
+		completions = env.Completion(env.RegexpSearch("page.tmpl", `\.Author\.()}}`))
+		got = nil
+		for _, item := range completions.Items {
+			got = append(got, item.Label)
+		}
+		if want := []string{"Initials", "Name"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("completions after .Author. = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestTypedNestedTemplates(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- page/page.go --
+package page
+
+type Page struct {
+	Title  string
+	Author *Person
+}
+
+type Person struct {
+	Name string
+}
+-- page.tmpl --
+{{define "page"}}{{/* gopls:type mod.com/page.Page */}}{{.Title}} {{.Titel}}
+{{block "author" .Author}}{{/* gopls:type mod.com/page.Person */}}{{.Name}} {{.Nmae}}{{end}}
+{{.Author.Name}}{{end}}
+`
+	WithOptions(
+		Settings{
+			"templateExtensions": []string{"tmpl"},
+		},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("page.tmpl")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("page.tmpl", "Titel"), WithMessage("can't evaluate field Titel in type page.Page")),
+			Diagnostics(env.AtRegexp("page.tmpl", "Nmae"), WithMessage("can't evaluate field Nmae in type page.Person")),
+			NoDiagnostics(ForFile("page.tmpl"), WithMessage("duplicate")),
+		)
+	})
+}
+
 // shorten long URIs
 func shorten(fn protocol.DocumentURI) string {
 	if len(fn) <= 20 {
@@ -747,6 +747,185 @@ module example.com/bar/baz
 	})
 }
 
+func TestUseGoWorkDefinition(t *testing.T) {
+	const files = `
+-- go.work --
+go 1.18
+
+use ./foo
+-- foo/go.mod --
+module example.com/foo
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("go.work")
+		loc := env.GoToDefinition(env.RegexpSearch("go.work", `\./foo`))
+		if got, want := env.Sandbox.Workdir.URIToPath(loc.URI), "foo/go.mod"; got != want {
+			t.Errorf("definition of ./foo: got %s, want %s", got, want)
+		}
+		if want := env.RegexpSearch("foo/go.mod", "module example.com/foo").Range; loc.Range != want {
+			t.Errorf("definition of ./foo: got range %v, want %v", loc.Range, want)
+		}
+	})
+}
+
+func TestUseGoWorkCodeLenses(t *testing.T) {
+	const files = `
+-- go.work --
+go 1.18
+
+use ./foo
+-- foo/go.mod --
+module example.com/foo
+-- bar/go.mod --
+module example.com/bar
+-- bar/testdata/go.mod --
+module example.com/bar/testdata
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("go.work")
+		lenses := func() map[string]*protocol.Command {
+			lenses := make(map[string]*protocol.Command)
+			for _, lens := range env.CodeLens("go.work") {
+				lenses[lens.Command.Title] = lens.Command
+			}
+			return lenses
+		}
+		var titles []string
+		for _, lens := range env.CodeLens("go.work") {
+			titles = append(titles, lens.Command.Title)
+		}
+		if got, want := strings.Join(titles, ", "), "Add module ./bar, Remove module"; got != want {
+			t.Fatalf("go.work code lenses: got %q, want %q", got, want)
+		}
+		// Lenses refer to the view, which changes along with go.work, so
+		// fetch them again before each command.
+		run := func(title string) {
+			cmd := lenses()[title]
+			if cmd == nil {
+				t.Fatalf("no code lens %q", title)
+			}
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, nil)
+			env.AfterChange()
+		}
+
+		run("Add module ./bar")
+		if got := env.ReadWorkspaceFile("go.work"); !strings.Contains(got, "./bar") {
+			t.Errorf("after adding ./bar, go.work is:\n%s", got)
+		}
+		run("Remove module")
+		if got := env.ReadWorkspaceFile("go.work"); strings.Contains(got, "./foo") {
+			t.Errorf("after removing ./foo, go.work is:\n%s", got)
+		}
+
+		// The modules found are memoized, until a go.mod file changes.
+		if lenses()["Add module ./foo"] == nil {
+			t.Errorf("no code lens to add the removed module ./foo")
+		}
+		env.WriteWorkspaceFile("baz/go.mod", "module example.com/baz\n")
+		env.AfterChange()
+		if lenses()["Add module ./baz"] == nil {
+			t.Errorf("no code lens to add the new module ./baz")
+		}
+	})
+}
+
+func TestUseGoWorkRenameDirectory(t *testing.T) {
+	const files = `
+-- go.work --
+go 1.18
+
+use (
+	./foo
+	./foo/sub
+	./bar
+)
+-- foo/go.mod --
+module example.com/foo
+-- foo/sub/go.mod --
+module example.com/foo/sub
+-- bar/go.mod --
+module example.com/bar
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("go.work")
+		env.RenameFile("foo", "lib")
+		want := `go 1.18
+
+use (
+	./lib
+	./lib/sub
+	./bar
+)
+`
+		if got := env.BufferText("go.work"); got != want {
+			t.Errorf("after renaming foo, go.work is:\n%s\nwant:\n%s", got, want)
+		}
+	})
+}
+
+func TestUseGoWorkReplaceConflicts(t *testing.T) {
+	const files = `
+-- go.work --
+go 1.18
+
+use (
+	./a
+	./b
+	./c
+)
+
+replace example.com/over => ./over
+-- a/go.mod --
+module example.com/a
+
+replace example.com/over => ./local
+
+replace example.com/dep => ../dep1
+-- b/go.mod --
+module example.com/b
+
+replace example.com/dep => ../dep2
+-- c/go.mod --
+module example.com/c
+
+replace example.com/dep => ../dep1
+-- over/go.mod --
+module example.com/over
+-- dep1/go.mod --
+module example.com/dep
+-- dep2/go.mod --
+module example.com/dep
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("go.work")
+		env.AfterChange(
+			Diagnostics(
+				env.AtRegexp("go.work", "replace example.com/over"),
+				WithMessage("replacement of example.com/over overrides ./local in ./a/go.mod"),
+				WithSeverityTags("go.work file", protocol.SeverityWarning, nil),
+			),
+			Diagnostics(
+				env.AtRegexp("go.work", "./b"),
+				WithMessage("conflicting replacements for example.com/dep"),
+			),
+			NoDiagnostics(env.AtRegexp("go.work", "./c")),
+		)
+
+		// Overriding the replacements in go.work resolves the conflict.
+		env.RegexpReplace("go.work", "replace example.com/over", "replace example.com/dep => ./dep1\n\nreplace example.com/over")
+		env.AfterChange(
+			NoDiagnostics(env.AtRegexp("go.work", "./b")),
+			Diagnostics(
+				env.AtRegexp("go.work", "replace example.com/dep"),
+				WithMessage("replacement of example.com/dep overrides ../dep2 in ./b/go.mod"),
+			),
+		)
+	})
+}
+
 func TestExpandToGoWork(t *testing.T) {
 	const workspace = `
 -- moda/a/go.mod --
@@ -47,11 +47,12 @@ type Analyzer struct {
 	ActionKind []protocol.CodeActionKind
 
 	// Severity is the severity set for diagnostics reported by this
-	// analyzer. If left unset it defaults to Warning.
+	// analyzer. If left unset it defaults to the severity of each
+	// diagnostic, or Warning if that is unspecified too.
 	Severity protocol.DiagnosticSeverity
 
 	// Tag is extra tags (unnecessary, deprecated, etc) for diagnostics
-	// reported by this analyzer.
+	// reported by this analyzer, in addition to their own tags.
 	Tag []protocol.DiagnosticTag
 }
 
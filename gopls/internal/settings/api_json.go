@@ -48,6 +48,14 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:    "experimental",
 				Hierarchy: "build",
 			},
+			{
+				Name:      "memoryBudget",
+				Type:      "string",
+				Doc:       "memoryBudget is a soft limit on the size of the gopls heap, expressed\nas a number of bytes with an optional unit suffix, such as `\"4GiB\"`.\n\ngopls keeps in memory the type-checked packages that are open, and\nthose imported by open packages, in an import graph that snapshots\nshare; all other packages are held only as export data in its file\ncache. When the heap grows beyond the budget, gopls releases the\nimport graphs, reverting the packages that are not open to export\ndata, from which they are imported afresh for each operation while\nthe heap remains over budget. It also evicts from its parse cache the\nparsed files that do not belong to open packages, and drops the\nsymbols of dependency modules held for workspace symbol queries.\nOpen packages are not evicted. The default value, `\"\"`, imposes no\nlimit.\n",
+				Default:   "\"\"",
+				Status:    "experimental",
+				Hierarchy: "build",
+			},
 			{
 				Name:      "expandWorkspaceToModule",
 				Type:      "bool",
@@ -110,6 +118,14 @@ var GeneratedAPIJSON = &APIJSON{
 				Default:   "true",
 				Hierarchy: "ui.documentation",
 			},
+			{
+				Name:      "structLayoutInHover",
+				Type:      "bool",
+				Doc:       "structLayoutInHover toggles the presence of the memory layout of\nstruct types in hover: their size and alignment, and the offset,\nsize and trailing padding of each field, as laid out by the gc\ncompiler for the view's GOARCH.\n",
+				Default:   "false",
+				Status:    "experimental",
+				Hierarchy: "ui.documentation",
+			},
 			{
 				Name:      "usePlaceholders",
 				Type:      "bool",
@@ -204,12 +220,16 @@ var GeneratedAPIJSON = &APIJSON{
 			{
 				Name: "symbolScope",
 				Type: "enum",
-				Doc:  "symbolScope controls which packages are searched for workspace/symbol\nrequests. The default value, \"workspace\", searches only workspace\npackages. The legacy behavior, \"all\", causes all loaded packages to be\nsearched, including dependencies; this is more expensive and may return\nunwanted results.\n",
+				Doc:  "symbolScope controls which packages are searched for workspace/symbol\nrequests. The default value, \"workspace\", searches only workspace\npackages. The legacy behavior, \"all\", causes all loaded packages to be\nsearched, including dependencies; this is more expensive and may return\nunwanted results. \"dependencies\" additionally searches every package\nof the modules in the module cache that the workspace depends on,\nincluding packages that the workspace does not import; this is the\nmost expensive.\n",
 				EnumValues: []EnumValue{
 					{
 						Value: "\"all\"",
 						Doc:   "`\"all\"` matches symbols in any loaded package, including\ndependencies.\n",
 					},
+					{
+						Value: "\"dependencies\"",
+						Doc:   "`\"dependencies\"` matches symbols in any loaded package, and in\nany package of the dependency modules in the module cache.\n",
+					},
 					{
 						Value: "\"workspace\"",
 						Doc:   "`\"workspace\"` matches symbols in workspace packages only.\n",
@@ -345,6 +365,11 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "check consistency of Printf format strings and arguments\n\nThe check applies to calls of the formatting functions such as\n[fmt.Printf] and [fmt.Sprintf], as well as any detected wrappers of\nthose functions.\n\nIn this example, the %d format operator requires an integer operand:\n\n\tfmt.Printf(\"%d\", \"hello\") // fmt.Printf format %d has arg \"hello\" of wrong type string\n\nSee the documentation of the fmt package for the complete set of\nformat operators and their operand types.\n\nTo enable printf checking on a function that is not found by this\nanalyzer's heuristics (for example, because control is obscured by\ndynamic method calls), insert a bogus call:\n\n\tfunc MyPrintf(format string, args ...any) {\n\t\tif false {\n\t\t\t_ = fmt.Sprintf(format, args...) // enable printf checker\n\t\t}\n\t\t...\n\t}\n\nThe -funcs flag specifies a comma-separated list of names of additional\nknown formatting functions or methods. If the name contains a period,\nit must denote a specific function using one of the following forms:\n\n\tdir/pkg.Function\n\tdir/pkg.Type.Method\n\t(*dir/pkg.Type).Method\n\nOtherwise the name is interpreted as a case-insensitive unqualified\nidentifier such as \"errorf\". Either way, if a listed name ends in f, the\nfunction is assumed to be Printf-like, taking a format string before the\nargument list. Otherwise it is assumed to be Print-like, taking a list\nof arguments with no format string.",
 							Default: "true",
 						},
+						{
+							Name:    "\"regexpsyntax\"",
+							Doc:     "check the syntax of regular expressions\n\nThis analyzer reports calls to functions of the regexp package, such as\nregexp.MustCompile or regexp.MatchString, whose pattern is a constant\nthat is not a valid regular expression. For example:\n\n\tvar re = regexp.MustCompile(\"(a|b\") // error: missing closing )\n\nSuch calls fail, or panic, every time they are executed.",
+							Default: "true",
+						},
 						{
 							Name:    "\"shadow\"",
 							Doc:     "check for possible unintended shadowing of variables\n\nThis analyzer check for shadowed variables.\nA shadowed variable is a variable declared in an inner scope\nwith the same name and type as a variable in an outer scope,\nand where the outer variable is mentioned after the inner one\nis declared.\n\n(This definition can be refined; the module generates too many\nfalse positives and is not yet enabled by default.)\n\nFor example:\n\n\tfunc BadRead(f *os.File, buf []byte) error {\n\t\tvar err error\n\t\tfor {\n\t\t\tn, err := f.Read(buf) // shadows the function variable 'err'\n\t\t\tif err != nil {\n\t\t\t\tbreak // causes return of wrong value\n\t\t\t}\n\t\t\tfoo(buf)\n\t\t}\n\t\treturn err\n\t}",
@@ -501,7 +526,7 @@ var GeneratedAPIJSON = &APIJSON{
 			{
 				Name: "annotations",
 				Type: "map[string]bool",
-				Doc:  "annotations specifies the various kinds of optimization diagnostics\nthat should be reported by the gc_details command.\n",
+				Doc:  "annotations specifies the various kinds of optimization details\nthat should be reported by the gc_details command. The details are\nshown as inlay hints, or as diagnostics if the client does not\nsupport inlay hints.\n",
 				EnumKeys: EnumKeys{
 					ValueType: "bool",
 					Keys: []EnumKey{
@@ -549,6 +574,36 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:    "experimental",
 				Hierarchy: "ui.diagnostic",
 			},
+			{
+				Name: "unusedDeclarations",
+				Type: "enum",
+				Doc:  "unusedDeclarations controls the reporting of package-level functions,\ntypes and constants, and of struct fields, that are declared in a\npackage with open files but are not referenced anywhere in the\nworkspace. Each report offers a quick fix to delete the declaration.\n\nMethods are never reported, since they may be called dynamically\nthrough interfaces. Nor are functions implemented in assembly, symbols\nnamed by a `//go:linkname` or `//export` directive, or struct fields\nwith tags, which are typically accessed by reflection.\n",
+				EnumValues: []EnumValue{
+					{
+						Value: "\"All\"",
+						Doc:   "`\"All\"`: Report unused exported and unexported declarations. Exported\ndeclarations are unused if no package in the workspace refers to them.\n",
+					},
+					{
+						Value: "\"Off\"",
+						Doc:   "`\"Off\"`: Do not report unused declarations.\n",
+					},
+					{
+						Value: "\"Unexported\"",
+						Doc:   "`\"Unexported\"`: Report unused unexported declarations, which can be referenced only\nfrom within their own package.\n",
+					},
+				},
+				Default:   "\"Off\"",
+				Status:    "experimental",
+				Hierarchy: "ui.diagnostic",
+			},
+			{
+				Name:      "unusedDeclarationsAllowlist",
+				Type:      "[]string",
+				Doc:       "unusedDeclarationsAllowlist holds patterns of declarations that are\nnever reported by unusedDeclarations, for example because they are\naccessed only by reflection. Each pattern is matched using\n[path.Match](https://pkg.go.dev/path#Match) against the qualified name\nof a declaration, such as `example.com/pkg.Func` or\n`example.com/pkg.Type.Field`.\n\nExample Usage:\n\n```json5\n\"unusedDeclarationsAllowlist\": [\"example.com/pkg.*\", \"example.com/api.Config.*\"]\n```\n",
+				Default:   "[]",
+				Status:    "experimental",
+				Hierarchy: "ui.diagnostic",
+			},
 			{
 				Name:      "diagnosticsDelay",
 				Type:      "time.Duration",
@@ -592,6 +647,11 @@ var GeneratedAPIJSON = &APIJSON{
 						Doc:     "Enable/disable inlay hints for variable types in assign statements:\n```go\n\ti/* int*/, j/* int*/ := 0, len(r)-1\n```",
 						Default: "false",
 					},
+					{
+						Name:    "\"capturedLoopVariables\"",
+						Doc:     "Enable/disable inlay hints for loop variables captured by function literals, such as closures started as goroutines:\n```go\n\tfor _, v := range values {\n\t\tgo func() {\n\t\t\tuse(v/* captured loop var*/)\n\t\t}()\n\t}\n```",
+						Default: "false",
+					},
 					{
 						Name:    "\"compositeLiteralFields\"",
 						Doc:     "Enable/disable inlay hints for composite literal field names:\n```go\n\t{/*in: */\"Hello, world\", /*want: */\"dlrow ,olleH\"}\n```",
@@ -607,11 +667,31 @@ var GeneratedAPIJSON = &APIJSON{
 						Doc:     "Enable/disable inlay hints for constant values:\n```go\n\tconst (\n\t\tKindNone   Kind = iota/* = 0*/\n\t\tKindPrint/*  = 1*/\n\t\tKindPrintf/* = 2*/\n\t\tKindErrorf/* = 3*/\n\t)\n```",
 						Default: "false",
 					},
+					{
+						Name:    "\"deferArguments\"",
+						Doc:     "Enable/disable inlay hints for the arguments of deferred calls, which are evaluated at the defer statement rather than when the call runs:\n```go\n\tdefer log.Printf(\"took %v\", time.Since(start)/* evaluated at defer*/)\n```",
+						Default: "false",
+					},
 					{
 						Name:    "\"functionTypeParameters\"",
 						Doc:     "Enable/disable inlay hints for implicit type parameters on generic functions:\n```go\n\tmyFoo/*[int, string]*/(1, \"hello\")\n```",
 						Default: "false",
 					},
+					{
+						Name:    "\"implicitAddressOf\"",
+						Doc:     "Enable/disable inlay hints for the implicit address-of operation on the receiver of methods with pointer receivers:\n```go\n\t/*(&*/buf/*)*/.WriteString(\"hello\")\n```",
+						Default: "false",
+					},
+					{
+						Name:    "\"interfaceConversions\"",
+						Doc:     "Enable/disable inlay hints for implicit conversions of call arguments to non-empty interface types:\n```go\n\tio.Copy(/*io.Writer(*/f/*)*/, /*io.Reader(*/r/*)*/)\n```",
+						Default: "false",
+					},
+					{
+						Name:    "\"methodValueTypeParameters\"",
+						Doc:     "Enable/disable inlay hints for the inferred type arguments of method values of generic types, and of generic functions used as values:\n```go\n\tpush := stack.Push/*[int]*/\n\tvar f func(int) string = format/*[int]*/\n```",
+						Default: "false",
+					},
 					{
 						Name:    "\"parameterNames\"",
 						Doc:     "Enable/disable inlay hints for parameter names:\n```go\n\tparseInt(/* str: */ \"123\", /* radix: */ 8)\n```",
@@ -636,7 +716,7 @@ var GeneratedAPIJSON = &APIJSON{
 					Keys: []EnumKey{
 						{
 							Name:    "\"gc_details\"",
-							Doc:     "Toggle the calculation of gc annotations.",
+							Doc:     "Toggle the calculation of gc annotations: the optimization decisions\nof the compiler, such as escapes to the heap, inlining, and bounds and\nnil checks, in the package of the file. They are shown as inlay hints,\nwhose tooltips and hovers explain the escape of values, or as\ndiagnostics if the client does not support inlay hints, and are\nrecomputed whenever the files of the package are saved.",
 							Default: "false",
 						},
 						{
@@ -644,6 +724,16 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "Runs `go generate` for a given directory.",
 							Default: "true",
 						},
+						{
+							Name:    "\"go_work_use\"",
+							Doc:     "Adds a module to the use directives of the current go.work file,\nby running `go work use`, or removes it, by running\n`go work edit -dropuse`. The go_work_use code lens offers this for\neach module used by a go.work file, and for each module beneath its\ndirectory that it does not use.",
+							Default: "true",
+						},
+						{
+							Name:    "\"references\"",
+							Doc:     "Returns the references to the symbol declared at the given location,\nfor presentation in the client's references view. This is the\ncommand of the references code lens, which displays the number of\nreferences to (and implementations of) each top-level function, type\nand method.\n\nLSP provides no means for the server to open the references view,\nso clients must handle the result of this command themselves; for\nexample, VS Code can pass it to editor.action.showReferences. If\nthere is exactly one reference, the server also asks the client to\nshow it, using window/showDocument.",
+							Default: "false",
+						},
 						{
 							Name:    "\"regenerate_cgo\"",
 							Doc:     "Regenerates cgo definitions.",
@@ -676,7 +766,7 @@ var GeneratedAPIJSON = &APIJSON{
 						},
 					},
 				},
-				Default:   "{\"gc_details\":false,\"generate\":true,\"regenerate_cgo\":true,\"tidy\":true,\"upgrade_dependency\":true,\"vendor\":true}",
+				Default:   "{\"gc_details\":false,\"generate\":true,\"go_work_use\":true,\"references\":false,\"regenerate_cgo\":true,\"tidy\":true,\"upgrade_dependency\":true,\"vendor\":true}",
 				Hierarchy: "ui",
 			},
 			{
@@ -727,6 +817,12 @@ var GeneratedAPIJSON = &APIJSON{
 		},
 	},
 	Commands: []*CommandJSON{
+		{
+			Command: "gopls.add_cached_dependency",
+			Title:   "Add a dependency from the module cache",
+			Doc:     "Runs `go get` for a module version in the module cache, or one of\nits packages, without network access, to add it and its requirements\nto the go.mod and go.sum files of the module containing the given\nfile. It is used by the completion of packages of modules that are\nnot yet required.",
+			ArgDoc:  "{\n\t// Any document URI within the relevant module.\n\t\"URI\": string,\n\t// The path and version of the module in the module cache.\n\t\"Module\": string,\n\t\"Version\": string,\n\t// The package of the module to be imported, if any, whose imports are\n\t// resolved too.\n\t\"Package\": string,\n}",
+		},
 		{
 			Command: "gopls.add_dependency",
 			Title:   "Add a dependency",
@@ -763,6 +859,12 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Checks for module upgrades.",
 			ArgDoc:  "{\n\t// The go.mod file URI.\n\t\"URI\": string,\n\t// The modules to check.\n\t\"Modules\": []string,\n}",
 		},
+		{
+			Command: "gopls.coverage",
+			Title:   "Show test coverage",
+			Doc:     "Runs `go test -coverprofile` for the package of the given file, or\nloads an existing coverage profile, and reports the coverage of each\nfile in the workspace: statements that were not executed by tests are\nreported as diagnostics, and hovering over the name of a function\nshows the percentage of its statements that were executed. The\ncoverage of a file is discarded once it is edited.",
+			ArgDoc:  "{\n\t// A file of the package whose tests are run, or, when loading or\n\t// clearing coverage, any file of the workspace.\n\t\"URI\": string,\n\t// The path of an existing coverage profile, as produced by\n\t// `go test -coverprofile`, to load instead of running tests.\n\t\"Profile\": string,\n\t// Whether to discard all test coverage instead.\n\t\"Clear\": bool,\n}",
+		},
 		{
 			Command: "gopls.edit_go_directive",
 			Title:   "Run go mod edit -go=version",
@@ -779,7 +881,7 @@ var GeneratedAPIJSON = &APIJSON{
 		{
 			Command: "gopls.gc_details",
 			Title:   "Toggle gc_details",
-			Doc:     "Toggle the calculation of gc annotations.",
+			Doc:     "Toggle the calculation of gc annotations: the optimization decisions\nof the compiler, such as escapes to the heap, inlining, and bounds and\nnil checks, in the package of the file. They are shown as inlay hints,\nwhose tooltips and hovers explain the escape of values, or as\ndiagnostics if the client does not support inlay hints, and are\nrecomputed whenever the files of the package are saved.",
 			ArgDoc:  "string",
 		},
 		{
@@ -794,6 +896,12 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Runs `go get` to fetch a package.",
 			ArgDoc:  "{\n\t// Any document URI within the relevant module.\n\t\"URI\": string,\n\t// The package to go get.\n\t\"Pkg\": string,\n\t\"AddRequire\": bool,\n}",
 		},
+		{
+			Command: "gopls.go_work_use",
+			Title:   "Add or remove a go.work module",
+			Doc:     "Adds a module to the use directives of the current go.work file,\nby running `go work use`, or removes it, by running\n`go work edit -dropuse`. The go_work_use code lens offers this for\neach module used by a go.work file, and for each module beneath its\ndirectory that it does not use.",
+			ArgDoc:  "{\n\t\"ViewID\": string,\n\t\"Dir\": string,\n\t\"Drop\": bool,\n}",
+		},
 		{
 			Command:   "gopls.list_imports",
 			Title:     "List imports of a file and its package",
@@ -819,6 +927,20 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:       "Call runtime.GC multiple times and return memory statistics as reported by\nruntime.MemStats.\n\nThis command is used for benchmarking, and may change in the future.",
 			ResultDoc: "{\n\t\"HeapAlloc\": uint64,\n\t\"HeapInUse\": uint64,\n\t\"TotalAlloc\": uint64,\n}",
 		},
+		{
+			Command:   "gopls.module_why",
+			Title:     "Explain why a module is required",
+			Doc:       "Reports why a module is required by the given go.mod file: the\nshortest chain of imports from a package of the main module to a\npackage of the module, as computed by `go mod why -m`, and the\nmodules of the module graph that require it.",
+			ArgDoc:    "{\n\t// The go.mod file.\n\t\"URI\": string,\n\t// The path of a module required by the go.mod file.\n\t\"Module\": string,\n}",
+			ResultDoc: "{\n\t// ImportChain is the shortest chain of import paths from a package of\n\t// the main module to a package of the module. It is empty if the main\n\t// module does not need the module.\n\t\"ImportChain\": []string,\n\t// RequiredBy lists the modules of the module graph that require the\n\t// module, as \"path@version\" (or just \"path\" for the main module).\n\t\"RequiredBy\": []string,\n}",
+		},
+		{
+			Command:   "gopls.references",
+			Title:     "Show references",
+			Doc:       "Returns the references to the symbol declared at the given location,\nfor presentation in the client's references view. This is the\ncommand of the references code lens, which displays the number of\nreferences to (and implementations of) each top-level function, type\nand method.\n\nLSP provides no means for the server to open the references view,\nso clients must handle the result of this command themselves; for\nexample, VS Code can pass it to editor.action.showReferences. If\nthere is exactly one reference, the server also asks the client to\nshow it, using window/showDocument.",
+			ArgDoc:    "{\n\t// The location of the declaring identifier.\n\t\"Location\": {\n\t\t\"uri\": string,\n\t\t\"range\": {\n\t\t\t\"start\": { ... },\n\t\t\t\"end\": { ... },\n\t\t},\n\t},\n\t// Whether the symbol is a type or method, and so may have\n\t// implementations.\n\t\"Implementations\": bool,\n}",
+			ResultDoc: "[]{\n\t\"uri\": string,\n\t\"range\": {\n\t\t\"start\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t\t\"end\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t},\n}",
+		},
 		{
 			Command: "gopls.regenerate_cgo",
 			Title:   "Regenerate cgo",
@@ -892,7 +1014,7 @@ var GeneratedAPIJSON = &APIJSON{
 		{
 			Command: "gopls.toggle_gc_details",
 			Title:   "Toggle gc_details",
-			Doc:     "Toggle the calculation of gc annotations.",
+			Doc:     "Toggle the calculation of gc annotations: the optimization decisions\nof the compiler, such as escapes to the heap, inlining, and bounds and\nnil checks, in the package of the file. They are shown as inlay hints,\nwhose tooltips and hovers explain the escape of values, or as\ndiagnostics if the client does not support inlay hints, and are\nrecomputed whenever the files of the package are saved.",
 			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
 		},
 		{
@@ -924,13 +1046,23 @@ var GeneratedAPIJSON = &APIJSON{
 		{
 			Lens:  "gc_details",
 			Title: "Toggle gc_details",
-			Doc:   "Toggle the calculation of gc annotations.",
+			Doc:   "Toggle the calculation of gc annotations: the optimization decisions\nof the compiler, such as escapes to the heap, inlining, and bounds and\nnil checks, in the package of the file. They are shown as inlay hints,\nwhose tooltips and hovers explain the escape of values, or as\ndiagnostics if the client does not support inlay hints, and are\nrecomputed whenever the files of the package are saved.",
 		},
 		{
 			Lens:  "generate",
 			Title: "Run go generate",
 			Doc:   "Runs `go generate` for a given directory.",
 		},
+		{
+			Lens:  "go_work_use",
+			Title: "Add or remove a go.work module",
+			Doc:   "Adds a module to the use directives of the current go.work file,\nby running `go work use`, or removes it, by running\n`go work edit -dropuse`. The go_work_use code lens offers this for\neach module used by a go.work file, and for each module beneath its\ndirectory that it does not use.",
+		},
+		{
+			Lens:  "references",
+			Title: "Show references",
+			Doc:   "Returns the references to the symbol declared at the given location,\nfor presentation in the client's references view. This is the\ncommand of the references code lens, which displays the number of\nreferences to (and implementations of) each top-level function, type\nand method.\n\nLSP provides no means for the server to open the references view,\nso clients must handle the result of this command themselves; for\nexample, VS Code can pass it to editor.action.showReferences. If\nthere is exactly one reference, the server also asks the client to\nshow it, using window/showDocument.",
+		},
 		{
 			Lens:  "regenerate_cgo",
 			Title: "Regenerate cgo",
@@ -1104,6 +1236,11 @@ var GeneratedAPIJSON = &APIJSON{
 			URL:     "https://pkg.go.dev/golang.org/x/tools/go/analysis/passes/printf",
 			Default: true,
 		},
+		{
+			Name:    "regexpsyntax",
+			Doc:     "check the syntax of regular expressions\n\nThis analyzer reports calls to functions of the regexp package, such as\nregexp.MustCompile or regexp.MatchString, whose pattern is a constant\nthat is not a valid regular expression. For example:\n\n\tvar re = regexp.MustCompile(\"(a|b\") // error: missing closing )\n\nSuch calls fail, or panic, every time they are executed.",
+			Default: true,
+		},
 		{
 			Name: "shadow",
 			Doc:  "check for possible unintended shadowing of variables\n\nThis analyzer check for shadowed variables.\nA shadowed variable is a variable declared in an inner scope\nwith the same name and type as a variable in an outer scope,\nand where the outer variable is mentioned after the inner one\nis declared.\n\n(This definition can be refined; the module generates too many\nfalse positives and is not yet enabled by default.)\n\nFor example:\n\n\tfunc BadRead(f *os.File, buf []byte) error {\n\t\tvar err error\n\t\tfor {\n\t\t\tn, err := f.Read(buf) // shadows the function variable 'err'\n\t\t\tif err != nil {\n\t\t\t\tbreak // causes return of wrong value\n\t\t\t}\n\t\t\tfoo(buf)\n\t\t}\n\t\treturn err\n\t}",
@@ -1260,6 +1397,10 @@ var GeneratedAPIJSON = &APIJSON{
 			Name: "assignVariableTypes",
 			Doc:  "Enable/disable inlay hints for variable types in assign statements:\n```go\n\ti/* int*/, j/* int*/ := 0, len(r)-1\n```",
 		},
+		{
+			Name: "capturedLoopVariables",
+			Doc:  "Enable/disable inlay hints for loop variables captured by function literals, such as closures started as goroutines:\n```go\n\tfor _, v := range values {\n\t\tgo func() {\n\t\t\tuse(v/* captured loop var*/)\n\t\t}()\n\t}\n```",
+		},
 		{
 			Name: "compositeLiteralFields",
 			Doc:  "Enable/disable inlay hints for composite literal field names:\n```go\n\t{/*in: */\"Hello, world\", /*want: */\"dlrow ,olleH\"}\n```",
@@ -1272,10 +1413,26 @@ var GeneratedAPIJSON = &APIJSON{
 			Name: "constantValues",
 			Doc:  "Enable/disable inlay hints for constant values:\n```go\n\tconst (\n\t\tKindNone   Kind = iota/* = 0*/\n\t\tKindPrint/*  = 1*/\n\t\tKindPrintf/* = 2*/\n\t\tKindErrorf/* = 3*/\n\t)\n```",
 		},
+		{
+			Name: "deferArguments",
+			Doc:  "Enable/disable inlay hints for the arguments of deferred calls, which are evaluated at the defer statement rather than when the call runs:\n```go\n\tdefer log.Printf(\"took %v\", time.Since(start)/* evaluated at defer*/)\n```",
+		},
 		{
 			Name: "functionTypeParameters",
 			Doc:  "Enable/disable inlay hints for implicit type parameters on generic functions:\n```go\n\tmyFoo/*[int, string]*/(1, \"hello\")\n```",
 		},
+		{
+			Name: "implicitAddressOf",
+			Doc:  "Enable/disable inlay hints for the implicit address-of operation on the receiver of methods with pointer receivers:\n```go\n\t/*(&*/buf/*)*/.WriteString(\"hello\")\n```",
+		},
+		{
+			Name: "interfaceConversions",
+			Doc:  "Enable/disable inlay hints for implicit conversions of call arguments to non-empty interface types:\n```go\n\tio.Copy(/*io.Writer(*/f/*)*/, /*io.Reader(*/r/*)*/)\n```",
+		},
+		{
+			Name: "methodValueTypeParameters",
+			Doc:  "Enable/disable inlay hints for the inferred type arguments of method values of generic types, and of generic functions used as values:\n```go\n\tpush := stack.Push/*[int]*/\n\tvar f func(int) string = format/*[int]*/\n```",
+		},
 		{
 			Name: "parameterNames",
 			Doc:  "Enable/disable inlay hints for parameter names:\n```go\n\tparseInt(/* str: */ \"123\", /* radix: */ 8)\n```",
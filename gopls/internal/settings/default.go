@@ -76,6 +76,7 @@ func DefaultOptions(overrides ...func(*Options)) *Options {
 							Nil:    true,
 						},
 						Vulncheck:                 ModeVulncheckOff,
+						UnusedDeclarations:        UnusedDeclarationsOff,
 						DiagnosticsDelay:          1 * time.Second,
 						DiagnosticsTrigger:        DiagnosticsOnEdit,
 						AnalysisProgressReporting: true,
@@ -100,9 +101,11 @@ func DefaultOptions(overrides ...func(*Options)) *Options {
 					},
 					Codelenses: map[string]bool{
 						string(command.Generate):          true,
+						string(command.GoWorkUse):         true,
 						string(command.RegenerateCgo):     true,
 						string(command.Tidy):              true,
 						string(command.GCDetails):         false,
+						string(command.References):        false,
 						string(command.UpgradeDependency): true,
 						string(command.Vendor):            true,
 						// TODO(hyangah): enable command.RunGovulncheck.
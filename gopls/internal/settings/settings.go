@@ -7,9 +7,12 @@ package settings
 import (
 	"context"
 	"fmt"
+	"math"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -59,6 +62,7 @@ import (
 	"golang.org/x/tools/gopls/internal/lsp/analysis/infertypeargs"
 	"golang.org/x/tools/gopls/internal/lsp/analysis/nonewvars"
 	"golang.org/x/tools/gopls/internal/lsp/analysis/noresultvalues"
+	"golang.org/x/tools/gopls/internal/lsp/analysis/regexpsyntax"
 	"golang.org/x/tools/gopls/internal/lsp/analysis/simplifycompositelit"
 	"golang.org/x/tools/gopls/internal/lsp/analysis/simplifyrange"
 	"golang.org/x/tools/gopls/internal/lsp/analysis/simplifyslice"
@@ -132,6 +136,8 @@ type ClientOptions struct {
 	CompletionTags                             bool
 	CompletionDeprecated                       bool
 	SupportedResourceOperations                []protocol.ResourceOperationKind
+	InlayHintSupported                         bool
+	InlayHintRefreshSupported                  bool
 }
 
 // ServerOptions holds LSP-specific configuration that is provided by the
@@ -181,6 +187,22 @@ type BuildOptions struct {
 	// Values other than `Normal` are untested and may break in surprising ways.
 	MemoryMode MemoryMode `status:"experimental"`
 
+	// MemoryBudget is a soft limit on the size of the gopls heap, expressed
+	// as a number of bytes with an optional unit suffix, such as `"4GiB"`.
+	//
+	// gopls keeps in memory the type-checked packages that are open, and
+	// those imported by open packages, in an import graph that snapshots
+	// share; all other packages are held only as export data in its file
+	// cache. When the heap grows beyond the budget, gopls releases the
+	// import graphs, reverting the packages that are not open to export
+	// data, from which they are imported afresh for each operation while
+	// the heap remains over budget. It also evicts from its parse cache the
+	// parsed files that do not belong to open packages, and drops the
+	// symbols of dependency modules held for workspace symbol queries.
+	// Open packages are not evicted. The default value, `""`, imposes no
+	// limit.
+	MemoryBudget string `status:"experimental"`
+
 	// ExpandWorkspaceToModule instructs `gopls` to adjust the scope of the
 	// workspace to find the best available module root. `gopls` first looks for
 	// a go.mod file in any parent directory of the workspace folder, expanding
@@ -303,6 +325,12 @@ type DocumentationOptions struct {
 
 	// LinksInHover toggles the presence of links to documentation in hover.
 	LinksInHover bool
+
+	// StructLayoutInHover toggles the presence of the memory layout of
+	// struct types in hover: their size and alignment, and the offset,
+	// size and trailing padding of each field, as laid out by the gc
+	// compiler for the view's GOARCH.
+	StructLayoutInHover bool `status:"experimental"`
 }
 
 type FormattingOptions struct {
@@ -339,13 +367,40 @@ type DiagnosticOptions struct {
 	// [Staticcheck's website](https://staticcheck.io/docs/checks/).
 	Staticcheck bool `status:"experimental"`
 
-	// Annotations specifies the various kinds of optimization diagnostics
-	// that should be reported by the gc_details command.
+	// Annotations specifies the various kinds of optimization details
+	// that should be reported by the gc_details command. The details are
+	// shown as inlay hints, or as diagnostics if the client does not
+	// support inlay hints.
 	Annotations map[Annotation]bool `status:"experimental"`
 
 	// Vulncheck enables vulnerability scanning.
 	Vulncheck VulncheckMode `status:"experimental"`
 
+	// UnusedDeclarations controls the reporting of package-level functions,
+	// types and constants, and of struct fields, that are declared in a
+	// package with open files but are not referenced anywhere in the
+	// workspace. Each report offers a quick fix to delete the declaration.
+	//
+	// Methods are never reported, since they may be called dynamically
+	// through interfaces. Nor are functions implemented in assembly, symbols
+	// named by a `//go:linkname` or `//export` directive, or struct fields
+	// with tags, which are typically accessed by reflection.
+	UnusedDeclarations UnusedDeclarationsMode `status:"experimental"`
+
+	// UnusedDeclarationsAllowlist holds patterns of declarations that are
+	// never reported by unusedDeclarations, for example because they are
+	// accessed only by reflection. Each pattern is matched using
+	// [path.Match](https://pkg.go.dev/path#Match) against the qualified name
+	// of a declaration, such as `example.com/pkg.Func` or
+	// `example.com/pkg.Type.Field`.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// "unusedDeclarationsAllowlist": ["example.com/pkg.*", "example.com/api.Config.*"]
+	// ```
+	UnusedDeclarationsAllowlist []string `status:"experimental"`
+
 	// DiagnosticsDelay controls the amount of time that gopls waits
 	// after the most recent file modification before computing deep diagnostics.
 	// Simple diagnostics (parsing and type-checking) are always run immediately
@@ -401,7 +456,10 @@ type NavigationOptions struct {
 	// requests. The default value, "workspace", searches only workspace
 	// packages. The legacy behavior, "all", causes all loaded packages to be
 	// searched, including dependencies; this is more expensive and may return
-	// unwanted results.
+	// unwanted results. "dependencies" additionally searches every package
+	// of the modules in the module cache that the workspace depends on,
+	// including packages that the workspace does not import; this is the
+	// most expensive.
 	SymbolScope SymbolScope
 }
 
@@ -437,6 +495,52 @@ func (u *UserOptions) SetEnvSlice(env []string) {
 	}
 }
 
+// MemoryBudgetBytes returns the MemoryBudget setting as a number of bytes,
+// or 0 if no budget is set.
+func (b *BuildOptions) MemoryBudgetBytes() uint64 {
+	n, _ := ParseByteSize(b.MemoryBudget) // validated by SetOptions
+	return n
+}
+
+// ParseByteSize parses a quantity of bytes such as "512MiB" or "2GB".
+// A number without a unit is interpreted as bytes. The empty string
+// parses as zero.
+func ParseByteSize(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	units := []struct {
+		suffix string
+		scale  uint64
+	}{
+		// Longer suffixes must precede their own suffixes.
+		{"KiB", 1 << 10},
+		{"MiB", 1 << 20},
+		{"GiB", 1 << 30},
+		{"TiB", 1 << 40},
+		{"KB", 1e3},
+		{"MB", 1e6},
+		{"GB", 1e9},
+		{"TB", 1e12},
+		{"B", 1},
+	}
+	num, scale := strings.TrimSpace(s), uint64(1)
+	for _, u := range units {
+		if strings.HasSuffix(num, u.suffix) {
+			num, scale = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseUint(num, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	if n > math.MaxUint64/scale {
+		return 0, fmt.Errorf("byte size %q is too large", s)
+	}
+	return n * scale, nil
+}
+
 // DiffFunction is the type for a function that produces a set of edits that
 // convert from the before content to the after content.
 type DiffFunction func(before, after string) []diff.Edit
@@ -643,6 +747,9 @@ const (
 	// AllSymbolScope matches symbols in any loaded package, including
 	// dependencies.
 	AllSymbolScope SymbolScope = "all"
+	// DependencySymbolScope matches symbols in any loaded package, and in
+	// any package of the dependency modules in the module cache.
+	DependencySymbolScope SymbolScope = "dependencies"
 )
 
 type HoverKind string
@@ -683,6 +790,21 @@ const (
 	// TODO: VulncheckRequire, VulncheckCallgraph
 )
 
+type UnusedDeclarationsMode string
+
+const (
+	// Do not report unused declarations.
+	UnusedDeclarationsOff UnusedDeclarationsMode = "Off"
+
+	// Report unused unexported declarations, which can be referenced only
+	// from within their own package.
+	UnusedDeclarationsUnexported UnusedDeclarationsMode = "Unexported"
+
+	// Report unused exported and unexported declarations. Exported
+	// declarations are unused if no package in the workspace refers to them.
+	UnusedDeclarationsAll UnusedDeclarationsMode = "All"
+)
+
 type DiagnosticsTrigger string
 
 const (
@@ -775,6 +897,11 @@ func (o *Options) ForClientCapabilities(clientName *protocol.Msg_XInitializePara
 	} else if caps.TextDocument.Completion.CompletionItem.DeprecatedSupport {
 		o.CompletionDeprecated = true
 	}
+	// Check if the client supports inlay hints, and requests to refresh them.
+	o.InlayHintSupported = caps.TextDocument.InlayHint != nil
+	if caps.Workspace.InlayHint != nil {
+		o.InlayHintRefreshSupported = caps.Workspace.InlayHint.RefreshSupport
+	}
 }
 
 func (o *Options) Clone() *Options {
@@ -814,6 +941,7 @@ func (o *Options) Clone() *Options {
 	result.BuildFlags = copySlice(o.BuildFlags)
 	result.DirectoryFilters = copySlice(o.DirectoryFilters)
 	result.StandaloneTags = copySlice(o.StandaloneTags)
+	result.UnusedDeclarationsAllowlist = copySlice(o.UnusedDeclarationsAllowlist)
 
 	copyAnalyzerMap := func(src map[string]*Analyzer) map[string]*Analyzer {
 		dst := make(map[string]*Analyzer)
@@ -946,6 +1074,15 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 		); ok {
 			o.MemoryMode = MemoryMode(s)
 		}
+	case "memoryBudget":
+		if v, ok := result.asString(); ok {
+			if _, err := ParseByteSize(v); err != nil {
+				result.parseErrorf("%v", err)
+				break
+			}
+			o.MemoryBudget = v
+		}
+
 	case "completionDocumentation":
 		result.setBool(&o.CompletionDocumentation)
 	case "usePlaceholders":
@@ -988,6 +1125,7 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 		if s, ok := result.asOneOf(
 			string(WorkspaceSymbolScope),
 			string(AllSymbolScope),
+			string(DependencySymbolScope),
 		); ok {
 			o.SymbolScope = SymbolScope(s)
 		}
@@ -1009,6 +1147,9 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "linksInHover":
 		result.setBool(&o.LinksInHover)
 
+	case "structLayoutInHover":
+		result.setBool(&o.StructLayoutInHover)
+
 	case "importShortcut":
 		if s, ok := result.asOneOf(string(BothShortcuts), string(LinkShortcut), string(DefinitionShortcut)); ok {
 			o.ImportShortcut = ImportShortcut(s)
@@ -1124,6 +1265,26 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "experimentalDiagnosticsDelay":
 		result.deprecated("diagnosticsDelay")
 
+	case "unusedDeclarations":
+		if s, ok := result.asOneOf(
+			string(UnusedDeclarationsOff),
+			string(UnusedDeclarationsUnexported),
+			string(UnusedDeclarationsAll),
+		); ok {
+			o.UnusedDeclarations = UnusedDeclarationsMode(s)
+		}
+
+	case "unusedDeclarationsAllowlist":
+		if patterns, ok := result.asStringSlice(); ok {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					result.parseErrorf("invalid pattern %q: %v", pattern, err)
+					return result
+				}
+			}
+			o.UnusedDeclarationsAllowlist = patterns
+		}
+
 	case "diagnosticsDelay":
 		result.setDuration(&o.DiagnosticsDelay)
 
@@ -1473,7 +1634,7 @@ func defaultAnalyzers() map[string]*Analyzer {
 		composite.Analyzer.Name:     {Analyzer: composite.Analyzer, Enabled: true},
 		copylock.Analyzer.Name:      {Analyzer: copylock.Analyzer, Enabled: true},
 		defers.Analyzer.Name:        {Analyzer: defers.Analyzer, Enabled: true},
-		deprecated.Analyzer.Name:    {Analyzer: deprecated.Analyzer, Enabled: true, Severity: protocol.SeverityHint, Tag: []protocol.DiagnosticTag{protocol.Deprecated}},
+		deprecated.Analyzer.Name:    {Analyzer: deprecated.Analyzer, Enabled: true},
 		directive.Analyzer.Name:     {Analyzer: directive.Analyzer, Enabled: true},
 		errorsas.Analyzer.Name:      {Analyzer: errorsas.Analyzer, Enabled: true},
 		httpresponse.Analyzer.Name:  {Analyzer: httpresponse.Analyzer, Enabled: true},
@@ -1498,6 +1659,7 @@ func defaultAnalyzers() map[string]*Analyzer {
 		deepequalerrors.Analyzer.Name:  {Analyzer: deepequalerrors.Analyzer, Enabled: true},
 		fieldalignment.Analyzer.Name:   {Analyzer: fieldalignment.Analyzer, Enabled: false},
 		nilness.Analyzer.Name:          {Analyzer: nilness.Analyzer, Enabled: true},
+		regexpsyntax.Analyzer.Name:     {Analyzer: regexpsyntax.Analyzer, Enabled: true},
 		shadow.Analyzer.Name:           {Analyzer: shadow.Analyzer, Enabled: false},
 		sortslice.Analyzer.Name:        {Analyzer: sortslice.Analyzer, Enabled: true},
 		testinggoroutine.Analyzer.Name: {Analyzer: testinggoroutine.Analyzer, Enabled: true},
@@ -43,6 +43,46 @@ func TestSetOption(t *testing.T) {
 			value: "2s",
 			check: func(o Options) bool { return o.CompletionBudget == 2*time.Second },
 		},
+		{
+			name:  "memoryBudget",
+			value: "4GiB",
+			check: func(o Options) bool { return o.MemoryBudgetBytes() == 4<<30 },
+		},
+		{
+			name:  "memoryBudget",
+			value: "1500 MB",
+			check: func(o Options) bool { return o.MemoryBudgetBytes() == 1500e6 },
+		},
+		{
+			name:      "memoryBudget",
+			value:     "lots",
+			wantError: true,
+			check:     func(o Options) bool { return o.MemoryBudget == "" },
+		},
+		{
+			name:  "unusedDeclarations",
+			value: "Unexported",
+			check: func(o Options) bool { return o.UnusedDeclarations == UnusedDeclarationsUnexported },
+		},
+		{
+			name:      "unusedDeclarations",
+			value:     "Everything",
+			wantError: true,
+			check:     func(o Options) bool { return o.UnusedDeclarations == "" },
+		},
+		{
+			name:  "unusedDeclarationsAllowlist",
+			value: []interface{}{"example.com/pkg.*"},
+			check: func(o Options) bool {
+				return len(o.UnusedDeclarationsAllowlist) == 1 && o.UnusedDeclarationsAllowlist[0] == "example.com/pkg.*"
+			},
+		},
+		{
+			name:      "unusedDeclarationsAllowlist",
+			value:     []interface{}{"example.com/["},
+			wantError: true,
+			check:     func(o Options) bool { return len(o.UnusedDeclarationsAllowlist) == 0 },
+		},
 		{
 			name:      "staticcheck",
 			value:     true,
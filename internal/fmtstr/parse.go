@@ -0,0 +1,215 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fmtstr defines a parser for the format strings of fmt.Printf
+// and similar functions, shared by the printf analyzer and gopls.
+package fmtstr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// An Operation describes a formatting directive such as "%3.*[4]d"
+// in a format string.
+type Operation struct {
+	Text  string // the full directive, from % through the verb: "%3.*[4]d"
+	Start int    // byte offset of the directive within the format string
+	Flags string // the flags, such as "#-"; a precision adds '.'
+	Width Size   // the width, if any
+	Prec  Size   // the precision, if any
+	Verb  Verb   // the verb
+}
+
+// End returns the byte offset just after the directive within the format
+// string.
+func (op *Operation) End() int { return op.Start + len(op.Text) }
+
+// SizeKind is the kind of a width or precision.
+type SizeKind int
+
+const (
+	NoSize  SizeKind = iota // no width or precision
+	Literal                 // a decimal number, as in "%3d"
+	Star                    // the value of an operand, as in "%*d" or "%[2]*d"
+)
+
+// A Size describes the width or precision of an Operation.
+type Size struct {
+	Kind     SizeKind
+	Fixed    int // the value of a Literal size
+	Index    int // the explicit 1-based operand index of a Star size, or 0
+	ArgIndex int // the 0-based index of the operand of a Star size
+}
+
+// A Verb describes the verb of an Operation.
+type Verb struct {
+	Verb     rune // the verb: 'd' for "%d"
+	Index    int  // the explicit 1-based operand index, or 0
+	ArgIndex int  // the 0-based index of the operand, or -1 for "%%"
+}
+
+// Parse parses the directives of the format string. Operand indexes are
+// relative to the first operand following the format.
+//
+// If a directive is malformed, Parse returns the directives that precede
+// it and an error describing it.
+func Parse(format string) ([]*Operation, error) {
+	var ops []*Operation
+	argNum := 0 // the operand we're expecting to format next
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		p := &parser{format: format[i:], nbytes: 1, argNum: argNum}
+		op, err := p.parseOperation()
+		if err != nil {
+			return ops, err
+		}
+		op.Start = i
+		ops = append(ops, op)
+		if p.lastArg >= 0 {
+			// Continue with the next sequential operand.
+			argNum = p.lastArg + 1
+		}
+		i += len(op.Text)
+	}
+	return ops, nil
+}
+
+// parser holds the state of the parsing of a single directive.
+type parser struct {
+	format       string // the rest of the format string, from the %
+	nbytes       int    // number of bytes of format consumed
+	argNum       int    // the operand we're expecting to format now
+	lastArg      int    // the last operand consumed, or -1
+	index        int    // the pending explicit operand index, or 0
+	indexPending bool   // whether index has not been consumed
+}
+
+func (p *parser) parseOperation() (*Operation, error) {
+	p.lastArg = -1
+	op := &Operation{}
+	op.Flags = p.parseFlags()
+	// There may be an index.
+	if err := p.parseIndex(); err != nil {
+		return nil, err
+	}
+	// There may be a width.
+	op.Width = p.parseSize()
+	// There may be a precision.
+	if p.nbytes < len(p.format) && p.format[p.nbytes] == '.' {
+		op.Flags += "." // Treat precision as a flag.
+		p.nbytes++
+		if err := p.parseIndex(); err != nil {
+			return nil, err
+		}
+		op.Prec = p.parseSize()
+	}
+	// Now a verb, possibly prefixed by an index (which we may already have).
+	if !p.indexPending {
+		if err := p.parseIndex(); err != nil {
+			return nil, err
+		}
+	}
+	if p.nbytes == len(p.format) {
+		return nil, fmt.Errorf("format %s is missing verb at end of string", p.format)
+	}
+	verb, w := utf8.DecodeRuneInString(p.format[p.nbytes:])
+	p.nbytes += w
+	op.Verb = Verb{Verb: verb, ArgIndex: -1}
+	if p.indexPending {
+		op.Verb.Index = p.index
+	}
+	if verb != '%' {
+		op.Verb.ArgIndex = p.consume()
+	}
+	op.Text = p.format[:p.nbytes]
+	return op, nil
+}
+
+// parseFlags accepts any printf flags.
+func (p *parser) parseFlags() string {
+	start := p.nbytes
+	for p.nbytes < len(p.format) {
+		switch p.format[p.nbytes] {
+		case '#', '0', '+', '-', ' ':
+			p.nbytes++
+		default:
+			return p.format[start:p.nbytes]
+		}
+	}
+	return p.format[start:p.nbytes]
+}
+
+// scanNum advances through a decimal number if present.
+func (p *parser) scanNum() {
+	for ; p.nbytes < len(p.format); p.nbytes++ {
+		c := p.format[p.nbytes]
+		if c < '0' || '9' < c {
+			return
+		}
+	}
+}
+
+// parseIndex scans an index expression, if present.
+func (p *parser) parseIndex() error {
+	if p.nbytes == len(p.format) || p.format[p.nbytes] != '[' {
+		return nil
+	}
+	// Argument index present.
+	p.nbytes++ // skip '['
+	start := p.nbytes
+	p.scanNum()
+	ok := true
+	if p.nbytes == len(p.format) || p.nbytes == start || p.format[p.nbytes] != ']' {
+		ok = false // syntax error is either missing "]" or invalid index.
+		p.nbytes = strings.Index(p.format[start:], "]")
+		if p.nbytes < 0 {
+			return fmt.Errorf("format %s is missing closing ]", p.format)
+		}
+		p.nbytes = p.nbytes + start
+	}
+	arg32, err := strconv.ParseInt(p.format[start:p.nbytes], 10, 32)
+	if err != nil || !ok || arg32 <= 0 {
+		return fmt.Errorf("format has invalid argument index [%s]", p.format[start:p.nbytes])
+	}
+	p.nbytes++ // skip ']'
+	p.index = int(arg32)
+	p.argNum = p.index - 1
+	p.indexPending = true
+	return nil
+}
+
+// parseSize scans a width or precision (or *).
+func (p *parser) parseSize() Size {
+	if p.nbytes < len(p.format) && p.format[p.nbytes] == '*' {
+		size := Size{Kind: Star}
+		if p.indexPending { // Absorb it.
+			size.Index = p.index
+			p.indexPending = false
+		}
+		p.nbytes++
+		size.ArgIndex = p.consume()
+		return size
+	}
+	start := p.nbytes
+	p.scanNum()
+	if p.nbytes == start {
+		return Size{Kind: NoSize}
+	}
+	n, _ := strconv.Atoi(p.format[start:p.nbytes])
+	return Size{Kind: Literal, Fixed: n}
+}
+
+// consume consumes the current operand and returns its index.
+func (p *parser) consume() int {
+	arg := p.argNum
+	p.lastArg = arg
+	p.argNum++
+	return arg
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmtstr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/internal/fmtstr"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string // the operations, or the error
+	}{
+		{"", ""},
+		{"hello", ""},
+		{"%d", "0:%d verb=d arg=0"},
+		{"x=%v y=%-3s", "2:%v verb=v arg=0 7:%-3s flags=- width=3 verb=s arg=1"},
+		{"100%%", "3:%% verb=%"},
+		{"%*d", "0:%*d width=*0 verb=d arg=1"},
+		{"%*.*f", "0:%*.*f flags=. width=*0 prec=*1 verb=f arg=2"},
+		{"%[2]d %d", "0:%[2]d verb=d[2] arg=1 6:%d verb=d arg=2"},
+		{"%[3]*.[2]*[1]f", "0:%[3]*.[2]*[1]f flags=. width=*[3]2 prec=*[2]1 verb=f[1] arg=0"},
+		{"%#-12.34f", "0:%#-12.34f flags=#-. width=12 prec=34 verb=f arg=0"},
+		{"héllo %q", "7:%q verb=q arg=0"},
+		{"%d %", "error: format % is missing verb at end of string"},
+		{"%[1", "error: format %[1 is missing closing ]"},
+		{"%[0]d", "error: format has invalid argument index [0]"},
+		{"%[x]d", "error: format has invalid argument index [x]"},
+	}
+	for _, test := range tests {
+		ops, err := fmtstr.Parse(test.format)
+		var got []string
+		if err != nil {
+			got = []string{"error: " + err.Error()}
+		} else {
+			for _, op := range ops {
+				got = append(got, describe(op))
+			}
+		}
+		if s := strings.Join(got, " "); s != test.want {
+			t.Errorf("Parse(%q) = %s, want %s", test.format, s, test.want)
+		}
+	}
+}
+
+func describe(op *fmtstr.Operation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d:%s", op.Start, op.Text)
+	if op.Flags != "" {
+		fmt.Fprintf(&b, " flags=%s", op.Flags)
+	}
+	size := func(name string, s fmtstr.Size) {
+		switch s.Kind {
+		case fmtstr.Literal:
+			fmt.Fprintf(&b, " %s=%d", name, s.Fixed)
+		case fmtstr.Star:
+			fmt.Fprintf(&b, " %s=*", name)
+			if s.Index > 0 {
+				fmt.Fprintf(&b, "[%d]", s.Index)
+			}
+			fmt.Fprintf(&b, "%d", s.ArgIndex)
+		}
+	}
+	size("width", op.Width)
+	size("prec", op.Prec)
+	fmt.Fprintf(&b, " verb=%c", op.Verb.Verb)
+	if op.Verb.Index > 0 {
+		fmt.Fprintf(&b, "[%d]", op.Verb.Index)
+	}
+	if op.Verb.ArgIndex >= 0 {
+		fmt.Fprintf(&b, " arg=%d", op.Verb.ArgIndex)
+	}
+	return b.String()
+}